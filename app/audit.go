@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+
+	"github.com/bobacgo/kit/app/audit"
+)
+
+const compAudit = "audit"
+
+// useAudit 按配置注册审计存储, 停止时关闭
+// 存储创建失败直接 panic (审计日志是合规要求, 不能静默丢失)
+func (o *AppOptions) useAudit() {
+	cfg := o.conf.Audit
+	var stores []audit.Store
+	if cfg.File.Path != "" {
+		s, err := audit.NewFileStore(cfg.File.Path, []byte(cfg.File.Key), cfg.File.Sync)
+		if err != nil {
+			log.Panicf("init audit file store failed: %v", err)
+		}
+		stores = append(stores, s)
+	}
+	if cfg.DB.Key != "" {
+		gdb := o.db.Get(cfg.DB.Key)
+		if gdb == nil {
+			log.Panicf("init audit db store failed: db %q not found", cfg.DB.Key)
+		}
+		s, err := audit.NewGormStore(gdb, cfg.DB.Table)
+		if err != nil {
+			log.Panicf("init audit db store failed: %v", err)
+		}
+		stores = append(stores, s)
+	}
+	if cfg.Kafka.Topic != "" {
+		if len(cfg.Kafka.Addrs) == 0 {
+			cfg.Kafka.Addrs = o.conf.Kafka.Addrs
+		}
+		s, err := audit.NewKafkaStore(cfg.Kafka)
+		if err != nil {
+			log.Panicf("init audit kafka store failed: %v", err)
+		}
+		stores = append(stores, s)
+	}
+	if len(stores) == 0 {
+		return
+	}
+
+	audit.Register(stores...)
+	components[compAudit] = struct{}{}
+	slog.Info(fmt.Sprintf(initDoneFmt, compAudit))
+	o.afterStop = append(o.afterStop, func(context.Context, *AppOptions) error {
+		if err := audit.Close(); err != nil {
+			slog.Error("[audit] close error", "err", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,138 @@
+// Package audit 审计日志
+/*
+	安全相关的事件 (登录、密码错误、配置重新加载、运维管理接口调用) 单独记录, 只追加不修改, 与普通日志分开:
+		1.事件结构固定 (见 Event), 不受日志级别、采样、脱敏等配置影响
+		2.存储可插拔 (见 Store), 内置:
+			file  哈希链文件 (HMAC), 每条记录包含上一条记录的哈希, 通过 Verify 或 go run ./cmd/audit verify 检测篡改
+			db    gorm 数据表
+			kafka Kafka topic (后台队列发送, 不阻塞调用方)
+		3.写入失败时记录错误日志并返回错误, 不影响业务
+
+	audit.Register(store)
+	audit.Record(ctx, audit.Event{Type: audit.TypeLogin, Actor: "admin", Outcome: audit.OutcomeSuccess})
+
+	未注册存储时 Record 不做任何处理
+*/
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bobacgo/kit/app/logger"
+	"github.com/bobacgo/kit/pkg/uid"
+)
+
+// EventType 事件类型
+type EventType string
+
+const (
+	TypeLogin          EventType = "auth.login"           // 颁发 token (JWToken.Generate)
+	TypePasswordFailed EventType = "auth.password_failed" // 密码验证失败 (PwdVerifier)
+	TypeConfigReload   EventType = "config.reload"        // 配置重新加载 (文件变化、密钥轮换)
+	TypeAdminCall      EventType = "admin.call"           // 运维管理接口调用
+)
+
+// Outcome 事件结果
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// OutcomeOf 根据错误返回事件结果
+func OutcomeOf(err error) Outcome {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+// Event 审计事件
+type Event struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	Type      EventType         `json:"type"`
+	Outcome   Outcome           `json:"outcome"`
+	Actor     string            `json:"actor,omitempty"`      // 操作者 (e.g. jwt subject、登录账号)
+	Action    string            `json:"action,omitempty"`     // 具体操作 (e.g. PUT /debug/loggers/:name)
+	Resource  string            `json:"resource,omitempty"`   // 操作对象 (e.g. 配置文件路径、模块名称)
+	Reason    string            `json:"reason,omitempty"`     // 失败原因
+	ClientIP  string            `json:"client_ip,omitempty"`  // 客户端 IP
+	AppID     string            `json:"app_id,omitempty"`     // 服务实例 ID
+	RequestID string            `json:"request_id,omitempty"` // 请求 ID
+	Metadata  map[string]string `json:"metadata,omitempty"`   // 其他信息
+}
+
+// Store 审计事件存储, 只追加
+type Store interface {
+	Append(ctx context.Context, e *Event) error
+	Close() error
+}
+
+var (
+	mu     sync.RWMutex
+	stores []Store
+)
+
+// Register 注册存储, 事件写入所有已注册的存储
+func Register(s ...Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	stores = append(stores, s...)
+}
+
+// Record 记录审计事件
+// ID、Time 为空时自动生成, AppID、RequestID 为空时取上下文日志字段 (见 logger.WithFields)
+// Actor 必须由调用方设置为认证后的身份, 不从上下文日志字段读取 (可能来自上游请求)
+func Record(ctx context.Context, e Event) error {
+	mu.RLock()
+	ss := stores
+	mu.RUnlock()
+	if len(ss) == 0 {
+		return nil
+	}
+
+	if e.ID == "" {
+		e.ID = uid.UUID()
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.AppID == "" {
+		e.AppID = logger.FieldValue(ctx, logger.FieldAppID)
+	}
+	if e.RequestID == "" {
+		e.RequestID = logger.FieldValue(ctx, logger.FieldRequestID)
+	}
+
+	var errs []error
+	for _, s := range ss {
+		if err := s.Append(ctx, &e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := errors.Join(errs...)
+	if err != nil {
+		slog.ErrorContext(ctx, "[audit] record error", "type", e.Type, "id", e.ID, "err", err)
+	}
+	return err
+}
+
+// Close 关闭并移除所有存储
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	var errs []error
+	for _, s := range stores {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	stores = nil
+	return errors.Join(errs...)
+}
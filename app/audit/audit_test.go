@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bobacgo/kit/app/logger"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	key := []byte("audit-key")
+	if _, err := NewFileStore(path, nil, true); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("expected ErrNoKey, got %v", err)
+	}
+	s, err := NewFileStore(path, key, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Register(s)
+	t.Cleanup(func() { Close() })
+
+	ctx := logger.WithFields(context.Background(), slog.String(logger.FieldRequestID, "r1"))
+	for _, typ := range []EventType{TypeLogin, TypePasswordFailed} {
+		if err := Record(ctx, Event{Type: typ, Outcome: OutcomeSuccess, Actor: "admin"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开后继续哈希链
+	s, err = NewFileStore(path, key, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(context.Background(), &Event{ID: "3", Type: TypeConfigReload}); err != nil {
+		t.Fatal(err)
+	}
+	seq, head := s.Head()
+	s.Close()
+	if seq != 3 {
+		t.Fatalf("unexpected seq %d", seq)
+	}
+
+	res, err := Verify(path, key, head)
+	if err != nil || res.Count != 3 {
+		t.Fatalf("verify %+v %v", res, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !bytes.Contains(data, []byte(`"request_id":"r1"`)) {
+		t.Errorf("request id not recorded: %s", data)
+	}
+
+	// 修改中间的记录
+	tampered := bytes.Replace(data, []byte(`"actor":"admin"`), []byte(`"actor":"guest"`), 1)
+	if _, err := VerifyReader(bytes.NewReader(tampered), key, ""); !IsTampered(err) || err.(*TamperError).Line != 1 {
+		t.Errorf("expected tamper error at line 1, got %v", err)
+	}
+	// 删除中间的记录
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	removed := bytes.Join([][]byte{lines[0], lines[2]}, nil)
+	if _, err := VerifyReader(bytes.NewReader(removed), key, ""); !IsTampered(err) || err.(*TamperError).Line != 2 {
+		t.Errorf("expected tamper error at line 2, got %v", err)
+	}
+	// 截断末尾的记录
+	if _, err := VerifyReader(bytes.NewReader(bytes.Join(lines[:2], nil)), key, head); !IsTampered(err) {
+		t.Errorf("expected head mismatch, got %v", err)
+	}
+
+	// 修改记录后重新计算之后所有记录的 hash, 没有 key 时无法通过校验
+	if _, err := VerifyReader(bytes.NewReader(rechain(t, tampered, []byte("guessed-key"))), key, ""); !IsTampered(err) || err.(*TamperError).Line != 1 {
+		t.Errorf("expected rewritten chain to be rejected, got %v", err)
+	}
+	if _, err := VerifyReader(bytes.NewReader(rechain(t, tampered, key)), key, ""); err != nil {
+		t.Errorf("chain rewritten with the real key should pass, got %v", err)
+	}
+}
+
+// rechain 使用 key 重新计算所有记录的 hash
+func rechain(t *testing.T, data, key []byte) []byte {
+	var (
+		out  bytes.Buffer
+		prev string
+	)
+	for _, line := range bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")) {
+		var rec chainRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			t.Fatal(err)
+		}
+		rec.Prev = prev
+		rec.Hash = chainHash(key, rec.Seq, rec.Prev, rec.Event)
+		prev = rec.Hash
+		b, _ := json.Marshal(rec)
+		out.Write(append(b, '\n'))
+	}
+	return out.Bytes()
+}
+
+func TestKafkaStoreQueue(t *testing.T) {
+	var (
+		started = make(chan struct{}, 4)
+		gate    = make(chan struct{})
+		sent    = make(chan string, 4)
+	)
+	s := newKafkaStore(1, func(e *Event) error {
+		started <- struct{}{}
+		<-gate
+		sent <- e.ID
+		return nil
+	})
+	go s.run()
+
+	// 发送阻塞时 Append 不等待, 队列已满时返回 ErrQueueFull
+	for _, id := range []string{"1", "2"} {
+		if err := s.Append(context.Background(), &Event{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+		if id == "1" {
+			<-started
+		}
+	}
+	if err := s.Append(context.Background(), &Event{ID: "x"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	close(gate)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 {
+		t.Errorf("expected queued events to be sent on close, got %d", len(sent))
+	}
+	if err := s.Append(context.Background(), &Event{ID: "3"}); !errors.Is(err, ErrStoreClosed) {
+		t.Errorf("expected ErrStoreClosed, got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package audit
+
+// Config 审计日志配置, 配置了的存储都会写入, 都没有配置时不记录
+type Config struct {
+	File  FileConfig  `mapstructure:"file"`
+	DB    DBConfig    `mapstructure:"db"`
+	Kafka KafkaConfig `mapstructure:"kafka"`
+}
+
+type FileConfig struct {
+	Path string `mapstructure:"path"`        // 文件路径 (e.g. ./logs/audit.log), 为空不启用
+	Key  string `mapstructure:"key" mask:""` // 哈希链的 HMAC 密钥, 必填, 不能与审计文件保存在一起 (e.g. ${AUDIT_KEY})
+	Sync bool   `mapstructure:"sync"`        // 每条记录写入后 fsync
+}
+
+type DBConfig struct {
+	Key   string `mapstructure:"key"`                         // 数据源 (db 配置的 key), 为空不启用
+	Table string `mapstructure:"table" default:"audit_event"` // 表名, 启动时自动创建
+}
+
+type KafkaConfig struct {
+	Addrs []string `mapstructure:"addrs"`                // 为空时使用 kafka.addrs
+	Topic string   `mapstructure:"topic"`                // 为空不启用
+	Queue int      `mapstructure:"queue" default:"1024"` // 发送队列长度, 队列已满时 Record 返回错误
+}
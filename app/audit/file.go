@@ -0,0 +1,239 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// 哈希链文件
+/*
+	每行一条记录 (JSON):
+		{"seq":1,"prev":"","hash":"...","event":{...}}
+
+	hash = HMAC-SHA256(key, "{seq}\n{prev}\n" + event), prev 为上一条记录的 hash (第一条为空)
+	修改、删除、插入、重排中间的记录都会导致链断开, 见 Verify
+	截断末尾的记录无法通过链本身发现, 需要与保存在其他位置的链头 (最后一条记录的 hash) 比较
+
+	key 通过配置 (e.g. 环境变量、密钥管理) 提供, 不能与审计文件保存在一起,
+	否则能修改文件的人可以重新计算之后所有记录的 hash
+*/
+
+// ErrNoKey 没有配置哈希链的密钥
+var ErrNoKey = errors.New("audit file: hmac key is required")
+
+// chainRecord 文件中的一条记录
+type chainRecord struct {
+	Seq   uint64          `json:"seq"`
+	Prev  string          `json:"prev"`
+	Hash  string          `json:"hash"`
+	Event json.RawMessage `json:"event"`
+}
+
+func chainHash(key []byte, seq uint64, prev string, event []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write(strconv.AppendUint(nil, seq, 10))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(prev))
+	h.Write([]byte{'\n'})
+	h.Write(event)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FileStore 哈希链文件存储
+type FileStore struct {
+	mu   sync.Mutex
+	f    *os.File
+	key  []byte
+	sync bool
+	seq  uint64
+	head string // 最后一条记录的 hash
+}
+
+// NewFileStore 打开 (不存在时创建) 哈希链文件, 从最后一条记录继续
+// key 为哈希链的 HMAC 密钥, 不能为空; sync 为 true 时每条记录写入后 fsync
+func NewFileStore(path string, key []byte, sync bool) (*FileStore, error) {
+	if len(key) == 0 {
+		return nil, ErrNoKey
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileStore{f: f, key: key, sync: sync}
+	line, err := lastLine(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if len(line) > 0 {
+		var rec chainRecord
+		if err := json.Unmarshal(line, &rec); err != nil || rec.Hash == "" {
+			f.Close()
+			return nil, fmt.Errorf("audit file %s: last record is corrupted, run verify", path)
+		}
+		s.seq, s.head = rec.Seq, rec.Hash
+	}
+	return s, nil
+}
+
+// Append 追加一条记录
+func (s *FileStore) Append(_ context.Context, e *Event) error {
+	event, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return os.ErrClosed
+	}
+	rec := chainRecord{Seq: s.seq + 1, Prev: s.head, Event: event}
+	rec.Hash = chainHash(s.key, rec.Seq, rec.Prev, event)
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("audit file write: %w", err)
+	}
+	if s.sync {
+		if err := s.f.Sync(); err != nil {
+			return fmt.Errorf("audit file sync: %w", err)
+		}
+	}
+	s.seq, s.head = rec.Seq, rec.Hash
+	return nil
+}
+
+// Head 最后一条记录的序号和 hash, 可保存到其他位置, 校验时用于发现末尾记录被截断
+func (s *FileStore) Head() (uint64, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq, s.head
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// lastLine 读取文件最后一行 (不含换行符)
+func lastLine(f *os.File) ([]byte, error) {
+	const chunk = 4096
+	end, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	var tail []byte
+	for pos := end; pos > 0; {
+		n := min(int64(chunk), pos)
+		pos -= n
+		buf := make([]byte, n)
+		if _, err := f.ReadAt(buf, pos); err != nil {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+	}
+	return bytes.TrimRight(tail, "\n"), nil
+}
+
+// TamperError 哈希链校验失败
+type TamperError struct {
+	Line   int // 行号, 从 1 开始
+	Reason string
+}
+
+func (e *TamperError) Error() string {
+	return fmt.Sprintf("audit chain broken at line %d: %s", e.Line, e.Reason)
+}
+
+// VerifyResult 校验结果
+type VerifyResult struct {
+	Count int    // 记录数
+	Head  string // 最后一条记录的 hash
+}
+
+// Verify 使用写入时的 key 校验哈希链文件, 链断开时返回 *TamperError
+// head 不为空时同时校验最后一条记录的 hash (发现末尾记录被截断)
+func Verify(path string, key []byte, head string) (VerifyResult, error) {
+	if len(key) == 0 {
+		return VerifyResult{}, ErrNoKey
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	defer f.Close()
+	return VerifyReader(f, key, head)
+}
+
+// VerifyReader 同 Verify
+func VerifyReader(r io.Reader, key []byte, head string) (VerifyResult, error) {
+	var (
+		res  VerifyResult
+		prev string
+		line int
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			return res, &TamperError{Line: line, Reason: "empty line"}
+		}
+		var rec chainRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return res, &TamperError{Line: line, Reason: "invalid record: " + err.Error()}
+		}
+		switch {
+		case rec.Seq != uint64(res.Count)+1:
+			return res, &TamperError{Line: line, Reason: fmt.Sprintf("seq %d, want %d", rec.Seq, res.Count+1)}
+		case rec.Prev != prev:
+			return res, &TamperError{Line: line, Reason: "prev hash mismatch"}
+		case !hmac.Equal([]byte(rec.Hash), []byte(chainHash(key, rec.Seq, rec.Prev, rec.Event))):
+			return res, &TamperError{Line: line, Reason: "hash mismatch"}
+		}
+		prev = rec.Hash
+		res.Count++
+		res.Head = rec.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return res, err
+	}
+	if head != "" && head != res.Head {
+		return res, &TamperError{Line: line, Reason: "head hash mismatch, records may be truncated"}
+	}
+	return res, nil
+}
+
+// IsTampered 是否为哈希链校验失败
+func IsTampered(err error) bool {
+	var te *TamperError
+	return errors.As(err, &te)
+}
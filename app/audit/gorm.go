@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bobacgo/kit/app/tenant"
+	"gorm.io/gorm"
+)
+
+// EventModel 审计事件表
+type EventModel struct {
+	ID        string    `gorm:"primaryKey;size:36"`
+	Time      time.Time `gorm:"index"`
+	Type      string    `gorm:"size:64;index"`
+	Outcome   string    `gorm:"size:16"`
+	Actor     string    `gorm:"size:128;index"`
+	Action    string    `gorm:"size:255"`
+	Resource  string    `gorm:"size:255"`
+	Reason    string    `gorm:"type:text"`
+	ClientIP  string    `gorm:"size:64"`
+	AppID     string    `gorm:"size:64"`
+	RequestID string    `gorm:"size:64"`
+	Metadata  string    `gorm:"type:text"` // JSON
+}
+
+// GormStore 数据表存储, 审计表是系统表, 不按租户隔离 (见 db 包的多租户说明)
+type GormStore struct {
+	db    *gorm.DB
+	table string
+}
+
+// NewGormStore 数据表存储, 表不存在时自动创建
+func NewGormStore(db *gorm.DB, table string) (*GormStore, error) {
+	if table == "" {
+		table = "audit_event"
+	}
+	if err := db.WithContext(tenant.Skip(db.Statement.Context)).Table(table).AutoMigrate(&EventModel{}); err != nil {
+		return nil, err
+	}
+	return &GormStore{db: db, table: table}, nil
+}
+
+func (s *GormStore) Append(ctx context.Context, e *Event) error {
+	m := EventModel{
+		ID:        e.ID,
+		Time:      e.Time,
+		Type:      string(e.Type),
+		Outcome:   string(e.Outcome),
+		Actor:     e.Actor,
+		Action:    e.Action,
+		Resource:  e.Resource,
+		Reason:    e.Reason,
+		ClientIP:  e.ClientIP,
+		AppID:     e.AppID,
+		RequestID: e.RequestID,
+	}
+	if len(e.Metadata) > 0 {
+		data, err := json.Marshal(e.Metadata)
+		if err != nil {
+			return err
+		}
+		m.Metadata = string(data)
+	}
+	return s.db.WithContext(tenant.Skip(ctx)).Table(s.table).Create(&m).Error
+}
+
+// Close 数据库连接由 db 组件管理, 这里不关闭
+func (s *GormStore) Close() error {
+	return nil
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bobacgo/kit/app/mq/kafka"
+)
+
+var (
+	ErrQueueFull   = errors.New("audit kafka queue is full")
+	ErrStoreClosed = errors.New("audit store is closed")
+)
+
+const (
+	kafkaMinBackoff   = time.Second
+	kafkaMaxBackoff   = time.Minute
+	kafkaCloseTimeout = 5 * time.Second
+)
+
+// KafkaStore Kafka topic 存储, 消息 key 为事件类型
+// Append 只把事件放入有界队列, 由后台协程发送, Kafka 不可用时按指数退避重试, 不阻塞调用方 (e.g. 登录)
+// 队列已满时返回 ErrQueueFull, Close 时在 kafkaCloseTimeout 内尽量发送剩余事件
+type KafkaStore struct {
+	topic string
+	pub   *kafka.LazyProducer
+	send  func(e *Event) error
+
+	queue   chan *Event
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+func NewKafkaStore(conf KafkaConfig) (*KafkaStore, error) {
+	if len(conf.Addrs) == 0 || conf.Topic == "" {
+		return nil, errors.New("audit kafka addrs or topic is empty")
+	}
+	// 审计事件不能丢失, 需要所有副本确认
+	pub := kafka.NewLazyProducer(conf.Addrs, kafka.ProducerConfig{RequiredAcks: kafka.RequiredAcksAll})
+	s := newKafkaStore(conf.Queue, nil)
+	s.topic, s.pub, s.send = conf.Topic, pub, s.publish
+	go s.run()
+	return s, nil
+}
+
+func newKafkaStore(size int, send func(e *Event) error) *KafkaStore {
+	if size <= 0 {
+		size = 1024
+	}
+	return &KafkaStore{
+		send:    send,
+		queue:   make(chan *Event, size),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *KafkaStore) Append(_ context.Context, e *Event) error {
+	ev := *e
+	select {
+	case <-s.done:
+		return ErrStoreClosed
+	default:
+	}
+	select {
+	case s.queue <- &ev:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (s *KafkaStore) publish(e *Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	pub, err := s.pub.Get()
+	if err != nil {
+		return err
+	}
+	// 上下文日志字段已写入事件, 不再通过 header 传递
+	return pub.SendMessage(context.Background(), s.topic, data, kafka.WithKey(string(e.Type)))
+}
+
+func (s *KafkaStore) run() {
+	defer close(s.stopped)
+	for {
+		select {
+		case <-s.done:
+			s.drain()
+			return
+		case e := <-s.queue:
+			s.retry(e)
+		}
+	}
+}
+
+// retry 发送失败时按指数退避重试, 直到成功或 Close
+func (s *KafkaStore) retry(e *Event) {
+	var backoff time.Duration
+	for {
+		err := s.send(e)
+		if err == nil {
+			return
+		}
+		backoff = min(max(backoff*2, kafkaMinBackoff), kafkaMaxBackoff)
+		slog.Error("[audit] kafka send error", "type", e.Type, "id", e.ID, "retry_in", backoff, "err", err)
+		select {
+		case <-s.done:
+			// 由 drain 再发送一次
+			s.requeue(e)
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (s *KafkaStore) requeue(e *Event) {
+	select {
+	case s.queue <- e:
+	default:
+		slog.Error("[audit] kafka event dropped on close", "type", e.Type, "id", e.ID)
+	}
+}
+
+// drain 关闭时每个剩余事件发送一次, 超时后放弃
+func (s *KafkaStore) drain() {
+	deadline := time.Now().Add(kafkaCloseTimeout)
+	var dropped int
+	for {
+		select {
+		case e := <-s.queue:
+			if time.Now().After(deadline) {
+				dropped++
+				continue
+			}
+			if err := s.send(e); err != nil {
+				dropped++
+				slog.Error("[audit] kafka send error", "type", e.Type, "id", e.ID, "err", err)
+			}
+		default:
+			if dropped > 0 {
+				slog.Error(fmt.Sprintf("[audit] %d kafka events not sent on close", dropped))
+			}
+			return
+		}
+	}
+}
+
+func (s *KafkaStore) Close() error {
+	s.once.Do(func() { close(s.done) })
+	// 正在进行的发送可能等待 sarama 超时, 不无限阻塞停止流程
+	select {
+	case <-s.stopped:
+	case <-time.After(kafkaCloseTimeout + time.Second):
+		return fmt.Errorf("audit kafka close timeout, %d events pending", len(s.queue))
+	}
+	if s.pub == nil {
+		return nil
+	}
+	return s.pub.Close()
+}
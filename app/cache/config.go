@@ -12,6 +12,7 @@ type RedisConf struct {
 	PoolSize     int            `mapstructure:"poolSize" yaml:"poolSize"`
 	ReadTimeout  types.Duration `mapstructure:"readTimeout" yaml:"readTimeout" validate:"duration"`   // 0.2s
 	WriteTimeout types.Duration `mapstructure:"writeTimeout" yaml:"writeTimeout" validate:"duration"` // 0.2s
+	Tenant       bool           `mapstructure:"tenant"`                                               // key 添加 ctx 中的租户前缀, 见 UseTenant
 }
 
 type LocalCacheConf struct {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/extra/redisotel/v9"
@@ -22,17 +24,19 @@ func NewRedis(appName string, cfg RedisConf) (redis.UniversalClient, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
+	creds := new(credentials)
+	creds.set(cfg.Username, cfg.Password)
+
 	if len(cfg.Addrs) > 1 {
 		clt := redis.NewClusterClient(&redis.ClusterOptions{
-			Addrs:          cfg.Addrs, // []string{"IP_ADDRESS:6379"},
-			ClientName:     appName,   // client name 方便监控和管理客户端连接
-			ReadOnly:       true,      // 允许从节点可以执行读命令 GET、MGET、HGETALL、ZRANGE、SCAN 等，减轻主节点压力
-			RouteByLatency: true,      // 按照延迟最低的节点进行读操作（主/从均可） 自动启用 ReadOnly
-			Username:       cfg.Username,
-			Password:       cfg.Password,
-			PoolSize:       cfg.PoolSize,
-			ReadTimeout:    cfg.ReadTimeout.TimeDuration(),  // 读取超时时间 默认是 3 秒
-			WriteTimeout:   cfg.WriteTimeout.TimeDuration(), // 写入超时时间 默认是 5 秒
+			Addrs:               cfg.Addrs, // []string{"IP_ADDRESS:6379"},
+			ClientName:          appName,   // client name 方便监控和管理客户端连接
+			ReadOnly:            true,      // 允许从节点可以执行读命令 GET、MGET、HGETALL、ZRANGE、SCAN 等，减轻主节点压力
+			RouteByLatency:      true,      // 按照延迟最低的节点进行读操作（主/从均可） 自动启用 ReadOnly
+			CredentialsProvider: creds.get, // 支持凭据轮换
+			PoolSize:            cfg.PoolSize,
+			ReadTimeout:         cfg.ReadTimeout.TimeDuration(),  // 读取超时时间 默认是 3 秒
+			WriteTimeout:        cfg.WriteTimeout.TimeDuration(), // 写入超时时间 默认是 5 秒
 		})
 		err = clt.ForEachShard(ctx, func(ctx context.Context, shard *redis.Client) error {
 			return shard.Ping(ctx).Err()
@@ -40,14 +44,13 @@ func NewRedis(appName string, cfg RedisConf) (redis.UniversalClient, error) {
 		rdb = clt
 	} else {
 		clt := redis.NewClient(&redis.Options{
-			Addr:         cfg.Addrs[0], // "IP_ADDRESS:6379",
-			ClientName:   appName,      // client name 方便监控和管理客户端连接
-			Username:     cfg.Username,
-			Password:     cfg.Password,                    // no password set
-			DB:           int(cfg.DB),                     // use default DB
-			PoolSize:     cfg.PoolSize,                    // connection pool size 100
-			ReadTimeout:  cfg.ReadTimeout.TimeDuration(),  // 读取超时时间 默认是 3 秒
-			WriteTimeout: cfg.WriteTimeout.TimeDuration(), // 写入超时时间 默认是 5 秒
+			Addr:                cfg.Addrs[0],                    // "IP_ADDRESS:6379",
+			ClientName:          appName,                         // client name 方便监控和管理客户端连接
+			CredentialsProvider: creds.get,                       // 支持凭据轮换
+			DB:                  int(cfg.DB),                     // use default DB
+			PoolSize:            cfg.PoolSize,                    // connection pool size 100
+			ReadTimeout:         cfg.ReadTimeout.TimeDuration(),  // 读取超时时间 默认是 3 秒
+			WriteTimeout:        cfg.WriteTimeout.TimeDuration(), // 写入超时时间 默认是 5 秒
 		})
 		err = clt.Ping(ctx).Err()
 		rdb = clt
@@ -61,5 +64,27 @@ func NewRedis(appName string, cfg RedisConf) (redis.UniversalClient, error) {
 		return nil, fmt.Errorf("enable redis tracing failed: %w", err)
 	}
 
+	if cfg.Tenant {
+		UseTenant(rdb)
+	}
+
+	credentialsOf.Store(rdb, creds)
 	return rdb, nil
 }
+
+// credentials 连接 Redis 时使用的用户名和密码
+// 建立新连接时读取, 凭据轮换后无需重建 client
+type credentials struct {
+	v atomic.Pointer[[2]string]
+}
+
+func (c *credentials) set(username, password string) {
+	c.v.Store(&[2]string{username, password})
+}
+
+func (c *credentials) get() (string, string) {
+	v := c.v.Load()
+	return v[0], v[1]
+}
+
+var credentialsOf sync.Map // redis.UniversalClient -> *credentials
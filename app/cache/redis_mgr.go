@@ -36,3 +36,19 @@ func (m RedisManager) Default() redis.UniversalClient {
 func (m RedisManager) Get(k string) redis.UniversalClient {
 	return m[k]
 }
+
+// UpdateCredentials 更新实例 k 的用户名和密码 (e.g. 密钥轮换)
+// 新建立的连接使用新凭据, 已认证的连接不受影响, 由连接池按空闲时间和错误自然淘汰
+func (m RedisManager) UpdateCredentials(k string, cfg RedisConf) error {
+	rdb, ok := m[k]
+	if !ok {
+		return fmt.Errorf("redis instance %s not found", k)
+	}
+	v, ok := credentialsOf.Load(rdb)
+	if !ok {
+		return fmt.Errorf("redis instance %s does not support credentials rotation", k)
+	}
+	v.(*credentials).set(cfg.Username, cfg.Password)
+	slog.Info(fmt.Sprintf("[redis] instance %s credentials updated", k))
+	return nil
+}
@@ -0,0 +1,281 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobacgo/kit/app/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+// WithTenant ctx 中有租户时 key 添加租户前缀 ({tenant}:key), 见 tenant.KeyPrefix
+// Cache 的方法没有 ctx, 需要在每个请求中包装
+//
+//	cache.WithTenant(ctx, localCache).Set("user:1", user, time.Minute)
+func WithTenant(ctx context.Context, c Cache) Cache {
+	prefix := tenant.KeyPrefix(ctx)
+	if prefix == "" {
+		return c
+	}
+	return &tenantCache{Cache: c, prefix: prefix}
+}
+
+type tenantCache struct {
+	Cache
+	prefix string
+}
+
+func (c *tenantCache) Set(key string, val any, expire time.Duration) error {
+	return c.Cache.Set(c.prefix+key, val, expire)
+}
+
+func (c *tenantCache) Get(key string, result any) error {
+	return c.Cache.Get(c.prefix+key, result)
+}
+
+func (c *tenantCache) Del(key string) bool {
+	return c.Cache.Del(c.prefix + key)
+}
+
+func (c *tenantCache) Exists(key string) bool {
+	return c.Cache.Exists(c.prefix + key)
+}
+
+// UseTenant 命令的 key 按 ctx 中的租户添加前缀, 见 tenant.KeyPrefix
+// 1.key 的位置见 commandKeys (同 COMMAND INFO 的 first/last/step), 不在表中的命令返回 ErrUnknownCommand, 避免 key 落到共享的空间
+// 2.作用于整个库的命令 (FLUSHDB、FLUSHALL、KEYS、SCAN ...) 返回 ErrUnknownCommand, 需要 tenant.Skip 后显式执行
+// 3.频道 (PUBLISH、SUBSCRIBE) 不处理, 返回值中的 key 不会去掉前缀
+func UseTenant(rdb redis.UniversalClient) {
+	rdb.AddHook(tenantHook{})
+}
+
+// ErrUnknownCommand 开启租户时不知道 key 位置的命令, 可以使用 tenant.Skip 跳过后自行添加前缀
+var ErrUnknownCommand = errors.New("cache: unknown key positions for command")
+
+type tenantHook struct{}
+
+func (tenantHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (tenantHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if prefix := tenant.KeyPrefix(ctx); prefix != "" {
+			if err := prefixKeys(prefix, cmd.Args()); err != nil {
+				cmd.SetErr(err)
+				return err
+			}
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (tenantHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if prefix := tenant.KeyPrefix(ctx); prefix != "" {
+			for _, cmd := range cmds {
+				if err := prefixKeys(prefix, cmd.Args()); err != nil {
+					for _, c := range cmds {
+						c.SetErr(err)
+					}
+					return err
+				}
+			}
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// keySpec 命令中 key 的位置
+type keySpec struct {
+	first, last, step int    // 同 COMMAND INFO, first 为 0 时没有 key, last 为负数时从末尾计算 (-1 为最后一个参数)
+	numkeys           int    // 大于 0 时为 numkeys 参数的位置, 之后的 numkeys 个参数为 key
+	keyword           string // keyword 之后剩余参数的前一半为 key (XREAD ... STREAMS k1 k2 id1 id2)
+	store             bool   // STORE、STOREDIST 之后的参数为 key (SORT、GEORADIUS)
+}
+
+var (
+	noKeys    = keySpec{}
+	firstKey  = keySpec{first: 1, last: 1, step: 1}
+	twoKeys   = keySpec{first: 1, last: 2, step: 1}
+	allKeys   = keySpec{first: 1, last: -1, step: 1}
+	blockKeys = keySpec{first: 1, last: -2, step: 1} // 最后一个参数为 timeout
+)
+
+// commandKeys 命令 -> key 的位置
+var commandKeys = map[string]keySpec{
+	// 没有 key
+	"ping": noKeys, "echo": noKeys, "info": noKeys, "auth": noKeys, "hello": noKeys, "select": noKeys, "quit": noKeys,
+	"reset": noKeys, "client": noKeys, "config": noKeys, "cluster": noKeys, "command": noKeys, "acl": noKeys,
+	"dbsize": noKeys, "time": noKeys, "script": noKeys, "function": noKeys,
+	"publish": noKeys, "spublish": noKeys, "subscribe": noKeys,
+	"unsubscribe": noKeys, "psubscribe": noKeys, "punsubscribe": noKeys, "ssubscribe": noKeys, "sunsubscribe": noKeys,
+	"pubsub": noKeys, "multi": noKeys, "exec": noKeys, "discard": noKeys, "unwatch": noKeys, "readonly": noKeys,
+	"readwrite": noKeys, "wait": noKeys, "waitaof": noKeys, "role": noKeys, "slowlog": noKeys, "latency": noKeys,
+	"debug": noKeys, "save": noKeys, "bgsave": noKeys, "bgrewriteaof": noKeys, "lastsave": noKeys,
+	"shutdown": noKeys, "module": noKeys,
+	// flushdb、flushall、swapdb、keys、scan、randomkey 作用于所有租户的 key, 不在表中 (返回 ErrUnknownCommand), 需要 tenant.Skip
+
+	// 子命令之后为 key
+	"object": {first: 2, last: 2, step: 1}, "memory": {first: 2, last: 2, step: 1},
+	"xinfo": {first: 2, last: 2, step: 1}, "xgroup": {first: 2, last: 2, step: 1},
+
+	// string
+	"get": firstKey, "set": firstKey, "setnx": firstKey, "setex": firstKey, "psetex": firstKey, "getset": firstKey,
+	"getdel": firstKey, "getex": firstKey, "append": firstKey, "strlen": firstKey, "incr": firstKey, "incrby": firstKey,
+	"incrbyfloat": firstKey, "decr": firstKey, "decrby": firstKey, "getrange": firstKey, "setrange": firstKey,
+	"substr": firstKey, "getbit": firstKey, "setbit": firstKey, "bitcount": firstKey, "bitpos": firstKey,
+	"bitfield": firstKey, "bitfield_ro": firstKey, "lcs": twoKeys,
+	"mget": allKeys, "mset": {first: 1, last: -1, step: 2}, "msetnx": {first: 1, last: -1, step: 2},
+	"bitop": {first: 2, last: -1, step: 1},
+
+	// generic
+	"del": allKeys, "unlink": allKeys, "exists": allKeys, "touch": allKeys, "watch": allKeys,
+	"type": firstKey, "expire": firstKey, "pexpire": firstKey, "expireat": firstKey, "pexpireat": firstKey,
+	"expiretime": firstKey, "pexpiretime": firstKey, "ttl": firstKey, "pttl": firstKey, "persist": firstKey,
+	"dump": firstKey, "restore": firstKey, "move": firstKey, "rename": twoKeys, "renamenx": twoKeys, "copy": twoKeys,
+	"sort": {first: 1, last: 1, step: 1, store: true}, "sort_ro": firstKey,
+
+	// hash
+	"hset": firstKey, "hsetnx": firstKey, "hget": firstKey, "hmset": firstKey, "hmget": firstKey, "hdel": firstKey,
+	"hlen": firstKey, "hkeys": firstKey, "hvals": firstKey, "hgetall": firstKey, "hexists": firstKey,
+	"hincrby": firstKey, "hincrbyfloat": firstKey, "hstrlen": firstKey, "hscan": firstKey, "hrandfield": firstKey,
+	"hexpire": firstKey, "hpexpire": firstKey, "hexpireat": firstKey, "hpexpireat": firstKey, "httl": firstKey,
+	"hpttl": firstKey, "hexpiretime": firstKey, "hpexpiretime": firstKey, "hpersist": firstKey,
+	"hgetdel": firstKey, "hgetex": firstKey, "hsetex": firstKey,
+
+	// list
+	"lpush": firstKey, "rpush": firstKey, "lpushx": firstKey, "rpushx": firstKey, "lpop": firstKey, "rpop": firstKey,
+	"llen": firstKey, "lrange": firstKey, "lindex": firstKey, "lset": firstKey, "lrem": firstKey, "ltrim": firstKey,
+	"linsert": firstKey, "lpos": firstKey, "rpoplpush": twoKeys, "lmove": twoKeys,
+	"brpoplpush": twoKeys, "blmove": twoKeys, "blpop": blockKeys, "brpop": blockKeys,
+	"lmpop": {numkeys: 1}, "blmpop": {numkeys: 2},
+
+	// set
+	"sadd": firstKey, "srem": firstKey, "smembers": firstKey, "sismember": firstKey, "smismember": firstKey,
+	"scard": firstKey, "spop": firstKey, "srandmember": firstKey, "sscan": firstKey, "smove": twoKeys,
+	"sinter": allKeys, "sunion": allKeys, "sdiff": allKeys,
+	"sinterstore": allKeys, "sunionstore": allKeys, "sdiffstore": allKeys, "sintercard": {numkeys: 1},
+
+	// sorted set
+	"zadd": firstKey, "zrem": firstKey, "zcard": firstKey, "zcount": firstKey, "zscore": firstKey,
+	"zmscore": firstKey, "zincrby": firstKey, "zrank": firstKey, "zrevrank": firstKey, "zrange": firstKey,
+	"zrangebyscore": firstKey, "zrangebylex": firstKey, "zrevrange": firstKey, "zrevrangebyscore": firstKey,
+	"zrevrangebylex": firstKey, "zremrangebyrank": firstKey, "zremrangebyscore": firstKey,
+	"zremrangebylex": firstKey, "zlexcount": firstKey, "zpopmin": firstKey, "zpopmax": firstKey,
+	"zrandmember": firstKey, "zscan": firstKey, "zrangestore": twoKeys, "bzpopmin": blockKeys, "bzpopmax": blockKeys,
+	"zunionstore": {first: 1, last: 1, step: 1, numkeys: 2}, "zinterstore": {first: 1, last: 1, step: 1, numkeys: 2},
+	"zdiffstore": {first: 1, last: 1, step: 1, numkeys: 2},
+	"zunion":     {numkeys: 1}, "zinter": {numkeys: 1}, "zdiff": {numkeys: 1}, "zintercard": {numkeys: 1},
+	"zmpop": {numkeys: 1}, "bzmpop": {numkeys: 2},
+
+	// geo
+	"geoadd": firstKey, "geodist": firstKey, "geohash": firstKey, "geopos": firstKey, "geosearch": firstKey,
+	"georadius_ro": firstKey, "georadiusbymember_ro": firstKey, "geosearchstore": twoKeys,
+	"georadius": {first: 1, last: 1, step: 1, store: true}, "georadiusbymember": {first: 1, last: 1, step: 1, store: true},
+
+	// hyperloglog
+	"pfadd": firstKey, "pfcount": allKeys, "pfmerge": allKeys,
+
+	// stream
+	"xadd": firstKey, "xlen": firstKey, "xrange": firstKey, "xrevrange": firstKey, "xdel": firstKey,
+	"xtrim": firstKey, "xack": firstKey, "xpending": firstKey, "xclaim": firstKey, "xautoclaim": firstKey,
+	"xsetid": firstKey, "xread": {keyword: "streams"}, "xreadgroup": {keyword: "streams"},
+
+	// script
+	"eval": {numkeys: 2}, "evalsha": {numkeys: 2}, "eval_ro": {numkeys: 2}, "evalsha_ro": {numkeys: 2},
+	"fcall": {numkeys: 2}, "fcall_ro": {numkeys: 2},
+}
+
+// prefixKeys 原地修改命令参数, 先计算所有 key 的位置, 出错时不修改
+func prefixKeys(prefix string, args []any) error {
+	if len(args) == 0 {
+		return nil
+	}
+	name := strings.ToLower(argString(args[0]))
+	spec, ok := commandKeys[name]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownCommand, name)
+	}
+	keys, err := spec.positions(args)
+	if err != nil {
+		return fmt.Errorf("cache: %s: %w", name, err)
+	}
+	for _, i := range keys {
+		args[i] = prefixArg(prefix, args[i])
+	}
+	return nil
+}
+
+// positions key 在 args 中的下标
+func (s keySpec) positions(args []any) ([]int, error) {
+	var keys []int
+	if s.first > 0 {
+		last := s.last
+		if last < 0 {
+			last += len(args)
+		}
+		for i := s.first; i <= last && i < len(args); i += s.step {
+			keys = append(keys, i)
+		}
+	}
+	if s.numkeys > 0 && s.numkeys < len(args) {
+		n, err := strconv.Atoi(argString(args[s.numkeys]))
+		if err != nil || n < 0 || s.numkeys+n >= len(args) {
+			return nil, fmt.Errorf("invalid numkeys %v", args[s.numkeys])
+		}
+		for i := s.numkeys + 1; i <= s.numkeys+n; i++ {
+			keys = append(keys, i)
+		}
+	}
+	start := 1
+	if len(keys) > 0 {
+		start = keys[len(keys)-1] + 1
+	}
+	for i := start; i < len(args); i++ {
+		word := strings.ToLower(argString(args[i]))
+		switch {
+		case s.keyword != "" && word == s.keyword:
+			rest := len(args) - i - 1
+			if rest == 0 || rest%2 != 0 {
+				return nil, fmt.Errorf("unbalanced %s arguments", s.keyword)
+			}
+			for j := i + 1; j <= i+rest/2; j++ {
+				keys = append(keys, j)
+			}
+			return keys, nil
+		case s.store && (word == "store" || word == "storedist") && i+1 < len(args):
+			keys = append(keys, i+1)
+			i++
+		}
+	}
+	return keys, nil
+}
+
+func prefixArg(prefix string, arg any) any {
+	switch v := arg.(type) {
+	case string:
+		return prefix + v
+	case []byte:
+		return append([]byte(prefix), v...)
+	}
+	return arg
+}
+
+func argString(arg any) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bobacgo/kit/app/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestPrefixKeys(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		cmd  redis.Cmder
+		want string
+	}{
+		{redis.NewStringCmd(ctx, "get", "k"), "[get t1:k]"},
+		{redis.NewStatusCmd(ctx, "set", "k", "v", "ex", 10), "[set t1:k v ex 10]"},
+		{redis.NewSliceCmd(ctx, "mget", "a", "b"), "[mget t1:a t1:b]"},
+		{redis.NewStatusCmd(ctx, "mset", "a", "1", "b", "2"), "[mset t1:a 1 t1:b 2]"},
+		{redis.NewCmd(ctx, "eval", "return 1", 2, "a", "b", "arg"), "[eval return 1 2 t1:a t1:b arg]"},
+		{redis.NewStatusCmd(ctx, "ping"), "[ping]"},
+		{redis.NewIntCmd(ctx, "publish", "ch", "msg"), "[publish ch msg]"},
+		{redis.NewStringCmd(ctx, "rpoplpush", "src", "dst"), "[rpoplpush t1:src t1:dst]"},
+		{redis.NewStringSliceCmd(ctx, "blpop", "a", "b", 0), "[blpop t1:a t1:b 0]"},
+		{redis.NewIntCmd(ctx, "zunionstore", "dest", 2, "k1", "k2", "weights", 1, 2), "[zunionstore t1:dest 2 t1:k1 t1:k2 weights 1 2]"},
+		{redis.NewIntCmd(ctx, "bitop", "AND", "dest", "k1"), "[bitop AND t1:dest t1:k1]"},
+		{redis.NewStringCmd(ctx, "object", "encoding", "k"), "[object encoding t1:k]"},
+		{redis.NewXStreamSliceCmd(ctx, "xread", "count", 1, "streams", "s1", "s2", "0", "0"), "[xread count 1 streams t1:s1 t1:s2 0 0]"},
+		{redis.NewIntCmd(ctx, "sort", "k", "by", "w_*", "store", "dst"), "[sort t1:k by w_* store t1:dst]"},
+	}
+	for _, c := range cases {
+		if err := prefixKeys("t1:", c.cmd.Args()); err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(c.cmd.Args()); got != c.want {
+			t.Errorf("got %s, want %s", got, c.want)
+		}
+	}
+}
+
+func TestPrefixKeysUnknown(t *testing.T) {
+	cmd := redis.NewCmd(context.Background(), "json.get", "k", "$")
+	if err := prefixKeys("t1:", cmd.Args()); !errors.Is(err, ErrUnknownCommand) {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(cmd.Args()); got != "[json.get k $]" {
+		t.Fatal(got)
+	}
+
+	// 经过 hook 时命令返回错误, 不会发送
+	called := false
+	process := tenantHook{}.ProcessHook(func(context.Context, redis.Cmder) error {
+		called = true
+		return nil
+	})
+	ctx, err := tenant.WithID(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process(ctx, cmd); !errors.Is(err, ErrUnknownCommand) || !errors.Is(cmd.Err(), ErrUnknownCommand) || called {
+		t.Fatal(err, called)
+	}
+	if err := process(context.Background(), cmd); err != nil || !called {
+		t.Fatal(err, called)
+	}
+}
+
+func TestPrefixKeysKeyspace(t *testing.T) {
+	ctx, err := tenant.WithID(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]any{{"flushdb"}, {"FLUSHALL", "async"}, {"swapdb", 0, 1}, {"keys", "*"}, {"scan", 0, "match", "*"}, {"randomkey"}} {
+		called := false
+		process := tenantHook{}.ProcessHook(func(context.Context, redis.Cmder) error {
+			called = true
+			return nil
+		})
+		cmd := redis.NewCmd(ctx, args...)
+		if err := process(ctx, cmd); !errors.Is(err, ErrUnknownCommand) || called {
+			t.Errorf("%v: %v, called %v", args, err, called)
+		}
+		// tenant.Skip 后不处理
+		if err := process(tenant.Skip(ctx), cmd); err != nil || !called {
+			t.Errorf("%v skip: %v, called %v", args, err, called)
+		}
+	}
+}
+
+// mapCache 记录写入的 key
+type mapCache struct {
+	Cache
+	m map[string]any
+}
+
+func (c *mapCache) Set(key string, val any, _ time.Duration) error {
+	c.m[key] = val
+	return nil
+}
+
+func (c *mapCache) Get(key string, result any) error {
+	v, ok := c.m[key]
+	if !ok {
+		return fmt.Errorf("%s not found", key)
+	}
+	*result.(*string) = v.(string)
+	return nil
+}
+
+func TestWithTenant(t *testing.T) {
+	c := &mapCache{m: make(map[string]any)}
+	t1, err := tenant.WithID(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WithTenant(t1, c).Set("k", "v1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithTenant(context.Background(), c).Set("k", "v0", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	var v string
+	if err := WithTenant(t1, c).Get("k", &v); err != nil || v != "v1" {
+		t.Fatal(v, err)
+	}
+	if err := c.Get("t1:k", &v); err != nil || v != "v1" {
+		t.Fatal(v, err)
+	}
+	if err := c.Get("k", &v); err != nil || v != "v0" {
+		t.Fatal(v, err)
+	}
+}
@@ -20,8 +20,10 @@ func NewGRPC(transport conf.Transport, opts ...grpc.DialOption) (*grpc.ClientCon
 		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 		grpc.WithChainUnaryInterceptor(
 			timeout.UnaryClientInterceptor(transport.Timeout.TimeDuration()),
+			interceptor.ClientLogFields(), // 向下游传递上下文日志字段
 			logging.UnaryClientInterceptor(interceptor.Logger(), logging.WithFieldsFromContext(interceptor.LogTraceID))),
 		grpc.WithChainStreamInterceptor(
+			interceptor.StreamClientLogFields(),
 			logging.StreamClientInterceptor(interceptor.Logger(), logging.WithFieldsFromContext(interceptor.LogTraceID))),
 	}
 
@@ -1,11 +1,14 @@
 package conf
 
 import (
+	"github.com/bobacgo/kit/app/audit"
 	"github.com/bobacgo/kit/app/cache"
 	"github.com/bobacgo/kit/app/db"
 	"github.com/bobacgo/kit/app/logger"
 	"github.com/bobacgo/kit/app/mq/kafka"
 	"github.com/bobacgo/kit/app/otel"
+	"github.com/bobacgo/kit/app/outbox"
+	"github.com/bobacgo/kit/app/secret"
 	"github.com/bobacgo/kit/app/security"
 	"github.com/bobacgo/kit/app/server/gateway"
 	"github.com/bobacgo/kit/app/types"
@@ -20,11 +23,16 @@ import (
 	4.配置值默认值
 	5.配置特殊类型解析
 	6.支持配置值脱敏输出
-	7.支持多配置文件
-	优先级: (相同key)
+	7.支持多配置文件 (include 支持 glob)
+	8.支持环境配置文件 config.{env}.yaml 自动合并
+	9.支持环境变量插值 ${VAR:default}
+	10.支持密钥引用 secret://provider/path (env、file、vault), 定时刷新
+	优先级: (相同key, 从低到高)
 
-		1.主配置文件优先级最高
-		2.configs 数组索引越小优先级越高
+		1.configs 数组 (已废弃, 索引越小优先级越高)
+		2.include 引入的文件
+		3.主配置文件
+		4.环境配置文件
 */
 type App[T any] struct {
 	Basic   `mapstructure:",squash"`
@@ -36,15 +44,19 @@ type Basic struct {
 	Name    string       `mapstructure:"name" validate:"required"`  // 服务名称
 	Version string       `mapstructure:"version" validate:"semver"` // 服务版本
 	Env     enum.EnvType `mapstructure:"env" validate:"oneof=dev test prod"`
-	// 和主配置文件的在同一个目录可以只写文件名加后缀
+	// Deprecated: 使用 Include 代替
 	Configs []string `mapstructure:"configs"` // 其他配置文件的路径
+	// 引入其他配置文件, 相对路径基于当前配置文件所在目录, 支持 glob (e.g. ./deploy/*.yaml)
+	Include []string `mapstructure:"include"`
 	// 注册中心的地址
 	Registry Transport `mapstructure:"registry"`
 	Server   struct {
-		Http Transport `mapstructure:"http"`
-		Rpc  Transport `mapstructure:"rpc"` // rpc 端口号没有指定,就是http端口号+1000
+		Http  Transport `mapstructure:"http"`
+		Rpc   Transport `mapstructure:"rpc"`   // rpc 端口号没有指定,就是http端口号+1000
+		Admin Admin     `mapstructure:"admin"` // 运维管理接口 (/debug/config、/debug/loggers 和 gRPC kit.admin.v1.Admin)
 	} `mapstructure:"server"`
 	Security    security.Config            `mapstructure:"security"`
+	Secret      secret.Config              `mapstructure:"secret"` // 密钥引用的解析配置
 	Logger      logger.Config              `mapstructure:"logger"`
 	DB          map[string]db.Config       `mapstructure:"db"` // 支持多数据源 default key 必须存在
 	LocalCache  cache.LocalCacheConf       `mapstructure:"localCache" yaml:"localCache"`
@@ -52,6 +64,14 @@ type Basic struct {
 	Kafka       kafka.Config               `mapstructure:"kafka"`
 	GrpcGateway *gateway.Config            `mapstructure:"gateway" yaml:"gateway"`
 	Otel        *otel.Config               `mapstructure:"otel" yaml:"otel"` // otel 配置
+	Audit       audit.Config               `mapstructure:"audit"`            // 审计日志
+	Outbox      outbox.Config              `mapstructure:"outbox"`           // 发件箱 relay (app.WithOutbox)
+}
+
+// Admin 运维管理接口, 注册在 http、rpc 的业务端口上
+type Admin struct {
+	Enabled bool   `mapstructure:"enabled"`       // 是否注册, 默认关闭
+	Token   string `mapstructure:"token" mask:""` // 调用方令牌 (Authorization: Bearer {token}), 为空时不校验
 }
 
 type Transport struct {
@@ -1,11 +1,14 @@
 package conf
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log/slog"
 	"sync/atomic"
 
+	"github.com/bobacgo/kit/app/audit"
+	"github.com/bobacgo/kit/app/secret"
 	"github.com/bobacgo/kit/pkg/tag"
 
 	"github.com/bobacgo/kit/app/validator"
@@ -38,56 +41,90 @@ func SetApp[T any](appCfg *App[T]) {
 }
 
 // LoadApp 加载配置文件
-// 配置文件有变化时,会自动全部重新加载配置文件
-// 优先级: (相同key)
+// 配置文件有变化时,会自动全部重新加载配置文件 (新引入的文件、新建的环境配置文件同样会触发, 见 watcher)
+// 优先级及合并规则见 profile.go
+// 值为 secret://provider/path 的配置项会被解析为密钥 (见 secret 包)
 //
-//	1.主配置文件优先级最高
-//	2.configs 数组索引越小优先级越高
+//	configs < include < 主配置文件 < 环境配置文件 config.{env}.yaml
 func LoadApp[T any](filepath string, onChange func(e fsnotify.Event)) (*App[T], error) {
-	cfg := new(App[T])
-
-	if onChange != nil {
-		onChange = reload[T](filepath, onChange)
+	cfg, l, err := loadApp[T](filepath)
+	if err != nil {
+		return nil, err
 	}
-
-	// 加载主配置文件
-	if err := Load(filepath, cfg, onChange); err != nil {
+	if cfg, err = applyApp(cfg, l); err != nil {
 		return nil, err
 	}
-
-	// 加载其他配置文件
-	// configs 数组索引越小优先级越高
-	for i := len(cfg.Configs) - 1; i >= 0; i-- {
-		configPath := cfg.Configs[i] // 捕获循环变量
-		if err := Load(configPath, cfg, onChange); err != nil {
-			return nil, err
+	if onChange != nil { // 加载成功之后再监听
+		w, err := newWatcher()
+		if err != nil {
+			return nil, fmt.Errorf("watch config error: %w", err)
 		}
+		w.update(l)
+		go w.run(reload[T](filepath, w, onChange))
 	}
+	return cfg, nil
+}
 
-	// 主配置文件优先级最高,最后加载以覆盖其他配置
-	if len(cfg.Configs) > 0 {
-		if err := Load(filepath, cfg, nil); err != nil {
-			return nil, err
-		}
+// loadApp 按优先级合并配置文件并校验
+func loadApp[T any](filepath string) (*App[T], *fileLoader, error) {
+	l, err := loadFiles(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := new(App[T])
+	if err := l.merged.Unmarshal(cfg); err != nil {
+		return nil, nil, err
 	}
 	if err := validator.Struct(cfg); err != nil {
-		return nil, fmt.Errorf("validate config error: %w", err)
+		return nil, nil, fmt.Errorf("validate config error: %w", err)
 	}
+	return cfg, l, nil
+}
+
+// applyApp 设置默认值、解析密钥并替换当前配置
+func applyApp[T any](cfg *App[T], l *fileLoader) (*App[T], error) {
 	cfg = tag.Default(cfg) // 带有默认值 tag 标签赋值
+	recordSnapshot(cfg, l) // 快照中保留密钥引用, 不输出解析后的值
+
+	secret.Configure(cfg.Secret)
+	if err := secret.ResolveStruct(context.Background(), cfg); err != nil {
+		return nil, fmt.Errorf("resolve secret error: %w", err)
+	}
 	SetApp(cfg)
 	return cfg, nil
 }
 
-func reload[T any](path string, onChange func(e fsnotify.Event)) func(e fsnotify.Event) {
+// reload 重新加载配置, 结果记录审计事件 audit.TypeConfigReload
+// 加载成功后按新的文件列表更新监听
+func reload[T any](path string, w *watcher, onChange func(e fsnotify.Event)) func(e fsnotify.Event) {
 	return func(e fsnotify.Event) {
-		if _, err := LoadApp[T](path, nil); err != nil {
+		cfg, l, err := loadApp[T](path)
+		if err == nil {
+			w.update(l)
+			_, err = applyApp(cfg, l)
+		}
+		RecordReload(context.Background(), e.Name, e.Op.String(), err)
+		if err != nil {
 			slog.Error("[config] reload config error", "err", err)
 			return
 		}
-		if onChange != nil {
-			onChange(e)
-		}
+		onChange(e)
+	}
+}
+
+// RecordReload 记录配置重新加载的审计事件
+// resource 为变化的文件或密钥引用, trigger 为触发原因 (e.g. WRITE、secret)
+func RecordReload(ctx context.Context, resource, trigger string, err error) {
+	e := audit.Event{
+		Type:     audit.TypeConfigReload,
+		Outcome:  audit.OutcomeOf(err),
+		Resource: resource,
+		Metadata: map[string]string{"trigger": trigger},
+	}
+	if err != nil {
+		e.Reason = err.Error()
 	}
+	_ = audit.Record(ctx, e)
 }
 
 func Load[T any](filepath string, cfg *T, onChange func(e fsnotify.Event)) error {
@@ -0,0 +1,178 @@
+package conf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 配置文件合并
+/*
+	优先级: (从低到高, 高优先级覆盖低优先级)
+
+		1.configs 数组 (已废弃, 索引越小优先级越高)
+		2.include 引入的文件 (按声明顺序, 后引入的覆盖先引入的; glob 匹配结果按文件名排序)
+		3.主配置文件 config.yaml
+		4.环境配置文件 config.{env}.yaml (与主配置文件同目录, 不存在则忽略), 同样支持 include
+
+	合并规则:
+
+		1.map (e.g. db、redis) 按 key 深度合并, 高优先级只需要写差异的字段
+		2.标量和数组整体替换
+
+	值插值:
+
+		${VAR}         读取环境变量 VAR, 不存在时为空字符串
+		${VAR:default} 读取环境变量 VAR, 不存在时使用 default
+*/
+
+const includeKey = "include"
+
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::([^}]*))?\}`)
+
+// expandEnv 替换 ${VAR} 和 ${VAR:default}
+func expandEnv(data []byte) []byte {
+	return envPattern.ReplaceAllFunc(data, func(m []byte) []byte {
+		sub := envPattern.FindSubmatch(m)
+		if v, ok := os.LookupEnv(string(sub[1])); ok {
+			return []byte(v)
+		}
+		return sub[2]
+	})
+}
+
+// profileFile 环境配置文件路径 ./config.yaml -> ./config.dev.yaml
+func profileFile(path, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// fileLoader 按优先级读取并合并配置文件
+type fileLoader struct {
+	merged   *viper.Viper
+	files    []string          // 已合并的文件, 按优先级从低到高
+	patterns []string          // include glob 和环境配置文件, 之后新建的匹配文件也需要重新加载 (见 watcher)
+	sources  map[string]string // 配置项 (小写 key, e.g. db.default.source) 最终生效值的来源文件
+	visiting map[string]bool   // 检测循环引用
+}
+
+func newFileLoader() *fileLoader {
+	return &fileLoader{
+		merged:   viper.New(),
+		sources:  make(map[string]string),
+		visiting: make(map[string]bool),
+	}
+}
+
+// loadFiles 读取主配置文件及其引入、环境配置文件
+func loadFiles(path string) (*fileLoader, error) {
+	l := newFileLoader()
+
+	main, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// configs 数组索引越小优先级越高
+	configs := main.GetStringSlice("configs")
+	for i := len(configs) - 1; i >= 0; i-- {
+		if err := l.load(configs[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+	if env := l.merged.GetString("env"); env != "" {
+		profile := profileFile(path, env)
+		l.patterns = append(l.patterns, profile)
+		if _, err := os.Stat(profile); err == nil {
+			if err := l.load(profile); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return l, nil
+}
+
+// load 先合并 include 的文件, 再合并文件本身
+func (l *fileLoader) load(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if l.visiting[abs] {
+		return fmt.Errorf("config include cycle: %s", path)
+	}
+	l.visiting[abs] = true
+	defer delete(l.visiting, abs)
+
+	vpr, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range vpr.GetStringSlice(includeKey) {
+		files, err := resolveInclude(filepath.Dir(path), pattern)
+		if err != nil {
+			return fmt.Errorf("%s include %q: %w", path, pattern, err)
+		}
+		if isGlob(pattern) {
+			l.patterns = append(l.patterns, includePath(filepath.Dir(path), pattern))
+		}
+		for _, f := range files {
+			if err := l.load(f); err != nil {
+				return err
+			}
+		}
+	}
+	if err := l.merged.MergeConfigMap(vpr.AllSettings()); err != nil {
+		return fmt.Errorf("merge config %s: %w", path, err)
+	}
+	for _, key := range vpr.AllKeys() {
+		l.sources[key] = path
+	}
+	l.files = append(l.files, path)
+	return nil
+}
+
+// readFile 读取单个配置文件并替换环境变量
+func readFile(path string) (*viper.Viper, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	vpr := viper.New()
+	vpr.SetConfigType(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err := vpr.ReadConfig(bytes.NewReader(expandEnv(data))); err != nil {
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+	return vpr, nil
+}
+
+// resolveInclude 解析 include 路径, 相对路径基于当前配置文件所在目录
+// glob 没有匹配到文件时忽略, 普通路径文件不存在时报错
+func resolveInclude(dir, pattern string) ([]string, error) {
+	pattern = includePath(dir, pattern)
+	if !isGlob(pattern) {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, err
+		}
+		return []string{pattern}, nil
+	}
+	return filepath.Glob(pattern)
+}
+
+func includePath(dir, pattern string) string {
+	if !filepath.IsAbs(pattern) {
+		return filepath.Join(dir, pattern)
+	}
+	return pattern
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
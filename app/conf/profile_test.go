@@ -0,0 +1,199 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+name: demo
+version: 1.0.0
+env: ${TEST_APP_ENV:dev}
+include:
+  - ./deploy/*.yaml
+db:
+  default:
+    driver: mysql
+    source: root:${TEST_DB_PWD:123456}@tcp(127.0.0.1:3306)/demo
+`,
+		"deploy/a_db.yaml": `
+db:
+  default:
+    driver: sqlite
+    maxOpenConn: 10
+  report:
+    driver: mysql
+    source: report
+`,
+		"deploy/b_logger.yaml": `
+logger:
+  level: info
+`,
+		"config.prod.yaml": `
+logger:
+  level: error
+db:
+  default:
+    maxOpenConn: 100
+`,
+	})
+
+	t.Setenv("TEST_APP_ENV", "prod")
+	cfg, _, err := loadApp[map[string]any](filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Env != "prod" {
+		t.Errorf("env = %s, want prod", cfg.Env)
+	}
+	if cfg.Logger.Level != "error" {
+		t.Errorf("logger.level = %s, want error (profile override)", cfg.Logger.Level)
+	}
+	def := cfg.DB["default"]
+	if def.Driver != "mysql" { // 主配置文件覆盖 include
+		t.Errorf("db.default.driver = %s, want mysql", def.Driver)
+	}
+	if def.Source != "root:123456@tcp(127.0.0.1:3306)/demo" {
+		t.Errorf("db.default.source = %s", def.Source)
+	}
+	if def.MaxOpenConn != 100 { // map 深度合并
+		t.Errorf("db.default.maxOpenConn = %d, want 100", def.MaxOpenConn)
+	}
+	if cfg.DB["report"].Source != "report" {
+		t.Errorf("db.report should be kept from include, got %+v", cfg.DB["report"])
+	}
+
+	t.Setenv("TEST_APP_ENV", "test") // config.test.yaml 不存在
+	cfg, _, err = loadApp[map[string]any](filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Logger.Level != "info" || cfg.DB["default"].MaxOpenConn != 10 {
+		t.Errorf("unexpected config without profile: %+v", cfg.Logger)
+	}
+}
+
+func TestLoadIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml": "include: [b.yaml]\n",
+		"b.yaml": "include: [a.yaml]\n",
+	})
+	if _, err := loadFiles(filepath.Join(dir, "a.yaml")); err == nil {
+		t.Error("expected include cycle error")
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("TEST_HOST", "db.local")
+	got := string(expandEnv([]byte("addr: ${TEST_HOST}:${TEST_PORT:3306} pwd: $2a$10$x ${TEST_MISSING}")))
+	want := "addr: db.local:3306 pwd: $2a$10$x "
+	if got != want {
+		t.Errorf("expandEnv() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadSecret(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+name: demo
+version: 1.0.0
+env: dev
+secret:
+  dir: ` + dir + `
+db:
+  default:
+    driver: mysql
+    source: secret://file/db_dsn
+redis:
+  default:
+    addrs: [127.0.0.1:6379]
+    password: secret://env/TEST_REDIS_PWD
+`,
+		"db_dsn": "root:123456@tcp(127.0.0.1:3306)/demo\n",
+	})
+	t.Setenv("TEST_REDIS_PWD", "redis-pwd")
+
+	cfg, err := LoadApp[map[string]any](filepath.Join(dir, "config.yaml"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DB["default"].Source != "root:123456@tcp(127.0.0.1:3306)/demo" || cfg.Redis["default"].Password != "redis-pwd" {
+		t.Errorf("secret not resolved: %+v %+v", cfg.DB, cfg.Redis)
+	}
+	if GetBasicConf().Redis["default"].Password != "redis-pwd" {
+		t.Error("stored config should be resolved")
+	}
+	if got := GetSnapshot().Config["db"].(map[string]any)["default"].(map[string]any)["source"]; got != "secret://file/db_dsn" {
+		t.Errorf("snapshot should keep the secret ref, got %v", got)
+	}
+}
+
+func TestWatchNewFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": `
+name: demo
+version: 1.0.0
+env: dev
+include:
+  - ./conf.d/*.yaml
+logger:
+  level: info
+`,
+	})
+	changed := make(chan string, 16)
+	if _, err := LoadApp[map[string]any](filepath.Join(dir, "config.yaml"), func(e fsnotify.Event) {
+		changed <- filepath.Base(e.Name)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	wait := func(desc string, ok func() bool) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case <-changed:
+				if ok() {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("no reload after %s", desc)
+			}
+		}
+	}
+
+	// 启动之后新建的环境配置文件
+	writeFiles(t, dir, map[string]string{"config.dev.yaml": "logger:\n  level: error\n"})
+	wait("config.dev.yaml created", func() bool { return GetBasicConf().Logger.Level == "error" })
+	// include glob 匹配的文件 (目录也是启动之后新建的)
+	service := func(want string) func() bool {
+		return func() bool { return fmt.Sprint(GetServiceConf[map[string]any]()["x"]) == want }
+	}
+	writeFiles(t, dir, map[string]string{"conf.d/a.yaml": "service:\n  x: 1\n"})
+	wait("conf.d/a.yaml created", service("1"))
+	writeFiles(t, dir, map[string]string{"conf.d/b.yaml": "service:\n  x: 2\n"})
+	wait("conf.d/b.yaml created", service("2"))
+}
@@ -0,0 +1,356 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bobacgo/kit/app/types"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	durationType = reflect.TypeOf(types.Duration(""))
+	byteSizeType = reflect.TypeOf(types.ByteSize(""))
+)
+
+// 特殊类型的格式约束
+const (
+	durationPattern = `^(-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)?$` // 与 validate:"duration" 一致, 允许为空
+	byteSizePattern = `^([0-9]+([kKmMgGtTpP][bB]?|[bB]))?$`
+	semverPattern   = `^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`
+)
+
+// JSONSchema 配置结构的 JSON Schema 描述
+// 由 mapstructure、validate、default、mask 标签推导
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	WriteOnly            bool                   `json:"writeOnly,omitempty"` // mask 标签字段 (敏感信息)
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+
+	order []string // 属性的声明顺序, 用于生成示例 YAML
+}
+
+// Schema 生成 App[T] 的 JSON Schema
+func Schema[T any]() *JSONSchema {
+	s := schemaOf(reflect.TypeOf(App[T]{}))
+	s.Schema = jsonSchemaDraft
+	s.Title = "app config"
+	return s
+}
+
+// SchemaJSON 生成 App[T] 的 JSON Schema (格式化后的 JSON)
+func SchemaJSON[T any]() ([]byte, error) {
+	return json.MarshalIndent(Schema[T](), "", "  ")
+}
+
+func schemaOf(t reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case durationType:
+		return &JSONSchema{Type: "string", Pattern: durationPattern}
+	case byteSizeType:
+		return &JSONSchema{Type: "string", Pattern: byteSizePattern}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.Struct:
+		s := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+		structSchema(t, s)
+		return s
+	default: // interface 等任意类型
+		return &JSONSchema{}
+	}
+}
+
+// structSchema 解析结构体字段到 s, mapstructure:",squash" 的字段会被展开到当前层级
+func structSchema(t reflect.Type, s *JSONSchema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, squash, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+		if squash {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structSchema(ft, s)
+				continue
+			}
+		}
+		prop := schemaOf(f.Type)
+		if required := applyValidateTag(prop, f.Tag.Get("validate")); required {
+			s.Required = append(s.Required, name)
+		}
+		if def, ok := f.Tag.Lookup("default"); ok && def != "" {
+			prop.Default = defaultValue(prop.Type, def)
+		}
+		if _, ok := f.Tag.Lookup("mask"); ok {
+			prop.WriteOnly = true
+		}
+		s.Properties[name] = prop
+		s.order = append(s.order, name)
+	}
+}
+
+// fieldName 获取字段在配置文件中的 key
+// ok = false 表示忽略该字段 (mapstructure:"-")
+func fieldName(f reflect.StructField) (name string, squash, ok bool) {
+	tag := f.Tag.Get("mapstructure")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "squash" {
+			squash = true
+		}
+	}
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	return name, squash, true
+}
+
+// applyValidateTag 将 validate 标签规则转换为 schema 约束
+// dive 之后的规则作用于元素, 这里不做处理
+func applyValidateTag(s *JSONSchema, tag string) (required bool) {
+	if tag == "" {
+		return false
+	}
+	omitempty := false
+	defer func() {
+		if omitempty && len(s.Enum) > 0 && s.Type == "string" {
+			s.Enum = append(s.Enum, "") // omitempty 允许为空值
+		}
+	}()
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			return
+		case "omitempty":
+			omitempty = true
+		case "required":
+			required = true
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, defaultValue(s.Type, v))
+			}
+		case "semver":
+			s.Pattern = semverPattern
+		case "duration":
+			s.Pattern = durationPattern
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "hostname_port":
+			s.Format = "hostname-port"
+		case "gte", "min":
+			setBound(s, param, true)
+		case "lte", "max":
+			setBound(s, param, false)
+		case "len":
+			setBound(s, param, true)
+			setBound(s, param, false)
+		}
+	}
+	return
+}
+
+// setBound 按类型设置上下限, isMin = true 设置下限
+func setBound(s *JSONSchema, param string, isMin bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "integer", "number":
+		if isMin {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	case "string":
+		l := int(n)
+		if isMin {
+			s.MinLength = &l
+		} else {
+			s.MaxLength = &l
+		}
+	case "array":
+		if l := int(n); isMin {
+			s.MinItems = &l
+		}
+	}
+}
+
+// defaultValue 将标签中的字符串值转换为 schema 类型对应的值
+func defaultValue(typ, val string) any {
+	switch typ {
+	case "boolean":
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	case "integer":
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(val, 64); err == nil {
+			return n
+		}
+	}
+	return val
+}
+
+// SampleYAML 生成带注释的示例配置文件
+// 注释包含字段类型、是否必填、默认值、可选值以及是否为敏感字段
+func SampleYAML[T any]() []byte {
+	b := new(strings.Builder)
+	b.WriteString("# yaml-language-server: $schema=./config.schema.json\n")
+	writeYAMLObject(b, Schema[T](), 0)
+	return []byte(b.String())
+}
+
+func writeYAMLObject(b *strings.Builder, s *JSONSchema, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, name := range s.order {
+		prop := s.Properties[name]
+		if comment := yamlComment(prop, s.isRequired(name)); comment != "" {
+			fmt.Fprintf(b, "%s# %s\n", indent, comment)
+		}
+		writeYAMLValue(b, name, prop, depth)
+	}
+}
+
+func writeYAMLValue(b *strings.Builder, name string, s *JSONSchema, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch {
+	case s.Type == "object" && len(s.order) > 0:
+		fmt.Fprintf(b, "%s%s:\n", indent, name)
+		writeYAMLObject(b, s, depth+1)
+	case s.Type == "object" && s.AdditionalProperties != nil && len(s.AdditionalProperties.order) > 0:
+		// 多实例配置 (e.g. db、redis) 以 default 实例作为示例
+		fmt.Fprintf(b, "%s%s:\n", indent, name)
+		writeYAMLValue(b, "default", s.AdditionalProperties, depth+1)
+	case s.Type == "object":
+		fmt.Fprintf(b, "%s%s: {}\n", indent, name)
+	case s.Type == "array":
+		fmt.Fprintf(b, "%s%s: []\n", indent, name)
+	default:
+		fmt.Fprintf(b, "%s%s: %s\n", indent, name, yamlScalar(s))
+	}
+}
+
+func yamlScalar(s *JSONSchema) string {
+	v := s.Default
+	if v == nil && len(s.Enum) > 0 {
+		v = s.Enum[0]
+	}
+	switch s.Type {
+	case "boolean":
+		if v == nil {
+			v = false
+		}
+	case "integer", "number":
+		if v == nil {
+			v = 0
+		}
+	default:
+		if v == nil {
+			v = ""
+		}
+		return strconv.Quote(fmt.Sprint(v))
+	}
+	return fmt.Sprint(v)
+}
+
+func yamlComment(s *JSONSchema, required bool) string {
+	parts := make([]string, 0, 4)
+	if s.Type != "" && s.Type != "object" { // 对象类型的结构本身已经表达了类型
+		parts = append(parts, s.Type)
+	}
+	if required {
+		parts = append(parts, "required")
+	}
+	if s.Default != nil {
+		parts = append(parts, fmt.Sprintf("default: %v", s.Default))
+	}
+	if len(s.Enum) > 0 {
+		enum := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			if v != "" {
+				enum = append(enum, fmt.Sprint(v))
+			}
+		}
+		parts = append(parts, "oneof: "+strings.Join(enum, " "))
+	}
+	switch s.Pattern {
+	case durationPattern:
+		parts = append(parts, "duration (e.g. 300ms, 1.5h)")
+	case byteSizePattern:
+		parts = append(parts, "byte size (e.g. 512MB)")
+	case semverPattern:
+		parts = append(parts, "semver")
+	}
+	if s.WriteOnly {
+		parts = append(parts, "sensitive")
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (s *JSONSchema) isRequired(name string) bool {
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 离线校验配置文件
+// 与 LoadApp 使用相同的加载顺序与 validator.Struct 规则, 但不会监听文件变化, 也不会替换全局配置
+func Check[T any](filepath string) error {
+	_, _, err := loadApp[T](filepath)
+	return err
+}
@@ -0,0 +1,46 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	s := Schema[map[string]any]()
+	if !slices.Contains(s.Required, "name") {
+		t.Errorf("name should be required, got %v", s.Required)
+	}
+	if _, ok := s.Properties["Basic"]; ok {
+		t.Error("squash field should be inlined")
+	}
+	timeout := s.Properties["server"].Properties["http"].Properties["timeout"]
+	if timeout.Default != "5s" || timeout.Pattern != durationPattern {
+		t.Errorf("unexpected timeout schema %+v", timeout)
+	}
+	if !s.Properties["db"].AdditionalProperties.Properties["source"].WriteOnly {
+		t.Error("db source should be marked as sensitive")
+	}
+	if _, ok := s.Properties["logger"].Properties["LevelCh"]; ok {
+		t.Error(`mapstructure:"-" field should be skipped`)
+	}
+	t.Log(string(SampleYAML[map[string]any]()))
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	_ = os.WriteFile(valid, []byte("name: demo\nversion: 1.0.0\nenv: dev\n"), 0o644)
+	_ = os.WriteFile(invalid, []byte("version: 1.0.0\nenv: local\n"), 0o644)
+
+	if err := Check[map[string]any](valid); err != nil {
+		t.Errorf("expected valid config, got %v", err)
+	}
+	if err := Check[map[string]any](invalid); err == nil {
+		t.Error("expected invalid config error")
+	} else {
+		t.Log(err)
+	}
+}
@@ -0,0 +1,179 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bobacgo/kit/pkg/tag"
+)
+
+// SourceDefault 配置项没有出现在任何配置文件中 (零值或 default 标签的默认值)
+const SourceDefault = "default"
+
+// Snapshot 当前生效配置的快照 (已脱敏)
+// 用于运行时查看 pod 实际使用的配置
+type Snapshot struct {
+	LoadedAt time.Time         `json:"loadedAt" yaml:"loadedAt"` // 最近一次加载 (含热加载) 的时间
+	Files    []string          `json:"files" yaml:"files"`       // 合并的配置文件, 按优先级从低到高
+	Config   map[string]any    `json:"config" yaml:"config"`     // 生效的配置 (mask 标签字段已脱敏)
+	Sources  map[string]string `json:"sources" yaml:"sources"`   // 配置项 -> 来源文件
+	Changes  []Change          `json:"changes" yaml:"changes"`   // 与上一次加载相比的变化 (首次加载为空)
+}
+
+// Change 配置项的变化 (已脱敏)
+type Change struct {
+	Key string `json:"key" yaml:"key"`
+	Old any    `json:"old" yaml:"old"`
+	New any    `json:"new" yaml:"new"`
+}
+
+var (
+	snapshot   atomic.Pointer[Snapshot]
+	snapshotMu sync.Mutex
+)
+
+// GetSnapshot 获取当前生效配置的快照
+func GetSnapshot() Snapshot {
+	if s := snapshot.Load(); s != nil {
+		return *s
+	}
+	return Snapshot{}
+}
+
+// recordSnapshot 记录配置快照, 并计算与上一次的差异
+func recordSnapshot[T any](cfg *App[T], l *fileLoader) {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+
+	masked := tag.Desensitize(cfg)
+	tree, _ := toMap(reflect.ValueOf(masked)).(map[string]any)
+	values := make(map[string]any)
+	flatten("", tree, values)
+
+	sources := make(map[string]string, len(values))
+	for k := range values {
+		sources[k] = lookupSource(l.sources, k)
+	}
+
+	s := &Snapshot{
+		LoadedAt: time.Now(),
+		Files:    l.files,
+		Config:   tree,
+		Sources:  sources,
+	}
+	if prev := snapshot.Load(); prev != nil {
+		old := make(map[string]any)
+		flatten("", prev.Config, old)
+		s.Changes = diff(old, values)
+	}
+	snapshot.Store(s)
+}
+
+// lookupSource 查找配置项的来源文件
+// 配置文件中的 key 为小写, 且 map、slice 可能整体来源于同一个文件
+func lookupSource(sources map[string]string, key string) string {
+	key = strings.ToLower(key)
+	for {
+		if src, ok := sources[key]; ok {
+			return src
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return SourceDefault
+		}
+		key = key[:i]
+	}
+}
+
+// toMap 按 mapstructure 标签将配置结构转换为 map (key 与配置文件一致)
+func toMap(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toMap(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]any)
+		structToMap(v, m)
+		return m
+	case reflect.Map:
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = toMap(iter.Value())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = toMap(v.Index(i))
+		}
+		return list
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return nil
+	case reflect.String:
+		return v.String() // 自定义字符串类型 (e.g. types.Duration) 统一输出为 string
+	default:
+		return v.Interface()
+	}
+}
+
+func structToMap(v reflect.Value, m map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, squash, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+		fv := v.Field(i)
+		if squash && reflect.Indirect(fv).Kind() == reflect.Struct {
+			structToMap(reflect.Indirect(fv), m)
+			continue
+		}
+		m[name] = toMap(fv)
+	}
+}
+
+// flatten 展开嵌套的 map, key 使用 . 连接 (slice 作为一个整体)
+func flatten(prefix string, m map[string]any, out map[string]any) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]any); ok && len(sub) > 0 {
+			flatten(key, sub, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+func diff(old, cur map[string]any) []Change {
+	changes := make([]Change, 0)
+	for k, v := range cur {
+		if ov, ok := old[k]; !ok || !reflect.DeepEqual(ov, v) {
+			changes = append(changes, Change{Key: k, Old: ov, New: v})
+		}
+	}
+	for k, ov := range old {
+		if _, ok := cur[k]; !ok {
+			changes = append(changes, Change{Key: k, Old: ov})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
@@ -0,0 +1,44 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	snapshot.Store(nil) // 不受其他测试加载的配置影响
+	dir := t.TempDir()
+	main := filepath.Join(dir, "config.yaml")
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": "name: demo\nversion: 1.0.0\nenv: dev\ninclude: [db.yaml]\n",
+		"db.yaml":     "db:\n  default:\n    driver: mysql\n    source: root:123456@tcp(127.0.0.1:3306)/demo\n",
+	})
+	if _, err := LoadApp[map[string]any](main, nil); err != nil {
+		t.Fatal(err)
+	}
+	s := GetSnapshot()
+	if s.LoadedAt.IsZero() || len(s.Files) != 2 || len(s.Changes) != 0 {
+		t.Errorf("unexpected snapshot %+v", s)
+	}
+	if got := s.Sources["db.default.source"]; got != filepath.Join(dir, "db.yaml") {
+		t.Errorf("db.default.source source = %s", got)
+	}
+	if got := s.Sources["server.http.timeout"]; got != SourceDefault {
+		t.Errorf("server.http.timeout source = %s, want default", got)
+	}
+	db := s.Config["db"].(map[string]any)["default"].(map[string]any)
+	if src, _ := db["source"].(string); src == "" || strings.Contains(src, "123456") {
+		t.Errorf("source should be masked, got %v", db["source"])
+	}
+
+	_ = os.WriteFile(main, []byte("name: demo2\nversion: 1.0.0\nenv: dev\ninclude: [db.yaml]\n"), 0o644)
+	if _, err := LoadApp[map[string]any](main, nil); err != nil {
+		t.Fatal(err)
+	}
+	s = GetSnapshot()
+	if len(s.Changes) != 1 || s.Changes[0].Key != "name" || s.Changes[0].Old != "demo" || s.Changes[0].New != "demo2" {
+		t.Errorf("unexpected changes %+v", s.Changes)
+	}
+}
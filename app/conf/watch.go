@@ -0,0 +1,125 @@
+package conf
+
+import (
+	"log/slog"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watcher 监听配置文件所在的目录
+/*
+	以下文件变化时触发重新加载:
+		1.已合并的配置文件
+		2.匹配 include glob 的文件 (包括启动之后新建的文件和目录)
+		3.环境配置文件 config.{env}.yaml (启动时不存在, 之后新建)
+		4.符号链接指向的文件变化 (e.g. k8s ConfigMap 挂载)
+
+	每次重新加载后按新的文件列表更新 (update), 新引入的文件所在目录也会被监听
+*/
+type watcher struct {
+	fsw *fsnotify.Watcher
+
+	mu       sync.Mutex
+	dirs     map[string]bool
+	files    map[string]string // 文件 -> 符号链接解析后的路径
+	patterns []string          // include glob、环境配置文件
+}
+
+func newWatcher() (*watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &watcher{fsw: fsw, dirs: make(map[string]bool)}, nil
+}
+
+// update 按 fileLoader 的结果更新监听的文件和目录
+func (w *watcher) update(l *fileLoader) {
+	files := make(map[string]string, len(l.files))
+	dirs := make([]string, 0, len(l.files)+len(l.patterns))
+	for _, f := range l.files {
+		abs := absPath(f)
+		real, _ := filepath.EvalSymlinks(abs)
+		files[abs] = real
+		dirs = append(dirs, filepath.Dir(abs))
+	}
+	patterns := make([]string, 0, len(l.patterns))
+	for _, p := range l.patterns {
+		abs := absPath(p)
+		patterns = append(patterns, abs)
+		dirs = append(dirs, filepath.Dir(abs))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files, w.patterns = files, patterns
+	for _, dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		// 目录不存在时 (e.g. include 的 glob 目录还没有创建) 忽略
+		if err := w.fsw.Add(dir); err != nil {
+			slog.Debug("[config] watch dir error", "dir", dir, "err", err)
+			continue
+		}
+		w.dirs[dir] = true
+	}
+}
+
+// changed 事件是否需要重新加载
+func (w *watcher) changed(e fsnotify.Event) bool {
+	if !e.Has(fsnotify.Write) && !e.Has(fsnotify.Create) && !e.Has(fsnotify.Remove) && !e.Has(fsnotify.Rename) {
+		return false
+	}
+	name := absPath(e.Name)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.files[name]; ok {
+		return true
+	}
+	for _, p := range w.patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+		// include glob 的目录新建时重新加载, 加载后开始监听该目录
+		if e.Has(fsnotify.Create) && filepath.Dir(p) == name {
+			return true
+		}
+	}
+	for f, real := range w.files {
+		if now, _ := filepath.EvalSymlinks(f); now != real {
+			return true
+		}
+	}
+	return false
+}
+
+// run 串行处理事件, 重新加载时不会并发执行 onChange
+func (w *watcher) run(onChange func(e fsnotify.Event)) {
+	for {
+		select {
+		case e, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if w.changed(e) {
+				onChange(e)
+			}
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("[config] watch error", "err", err)
+		}
+	}
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return filepath.Clean(abs)
+	}
+	return filepath.Clean(path)
+}
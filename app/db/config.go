@@ -5,10 +5,21 @@ import "github.com/bobacgo/kit/app/types"
 type Config struct {
 	Driver        string         `mapstructure:"driver" yaml:"driver" validate:"required"`    // 驱动名称
 	Source        string         `mapstructure:"source" mask:":([^@]+)@" validate:"required"` // root:****@tcp(127.0.0.1:3306)/test
+	Replicas      []string       `mapstructure:"replicas" mask:":([^@]+)@"`                   // 只读副本, 读请求负载均衡到副本, 写请求和事务使用 source (主库)
+	Resolver      ResolverConfig `mapstructure:"resolver"`                                    // 读写分离配置 (配置了 replicas 时生效)
+	Tenant        TenantConfig   `mapstructure:"tenant"`                                      // 多租户隔离, 见 TenantConfig
 	DryRun        bool           `mapstructure:"dryRun" yaml:"dryRun"`                        // 是否为测试模式（空跑sql，不会实际操作数据库）
-	SlowThreshold types.Duration `mapstructure:"slowThreshold" yaml:"slowThreshold"`          // 慢日志阈值
+	SlowThreshold types.Duration `mapstructure:"slowThreshold" yaml:"slowThreshold"`          // 慢查询阈值 (默认 100ms), 见 useMetrics
 	MaxOpenConn   int            `mapstructure:"maxOpenConn" yaml:"maxOpenConn"`              // 最大连接数 (高并发 500，低并发 100)
 	MaxIdleConn   int            `mapstructure:"maxIdleConn" yaml:"maxIdleConn"`              // 最大空闲连接数 (高并发 50，低并发 10)
 	MaxLifeTime   types.Duration `mapstructure:"maxLifeTime" yaml:"maxLifeTime"`              // 最大连接时间 (高并发 1h，低并发 30m)
 	MaxIdleTime   types.Duration `mapstructure:"maxIdleTime" yaml:"maxIdleTime"`              // 最大空闲时间 (高并发 15m，低并发 10m)
-}
\ No newline at end of file
+}
+
+// ResolverConfig 读写分离配置
+type ResolverConfig struct {
+	Policy      Policy         `mapstructure:"policy" validate:"omitempty,oneof=random roundRobin leastLatency" default:"random"` // 副本选择策略
+	HealthCheck types.Duration `mapstructure:"healthCheck" yaml:"healthCheck" validate:"duration" default:"10s"`                  // 副本健康检查间隔
+	MaxLag      types.Duration `mapstructure:"maxLag" yaml:"maxLag" validate:"duration" default:"10s"`                            // 复制延迟超过时剔除副本, 为 0 不检查延迟
+	LagQuery    string         `mapstructure:"lagQuery" yaml:"lagQuery"`                                                          // 查询复制延迟 (秒) 的 SQL, 为空时 mysql、postgres 使用内置语句
+}
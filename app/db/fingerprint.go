@@ -0,0 +1,121 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 参数列表: (?, ?, ?) -> (...)
+var listRe = regexp.MustCompile(`\(\s*\?(\s*,\s*\?)*\s*\)`)
+
+// 多行 VALUES: (...), (...) -> (...)
+var rowsRe = regexp.MustCompile(`\(\.\.\.\)(\s*,\s*\(\.\.\.\))+`)
+
+// Fingerprint 归一化的 SQL, 用于慢查询聚合, 不包含参数值
+/*
+	1.字符串、数字、占位符 ($1、:name) 替换为 ?
+	2.IN 列表、批量 VALUES 折叠为 (...)
+	3.去掉注释, 合并空白, 转为小写 (引号中的标识符不变)
+
+	SELECT * FROM `user` WHERE id IN (1, 2, 3) AND name = 'bob'
+	-> select * from `user` where id in (...) and name = ?
+*/
+func Fingerprint(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+	space := false
+	write := func(s string) {
+		if space && b.Len() > 0 && s != "," && s != ")" && !strings.HasSuffix(b.String(), "(") {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '#':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			space = true
+			i += end
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 4
+			}
+			space = true
+			i += end + 4
+		case c == '\'':
+			i = skipQuoted(sql, i)
+			write("?")
+		case c == '`' || c == '"': // 标识符
+			j := skipQuoted(sql, i)
+			write(sql[i:j])
+			i = j
+		case c == '$' || c == ':' && i+1 < len(sql) && isIdent(sql[i+1]) && (i == 0 || sql[i-1] != ':'):
+			j := i + 1
+			for j < len(sql) && isIdent(sql[j]) {
+				j++
+			}
+			if j == i+1 { // :: 类型转换
+				write(sql[i : i+1])
+				i++
+				continue
+			}
+			write("?")
+			i = j
+		case isDigit(c) && (i == 0 || !isIdent(sql[i-1])):
+			j := i + 1
+			for j < len(sql) && (isIdent(sql[j]) || sql[j] == '.') {
+				j++
+			}
+			write("?")
+			i = j
+		case isIdent(c):
+			j := i + 1
+			for j < len(sql) && isIdent(sql[j]) {
+				j++
+			}
+			write(strings.ToLower(sql[i:j]))
+			i = j
+		default:
+			write(sql[i : i+1])
+			space = c == ','
+			i++
+		}
+	}
+	s := listRe.ReplaceAllString(b.String(), "(...)")
+	return rowsRe.ReplaceAllString(s, "(...)")
+}
+
+// skipQuoted 跳过引号包围的内容, 支持引号重复和反斜杠转义, 返回结束引号之后的位置
+func skipQuoted(s string, i int) int {
+	q := s[i]
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case q:
+			if j+1 < len(s) && s[j+1] == q {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
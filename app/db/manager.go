@@ -1,9 +1,10 @@
 package db
 
 import (
+	"errors"
 	"fmt"
-	"log/slog"
 
+	"github.com/bobacgo/kit/app/logger"
 	"golang.org/x/exp/maps"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -11,6 +12,8 @@ import (
 
 const ComponentName = "database"
 
+var log = logger.Named("db")
+
 func withPrefix(prefix, format string, msgs ...any) string {
 	format = "[" + prefix + "] " + format
 	return fmt.Sprintf(format, msgs...)
@@ -31,8 +34,21 @@ func NewDBManager(cfgKV map[string]DialectorConfig) (DBManager, error) {
 		if dbs[k], err = NewDB(cfg.Dialector, cfg.Config); err != nil {
 			return nil, fmt.Errorf("k = %s , init err: %v", k, err)
 		}
+		if err = useMetrics(k, dbs[k], cfg.Config); err != nil {
+			return nil, fmt.Errorf("k = %s , init metrics err: %v", k, err)
+		}
+		if err = useTenant(dbs[k], cfg.Config.Tenant); err != nil {
+			return nil, fmt.Errorf("k = %s , init tenant err: %v", k, err)
+		}
+		if len(cfg.Replicas) == 0 {
+			continue
+		}
+		if err = useResolver(dbs[k], cfg.Replicas, cfg.Config); err != nil {
+			return nil, fmt.Errorf("k = %s , init replicas err: %v", k, err)
+		}
+		log.Info(withPrefix(ComponentName, "instance %s replicas %d, policy %s", k, len(cfg.Replicas), cfg.Config.Resolver.Policy))
 	}
-	slog.Info(withPrefix(ComponentName, "instances object %+q", maps.Keys(dbs)))
+	log.Info(withPrefix(ComponentName, "instances object %+q", maps.Keys(dbs)))
 	return dbs, nil
 }
 
@@ -44,8 +60,28 @@ func (m DBManager) Get(k string) *gorm.DB {
 	return m[k]
 }
 
+// Close 停止副本健康检查并关闭所有连接
+func (m DBManager) Close() error {
+	var errs []error
+	for k, db := range m {
+		unregisterPool(db)
+		if r, ok := db.Config.Plugins[resolverName].(*resolver); ok {
+			if err := r.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s replicas: %w", k, err))
+			}
+		}
+		if sqlDB, err := db.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", k, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
 type DialectorConfig struct {
 	Dialector gorm.Dialector
+	Replicas  []gorm.Dialector // 只读副本
 	Config    Config
 }
 
@@ -62,15 +98,19 @@ func DialectorMap(drivers []DriverOpenFunc, cfgMap map[string]Config) map[string
 		driverMap[name] = d
 	}
 
-	slog.Info(withPrefix(ComponentName, "support driver %+q", maps.Keys(driverMap)))
+	log.Info(withPrefix(ComponentName, "support driver %+q", maps.Keys(driverMap)))
 
 	for k, c := range cfgMap {
 		openFunc, ok := driverMap[c.Driver]
 		if !ok {
-			slog.Warn(withPrefix(ComponentName, "driver not found, Please check the configuration file"), "driver", c.Driver)
+			log.Warn(withPrefix(ComponentName, "driver not found, Please check the configuration file"), "driver", c.Driver)
 			continue
 		}
-		dialectorMap[k] = DialectorConfig{Dialector: openFunc(c.Source), Config: c}
+		dc := DialectorConfig{Dialector: openFunc(c.Source), Config: c}
+		for _, src := range c.Replicas {
+			dc.Replicas = append(dc.Replicas, openFunc(src))
+		}
+		dialectorMap[k] = dc
 	}
 	return dialectorMap
 }
\ No newline at end of file
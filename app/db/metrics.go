@@ -0,0 +1,255 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"moul.io/zapgorm2"
+)
+
+// 数据库指标 (OpenTelemetry), 没有配置 MeterProvider 时为 noop
+/*
+	连接池 (sql.DBStats, 采集时读取, 导出周期由 MeterProvider 的 reader 决定), 属性 db.pool.name 为 DBManager 的 key
+		db.client.connections.open          打开的连接数
+		db.client.connections.usage         state=used|idle
+		db.client.connections.max           最大连接数
+		db.client.connections.wait_count    等待连接的次数
+		db.client.connections.wait_time     等待连接的总时间 (s)
+		db.client.connections.closed        reason=max_idle|max_idle_time|max_lifetime
+
+	查询 (gorm 回调), 属性 db.pool.name、db.collection.name (表, 不包含 schema)、db.operation.name (select、insert ...)、error
+		db.client.operation.duration        执行时间 (s)
+		db.client.slow_queries              慢查询次数
+
+	超过 Config.SlowThreshold (默认 100ms) 的查询输出 warn 日志和 span 事件 db.slow_query,
+	包含归一化的 SQL (见 Fingerprint), 不包含参数值
+*/
+
+const (
+	meterName         = "github.com/bobacgo/kit/app/db"
+	metricsPluginName = "kit:db_metrics"
+	metricsStartKey   = metricsPluginName + ":start"
+	attrPool          = "db.pool.name"
+
+	defaultSlowThreshold = 100 * time.Millisecond
+)
+
+var (
+	poolsMu sync.RWMutex
+	pools   = make(map[*gorm.DB]string) // db -> DBManager key
+
+	metricOnce sync.Once
+	duration   metric.Float64Histogram
+	slowTotal  metric.Int64Counter
+)
+
+func registerMetrics() {
+	meter := otel.Meter(meterName)
+	var errs []error
+	add := func(err error) {
+		errs = append(errs, err)
+	}
+	var err error
+	duration, err = meter.Float64Histogram("db.client.operation.duration",
+		metric.WithDescription("duration of database operations"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10),
+	)
+	add(err)
+	slowTotal, err = meter.Int64Counter("db.client.slow_queries",
+		metric.WithDescription("number of queries slower than the slow threshold"),
+	)
+	add(err)
+
+	open, err := meter.Int64ObservableGauge("db.client.connections.open",
+		metric.WithDescription("number of established connections, both in use and idle"))
+	add(err)
+	usage, err := meter.Int64ObservableGauge("db.client.connections.usage",
+		metric.WithDescription("number of connections by state"))
+	add(err)
+	maxOpen, err := meter.Int64ObservableGauge("db.client.connections.max",
+		metric.WithDescription("maximum number of open connections allowed"))
+	add(err)
+	waitCount, err := meter.Int64ObservableCounter("db.client.connections.wait_count",
+		metric.WithDescription("total number of connections waited for"))
+	add(err)
+	waitTime, err := meter.Float64ObservableCounter("db.client.connections.wait_time",
+		metric.WithDescription("total time blocked waiting for a new connection"), metric.WithUnit("s"))
+	add(err)
+	closed, err := meter.Int64ObservableCounter("db.client.connections.closed",
+		metric.WithDescription("total number of connections closed by the pool"))
+	add(err)
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		poolsMu.RLock()
+		defer poolsMu.RUnlock()
+		for db, name := range pools {
+			sqlDB, err := db.DB() // 重新连接后为新的连接池
+			if err != nil {
+				continue
+			}
+			s := sqlDB.Stats()
+			pool := attribute.String(attrPool, name)
+			o.ObserveInt64(open, int64(s.OpenConnections), metric.WithAttributes(pool))
+			o.ObserveInt64(usage, int64(s.InUse), metric.WithAttributes(pool, attribute.String("state", "used")))
+			o.ObserveInt64(usage, int64(s.Idle), metric.WithAttributes(pool, attribute.String("state", "idle")))
+			o.ObserveInt64(maxOpen, int64(s.MaxOpenConnections), metric.WithAttributes(pool))
+			o.ObserveInt64(waitCount, s.WaitCount, metric.WithAttributes(pool))
+			o.ObserveFloat64(waitTime, s.WaitDuration.Seconds(), metric.WithAttributes(pool))
+			o.ObserveInt64(closed, s.MaxIdleClosed, metric.WithAttributes(pool, attribute.String("reason", "max_idle")))
+			o.ObserveInt64(closed, s.MaxIdleTimeClosed, metric.WithAttributes(pool, attribute.String("reason", "max_idle_time")))
+			o.ObserveInt64(closed, s.MaxLifetimeClosed, metric.WithAttributes(pool, attribute.String("reason", "max_lifetime")))
+		}
+		return nil
+	}, open, usage, maxOpen, waitCount, waitTime, closed)
+	add(err)
+
+	if err := errors.Join(errs...); err != nil {
+		otel.Handle(err)
+	}
+}
+
+// useMetrics 注册连接池指标和查询回调, 慢查询由插件输出, 关闭 zapgorm2 的慢日志
+func useMetrics(name string, db *gorm.DB, conf Config) error {
+	metricOnce.Do(registerMetrics)
+
+	slow := defaultSlowThreshold
+	if conf.SlowThreshold != "" {
+		slow = conf.SlowThreshold.TimeDuration()
+	}
+	if l, ok := db.Logger.(zapgorm2.Logger); ok {
+		l.SlowThreshold = 0
+		db.Logger = l
+	}
+	if err := db.Use(&metricsPlugin{name: name, slow: slow}); err != nil {
+		return err
+	}
+	poolsMu.Lock()
+	pools[db] = name
+	poolsMu.Unlock()
+	return nil
+}
+
+// unregisterPool 关闭后不再采集连接池指标
+func unregisterPool(db *gorm.DB) {
+	poolsMu.Lock()
+	delete(pools, db)
+	poolsMu.Unlock()
+}
+
+type metricsPlugin struct {
+	name string
+	slow time.Duration
+}
+
+func (p *metricsPlugin) Name() string {
+	return metricsPluginName
+}
+
+func (p *metricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register(metricsPluginName+":before", p.before),
+		cb.Create().After("gorm:create").Before("otel:after:create").Register(metricsPluginName+":after", p.after),
+		cb.Query().Before("gorm:query").Register(metricsPluginName+":before", p.before),
+		cb.Query().After("gorm:query").Before("otel:after:select").Register(metricsPluginName+":after", p.after),
+		cb.Update().Before("gorm:update").Register(metricsPluginName+":before", p.before),
+		cb.Update().After("gorm:update").Before("otel:after:update").Register(metricsPluginName+":after", p.after),
+		cb.Delete().Before("gorm:delete").Register(metricsPluginName+":before", p.before),
+		cb.Delete().After("gorm:delete").Before("otel:after:delete").Register(metricsPluginName+":after", p.after),
+		cb.Row().Before("gorm:row").Register(metricsPluginName+":before", p.before),
+		cb.Row().After("gorm:row").Before("otel:after:row").Register(metricsPluginName+":after", p.after),
+		cb.Raw().Before("gorm:raw").Register(metricsPluginName+":before", p.before),
+		cb.Raw().After("gorm:raw").Before("otel:after:raw").Register(metricsPluginName+":after", p.after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *metricsPlugin) before(db *gorm.DB) {
+	if db.DryRun {
+		return
+	}
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+func (p *metricsPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(metricsStartKey)
+	if !ok {
+		return
+	}
+	start, ok := v.(time.Time)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start)
+	stmt := db.Statement
+	ctx := stmt.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	op := operation(stmt.SQL.String())
+	table := collection(stmt.Table)
+	attrs := []attribute.KeyValue{
+		attribute.String(attrPool, p.name),
+		attribute.String("db.collection.name", table),
+		attribute.String("db.operation.name", op),
+		attribute.Bool("error", db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)),
+	}
+	duration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(attrs...))
+	if p.slow <= 0 || elapsed < p.slow {
+		return
+	}
+	slowTotal.Add(ctx, 1, metric.WithAttributes(attrs[:3]...))
+
+	fp := Fingerprint(stmt.SQL.String())
+	trace.SpanFromContext(ctx).AddEvent("db.slow_query", trace.WithAttributes(
+		attribute.String("db.query.fingerprint", fp),
+		attribute.Int64("db.duration_ms", elapsed.Milliseconds()),
+		attribute.Int64("db.rows", stmt.RowsAffected),
+	))
+	log.WarnContext(ctx, withPrefix(ComponentName, "slow query"),
+		"db", p.name,
+		"table", table,
+		"operation", op,
+		"elapsed", elapsed,
+		"threshold", p.slow,
+		"rows", stmt.RowsAffected,
+		"fingerprint", fp,
+	)
+}
+
+// collection 去掉 schema 的表名, schema 租户模式下为 tenant_{id}.table, 按租户区分会使指标的序列数无限增长
+func collection(table string) string {
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		return table[i+1:]
+	}
+	return table
+}
+
+// operation SQL 的第一个关键字 (select、insert ...)
+func operation(sql string) string {
+	sql = strings.TrimLeft(sql, " \t\r\n(")
+	i := strings.IndexFunc(sql, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if i >= 0 {
+		sql = sql[:i]
+	}
+	if sql == "" {
+		return "unknown"
+	}
+	return strings.ToLower(sql)
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bobacgo/kit/app/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"gorm.io/driver/sqlite"
+)
+
+type metricItem struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		sql, want string
+	}{
+		{"SELECT * FROM `user` WHERE id IN (1, 2, 3) AND name = 'bob'", "select * from `user` where id in (...) and name = ?"},
+		{"select *\n  from user where id = ? -- comment\n limit 10", "select * from user where id = ? limit ?"},
+		{`INSERT INTO "t" ("a","b") VALUES ($1,$2),($3,$4)`, `insert into "t" ("a", "b") values (...)`},
+		{"UPDATE t SET name = 'it''s', v = -1.5 /* x */ WHERE id = 7", "update t set name = ?, v = -? where id = ?"},
+		{"select a::text from t2 where k = :key", "select a::text from t2 where k = ?"},
+	}
+	for _, tt := range tests {
+		if got := Fingerprint(tt.sql); got != tt.want {
+			t.Errorf("Fingerprint(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	m, err := NewDBManager(map[string]DialectorConfig{
+		defaultInstanceKey: {Dialector: sqlite.Open(filepath.Join(t.TempDir(), "metrics.db")), Config: Config{Driver: "sqlite", SlowThreshold: types.Duration("1ns")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	db := m.Default()
+	if err := db.AutoMigrate(&metricItem{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&metricItem{Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var items []metricItem
+	if err := db.Where("name = ?", "a").Find(&items).Error; err != nil || len(items) != 1 {
+		t.Fatal(items, err)
+	}
+
+	// schema 租户模式下表名不包含租户的 schema
+	tm, err := NewDBManager(map[string]DialectorConfig{
+		defaultInstanceKey: {Dialector: sqlite.Open(filepath.Join(t.TempDir(), "tenant.db")), Config: Config{Driver: "sqlite", MaxOpenConn: 1, MaxIdleConn: 1, Tenant: TenantConfig{Mode: TenantSchema}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tm.Close() })
+	if err := tm.Default().Exec(fmt.Sprintf("ATTACH DATABASE '%s' AS tenant_t1", filepath.Join(t.TempDir(), "t1.db"))).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.Default().Exec("CREATE TABLE tenant_t1.metric_item (id INTEGER PRIMARY KEY, name TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.Default().WithContext(tenantCtx(t, "t1")).Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		if sm.Scope.Name != meterName {
+			continue
+		}
+		for _, mt := range sm.Metrics {
+			got[mt.Name] = mt.Data
+		}
+	}
+
+	hist, ok := got["db.client.operation.duration"].(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("duration histogram not found: %v", got)
+	}
+	ops := make(map[string]bool)
+	for _, dp := range hist.DataPoints {
+		table, _ := dp.Attributes.Value("db.collection.name")
+		op, _ := dp.Attributes.Value("db.operation.name")
+		if pool, _ := dp.Attributes.Value(attrPool); pool.AsString() != defaultInstanceKey {
+			t.Fatal(dp.Attributes)
+		}
+		if strings.Contains(table.AsString(), ".") {
+			t.Fatalf("table with schema: %s", table.AsString())
+		}
+		if table.AsString() == "metric_item" {
+			ops[op.AsString()] = true
+		}
+	}
+	if !ops["insert"] || !ops["select"] {
+		t.Fatal(ops)
+	}
+
+	if slow, ok := got["db.client.slow_queries"].(metricdata.Sum[int64]); !ok || len(slow.DataPoints) == 0 {
+		t.Fatalf("slow queries not found: %v", got["db.client.slow_queries"])
+	}
+
+	usage, ok := got["db.client.connections.usage"].(metricdata.Gauge[int64])
+	if !ok {
+		t.Fatalf("connections usage not found: %v", got)
+	}
+	states := make(map[string]bool)
+	for _, dp := range usage.DataPoints {
+		if dp.Attributes.HasValue(attrPool) {
+			v, _ := dp.Attributes.Value(attribute.Key("state"))
+			states[v.AsString()] = true
+		}
+	}
+	if !states["used"] || !states["idle"] {
+		t.Fatal(states)
+	}
+	for _, name := range []string{"db.client.connections.open", "db.client.connections.max", "db.client.connections.wait_count", "db.client.connections.wait_time", "db.client.connections.closed"} {
+		if got[name] == nil {
+			t.Fatalf("%s not found", name)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/bobacgo/kit/app/tenant"
+	"github.com/bobacgo/kit/pkg/uid"
+	"gorm.io/gorm"
+)
+
+const (
+	lockTTL   = time.Minute // 持有锁的实例崩溃后, 超时可被其他实例获取; 持有期间每 lockTTL/3 续期
+	lockRetry = 500 * time.Millisecond
+)
+
+// lockRow 迁移锁, 只有一行 (id = 1)
+type lockRow struct {
+	ID        int    `gorm:"primaryKey;autoIncrement:false"`
+	Owner     string `gorm:"size:128"`
+	ExpiresAt time.Time
+}
+
+func (lockRow) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// tableLock 基于表的分布式锁, MySQL、SQLite 等都支持
+// 插入成功即获得锁, 主键冲突说明其他实例持有锁, 等待释放或过期
+// 持有期间定时续期 expires_at, 迁移耗时超过 ttl 也不会被其他实例获取
+// 过期时间按各实例的本地时钟判断, 实例间的时钟偏差需要远小于 ttl
+type tableLock struct {
+	db    *gorm.DB
+	owner string
+	ttl   time.Duration
+}
+
+func newTableLock(db *gorm.DB) *tableLock {
+	host, _ := os.Hostname()
+	// 锁表不属于任何租户, 续期和释放没有迁移的 ctx
+	db = db.WithContext(tenant.Skip(context.Background()))
+	return &tableLock{db: db, owner: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uid.UUID()[:8]), ttl: lockTTL}
+}
+
+// acquire 获取锁, 直到 ctx 结束
+func (l *tableLock) acquire(ctx context.Context) (release func(), err error) {
+	db := l.db.WithContext(tenant.Skip(ctx))
+	if err := db.AutoMigrate(&lockRow{}); err != nil {
+		return nil, fmt.Errorf("create migrations lock table: %w", err)
+	}
+	for waited := false; ; waited = true {
+		ok, err := l.tryAcquire(db)
+		if err != nil {
+			return nil, fmt.Errorf("acquire migrations lock: %w", err)
+		}
+		if ok {
+			stop, done := make(chan struct{}), make(chan struct{})
+			go l.heartbeat(stop, done)
+			return func() {
+				close(stop)
+				<-done
+				// 迁移的 ctx 可能已经结束, 仍然需要释放锁
+				err := l.db.Where("id = 1 AND owner = ?", l.owner).Delete(&lockRow{}).Error
+				if err != nil {
+					slog.Error("[migrate] release lock error", "owner", l.owner, "err", err)
+				}
+			}, nil
+		}
+		if !waited {
+			slog.Info("[migrate] waiting for migrations lock", "owner", l.owner)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("acquire migrations lock: %w", ctx.Err())
+		case <-time.After(lockRetry):
+		}
+	}
+}
+
+// heartbeat 持有锁期间续期, 直到 stop 关闭
+func (l *tableLock) heartbeat(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			res := l.db.Model(&lockRow{}).Where("id = 1 AND owner = ?", l.owner).Update("expires_at", now.Add(l.ttl))
+			switch {
+			case res.Error != nil:
+				slog.Error("[migrate] renew lock error", "owner", l.owner, "err", res.Error)
+			case res.RowsAffected == 0:
+				slog.Error("[migrate] migrations lock lost, another instance may be migrating", "owner", l.owner)
+			}
+		}
+	}
+}
+
+func (l *tableLock) tryAcquire(db *gorm.DB) (bool, error) {
+	now := time.Now()
+	// 过期的锁 (持有者崩溃) 直接删除
+	if err := db.Where("id = 1 AND expires_at < ?", now).Delete(&lockRow{}).Error; err != nil {
+		return false, err
+	}
+	err := db.Create(&lockRow{ID: 1, Owner: l.owner, ExpiresAt: now.Add(l.ttl)}).Error
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return false, nil
+	}
+	// 未开启 TranslateError 时主键冲突不能识别, 锁存在即视为冲突
+	var n int64
+	if db.Model(&lockRow{}).Where("id = 1").Count(&n).Error == nil && n > 0 {
+		return false, nil
+	}
+	return false, err
+}
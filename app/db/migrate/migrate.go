@@ -0,0 +1,305 @@
+// Package migrate 数据库版本迁移
+/*
+	迁移文件按数据源 (db 配置的 key) 分目录, 路径相对迁移根目录, 见 KeyOf、Sub:
+
+		//go:embed migrations
+		var migrations embed.FS
+
+		migrations/0001_create_user.up.sql
+		migrations/0001_create_user.down.sql
+		migrations/order/20240101120000_create_order.up.sql
+
+		app.New[T](path, app.WithMustDB(), app.WithMigrations(migrations, "migrations")) // 启动前执行 Up
+
+	1.版本号为文件名前缀的数字, 按版本号从小到大执行, 每个版本在一个事务中执行并写入 schema_migrations 表
+	  MySQL 的 DDL (CREATE、ALTER、DROP ...) 会隐式提交, 事务不能回滚: 包含多条语句的迁移中途失败时,
+	  之前的语句已经生效而版本没有记录, 需要人工恢复后重新执行; 建议 MySQL 的每个迁移只包含一条 DDL
+	2.Go 迁移通过 Register 注册, 与 SQL 迁移一起按版本号排序
+	3.已执行的 SQL 迁移文件被修改时 (checksum 不一致) 拒绝执行, 见 ErrDrift
+	4.多个实例同时启动时通过 schema_migrations_lock 表加锁, 只有一个实例执行迁移 (持有期间自动续期)
+	5.db 配置 dryRun 为 true 时只输出要执行的 SQL, 不执行
+	  一个文件中的多条语句按分号拆分执行, 触发器、存储过程等包含分号的语句
+	  用 -- +migrate StatementBegin 和 -- +migrate StatementEnd 两行注释包围, 整体作为一条语句
+	6.schema_migrations 是系统表, 迁移不按租户隔离 (见 db 包的多租户说明)
+*/
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/bobacgo/kit/app/db"
+	"github.com/bobacgo/kit/app/tenant"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const DefaultKey = "default"
+
+// ErrDrift 已执行的迁移文件被修改
+var ErrDrift = errors.New("migration checksum drift")
+
+// record 已执行的迁移
+type record struct {
+	Version   uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	Checksum  string `gorm:"size:64"`
+	AppliedAt time.Time
+}
+
+func (record) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator 一个数据源的迁移
+type Migrator struct {
+	db         *gorm.DB
+	key        string
+	migrations []*Migration
+	lock       *tableLock
+	out        io.Writer // dry-run 时输出 SQL
+	backslash  bool      // 字符串中的 \ 为转义符 (MySQL)
+}
+
+// New 读取数据源 key 的迁移, fsys 为迁移根目录, 见 Load、Sub
+func New(db *gorm.DB, key string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db: db, key: key, migrations: migrations, lock: newTableLock(db), out: os.Stdout,
+		backslash: db.Dialector.Name() == "mysql",
+	}, nil
+}
+
+// SetOutput dry-run 时 SQL 的输出位置, 默认 stdout
+func (m *Migrator) SetOutput(w io.Writer) {
+	m.out = w
+}
+
+// Status 迁移状态
+type Status struct {
+	Version   uint64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Drift     bool // 已执行的 SQL 迁移文件被修改
+}
+
+// Status 所有迁移的状态 (包括已执行但迁移文件不存在的版本)
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(tenant.Skip(db.ForcePrimary(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	var list []Status
+	for _, mg := range m.migrations {
+		s := Status{Version: mg.Version, Name: mg.Name}
+		if r, ok := applied[mg.Version]; ok {
+			s.Applied, s.AppliedAt, s.Drift = true, r.AppliedAt, r.Checksum != mg.Checksum
+			delete(applied, mg.Version)
+		}
+		list = append(list, s)
+	}
+	for _, r := range applied {
+		list = append(list, Status{Version: r.Version, Name: r.Name, Applied: true, AppliedAt: r.AppliedAt})
+	}
+	slices.SortFunc(list, func(a, b Status) int { return compare(a.Version, b.Version) })
+	return list, nil
+}
+
+// Up 执行所有未执行的迁移
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, ^uint64(0))
+}
+
+// Down 回滚最近执行的一个版本
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(ctx context.Context, applied map[uint64]record) error {
+		var last *Migration
+		for _, mg := range m.migrations {
+			if _, ok := applied[mg.Version]; ok {
+				last = mg
+			}
+		}
+		if last == nil {
+			return nil
+		}
+		return m.down(ctx, last)
+	})
+}
+
+// To 迁移到指定版本: 执行 <= version 的未执行迁移, 回滚 > version 的已执行迁移 (从大到小)
+func (m *Migrator) To(ctx context.Context, version uint64) error {
+	return m.withLock(ctx, func(ctx context.Context, applied map[uint64]record) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mg := m.migrations[i]
+			if _, ok := applied[mg.Version]; ok && mg.Version > version {
+				if err := m.down(ctx, mg); err != nil {
+					return err
+				}
+			}
+		}
+		for _, mg := range m.migrations {
+			if _, ok := applied[mg.Version]; !ok && mg.Version <= version {
+				if err := m.up(ctx, mg); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// withLock 加锁, 检查 checksum 后执行 fn
+func (m *Migrator) withLock(ctx context.Context, fn func(ctx context.Context, applied map[uint64]record) error) error {
+	ctx = tenant.Skip(db.ForcePrimary(ctx)) // 只读副本有复制延迟; 迁移表不属于任何租户
+	if !m.db.DryRun {
+		if err := m.db.WithContext(ctx).AutoMigrate(&record{}); err != nil {
+			return fmt.Errorf("[%s] create migrations table: %w", m.key, err)
+		}
+		release, err := m.lock.acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("[%s] %w", m.key, err)
+		}
+		defer release()
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	var drift []string
+	for _, mg := range m.migrations {
+		if r, ok := applied[mg.Version]; ok && r.Checksum != mg.Checksum {
+			drift = append(drift, mg.String())
+		}
+	}
+	if len(drift) > 0 {
+		return fmt.Errorf("[%s] %w: %q", m.key, ErrDrift, drift)
+	}
+	return fn(ctx, applied)
+}
+
+// applied 已执行的迁移
+// dry-run 的 gorm 不执行查询, 直接通过底层连接读取, 表不存在时为空
+func (m *Migrator) applied(ctx context.Context) (map[uint64]record, error) {
+	applied := make(map[uint64]record)
+	if m.db.DryRun {
+		sqlDB, err := m.db.DB()
+		if err != nil {
+			return applied, nil
+		}
+		rows, err := sqlDB.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+		if err != nil {
+			return applied, nil
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var r record
+			if err := rows.Scan(&r.Version, &r.Name, &r.Checksum, &r.AppliedAt); err != nil {
+				return nil, err
+			}
+			applied[r.Version] = r
+		}
+		return applied, rows.Err()
+	}
+
+	var records []record
+	if err := m.db.WithContext(ctx).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("[%s] read migrations: %w", m.key, err)
+	}
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+func (m *Migrator) up(ctx context.Context, mg *Migration) error {
+	start := time.Now()
+	err := m.run(ctx, mg, mg.UpSQL, mg.Up, func(tx *gorm.DB) error {
+		return tx.Create(&record{Version: mg.Version, Name: mg.Name, Checksum: mg.Checksum, AppliedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("[%s] migrate up %s: %w", m.key, mg, err)
+	}
+	slog.Info("[migrate] up", "db", m.key, "version", mg.Version, "name", mg.Name, "latency", time.Since(start), "dryRun", m.db.DryRun)
+	return nil
+}
+
+func (m *Migrator) down(ctx context.Context, mg *Migration) error {
+	if !mg.hasDown() {
+		return fmt.Errorf("[%s] migration %s has no down migration", m.key, mg)
+	}
+	err := m.run(ctx, mg, mg.DownSQL, mg.Down, func(tx *gorm.DB) error {
+		return tx.Delete(&record{}, mg.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("[%s] migrate down %s: %w", m.key, mg, err)
+	}
+	slog.Info("[migrate] down", "db", m.key, "version", mg.Version, "name", mg.Name, "dryRun", m.db.DryRun)
+	return nil
+}
+
+// run 在事务中执行 SQL 或 Go 迁移, 并更新 schema_migrations
+// MySQL 的 DDL 会隐式提交, 这时事务只保证 schema_migrations 的记录在全部语句成功后写入
+func (m *Migrator) run(ctx context.Context, mg *Migration, sql string, fn GoFunc, bookkeep func(tx *gorm.DB) error) error {
+	if m.db.DryRun {
+		return m.dryRun(ctx, mg, sql, fn)
+	}
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if fn != nil {
+			if err := fn(ctx, tx); err != nil {
+				return err
+			}
+		}
+		for _, stmt := range splitSQL(sql, m.backslash) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return bookkeep(tx)
+	})
+}
+
+// dryRun 输出 SQL, Go 迁移在 dry-run 会话中执行并输出生成的 SQL
+func (m *Migrator) dryRun(ctx context.Context, mg *Migration, sql string, fn GoFunc) error {
+	fmt.Fprintf(m.out, "-- [%s] %s\n", m.key, mg)
+	for _, stmt := range splitSQL(sql, m.backslash) {
+		fmt.Fprintf(m.out, "%s;\n", stmt)
+	}
+	if fn == nil {
+		return nil
+	}
+	tx := m.db.Session(&gorm.Session{DryRun: true, Logger: &sqlPrinter{Interface: m.db.Logger, out: m.out}})
+	return fn(ctx, tx.WithContext(ctx))
+}
+
+// sqlPrinter 输出 dry-run 会话生成的 SQL
+type sqlPrinter struct {
+	gormlogger.Interface
+	out io.Writer
+}
+
+func (p *sqlPrinter) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	fmt.Fprintf(p.out, "%s;\n", sql)
+}
+
+func compare(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
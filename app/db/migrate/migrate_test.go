@@ -0,0 +1,264 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/bobacgo/kit/app/db"
+	"github.com/bobacgo/kit/app/tenant"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var testFS = fstest.MapFS{
+	"0001_create_user.up.sql":        {Data: []byte("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT); -- ;\nCREATE INDEX idx_user_name ON user (name);")},
+	"0001_create_user.down.sql":      {Data: []byte("DROP TABLE user;")},
+	"0003_add_email.up.sql":          {Data: []byte("ALTER TABLE user ADD COLUMN email TEXT DEFAULT 'a;b';")},
+	"0003_add_email.down.sql":        {Data: []byte("ALTER TABLE user DROP COLUMN email;")},
+	"order/0001_create_order.up.sql": {Data: []byte("CREATE TABLE orders (id INTEGER PRIMARY KEY);")},
+}
+
+func init() {
+	Register(DefaultKey, 2, "seed_user", func(ctx context.Context, tx *gorm.DB) error {
+		return tx.Exec("INSERT INTO user (id, name) VALUES (1, 'admin')").Error
+	}, func(ctx context.Context, tx *gorm.DB) error {
+		return tx.Exec("DELETE FROM user WHERE id = 1").Error
+	})
+}
+
+func openDB(t *testing.T, file string, dryRun bool) *gorm.DB {
+	gdb, err := db.NewDB(sqlite.Open(file), db.Config{Driver: "sqlite", DryRun: dryRun})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gdb
+}
+
+func versions(t *testing.T, m *Migrator) (applied []uint64) {
+	list, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range list {
+		if s.Applied {
+			applied = append(applied, s.Version)
+		}
+	}
+	return applied
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "migrate.db")
+	gdb := openDB(t, file, false)
+
+	keys, err := Keys(testFS)
+	if err != nil || strings.Join(keys, ",") != "default,order" {
+		t.Fatal(keys, err)
+	}
+
+	m, err := New(gdb, DefaultKey, testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(t, m); len(got) != 3 {
+		t.Fatal(got)
+	}
+	var email string
+	if err := gdb.Raw("SELECT email FROM user WHERE id = 1").Scan(&email).Error; err != nil || email != "a;b" {
+		t.Fatal(email, err)
+	}
+	if err := m.Up(ctx); err != nil { // 重复执行无变化
+		t.Fatal(err)
+	}
+
+	if err := m.Down(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(t, m); len(got) != 2 || got[1] != 2 {
+		t.Fatal(got)
+	}
+	if err := m.To(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	if gdb.Raw("SELECT count(*) FROM user").Scan(&n); n != 0 {
+		t.Fatal("seed not rolled back", n)
+	}
+	if got := versions(t, m); len(got) != 1 || got[0] != 1 {
+		t.Fatal(got)
+	}
+
+	// 已执行的迁移文件被修改
+	drift := fstest.MapFS{}
+	for k, v := range testFS {
+		drift[k] = v
+	}
+	drift["0001_create_user.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE user (id INTEGER PRIMARY KEY);")}
+	m2, err := New(gdb, DefaultKey, drift)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m2.Up(ctx); !errors.Is(err, ErrDrift) {
+		t.Fatal(err)
+	}
+
+	// dry-run 只输出 SQL
+	dry, err := New(openDB(t, file, true), DefaultKey, testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	dry.SetOutput(&out)
+	if err := dry.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"-- [default] 2_seed_user", "INSERT INTO user", "ALTER TABLE user ADD COLUMN email TEXT DEFAULT 'a;b';"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("dry-run output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "create_user") {
+		t.Fatalf("applied migration in dry-run output:\n%s", out.String())
+	}
+	if got := versions(t, m); len(got) != 1 {
+		t.Fatal(got)
+	}
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	gdb := openDB(t, filepath.Join(t.TempDir(), "lock.db"), false)
+	a, b := newTableLock(gdb), newTableLock(gdb)
+
+	release, err := a.acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if _, err := b.acquire(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	release()
+	release, err = b.acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	// 过期的锁可以获取
+	if err := gdb.Create(&lockRow{ID: 1, Owner: "crashed", ExpiresAt: time.Now().Add(-time.Second)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if release, err = a.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	// 持有时间超过 ttl 时续期, 其他实例不能获取
+	a.ttl, b.ttl = 300*time.Millisecond, 300*time.Millisecond
+	if release, err = a.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	if ok, err := b.tryAcquire(gdb); err != nil || ok {
+		t.Fatalf("lock should be renewed: %v %v", ok, err)
+	}
+	release()
+	if ok, err := b.tryAcquire(gdb); err != nil || !ok {
+		t.Fatalf("lock should be released: %v %v", ok, err)
+	}
+}
+
+// 开启 schema 租户模式时迁移表、锁表不按租户隔离
+func TestTenantSchema(t *testing.T) {
+	m, err := db.NewDBManager(map[string]db.DialectorConfig{
+		DefaultKey: {Dialector: sqlite.Open(filepath.Join(t.TempDir(), "tenant.db")), Config: db.Config{Driver: "sqlite", Tenant: db.TenantConfig{Mode: db.TenantSchema}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	gdb := m.Default()
+
+	mg, err := New(gdb, DefaultKey, testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg.lock.ttl = 300 * time.Millisecond
+	if err := mg.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t1, err := tenant.WithID(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mg.Down(t1); err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(t, mg); len(got) != 2 {
+		t.Fatal(got)
+	}
+
+	// 续期没有迁移的 ctx
+	release, err := mg.lock.acquire(t1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	other := newTableLock(gdb)
+	if ok, err := other.tryAcquire(other.db); err != nil || ok {
+		t.Fatalf("lock should be renewed: %v %v", ok, err)
+	}
+	release()
+}
+
+func TestSub(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db/schema/0001_create_user.up.sql":        {Data: []byte("CREATE TABLE user (id INTEGER PRIMARY KEY);")},
+		"db/schema/order/0001_create_order.up.sql": {Data: []byte("CREATE TABLE orders (id INTEGER PRIMARY KEY);")},
+		"db/schema/order/old/0001_x.up.sql":        {Data: []byte("SELECT 1;")},
+	}
+	root, err := Sub(fsys, "db/schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := Keys(root)
+	if err != nil || strings.Join(keys, ",") != "default,order" {
+		t.Fatal(keys, err)
+	}
+	list, err := Load(root, "order")
+	if err != nil || len(list) != 1 || list[0].Name != "create_order" {
+		t.Fatal(list, err)
+	}
+}
+
+func TestSplitSQL(t *testing.T) {
+	tests := []struct {
+		sql       string
+		backslash bool
+		want      []string
+	}{
+		{`INSERT INTO t VALUES ('it\'s; x'); SELECT 1`, true, []string{`INSERT INTO t VALUES ('it\'s; x')`, "SELECT 1"}},
+		{`INSERT INTO t VALUES ('C:\'); SELECT 1`, false, []string{`INSERT INTO t VALUES ('C:\')`, "SELECT 1"}},
+		{`INSERT INTO t VALUES ('it''s; x'); SELECT 1`, false, []string{`INSERT INTO t VALUES ('it''s; x')`, "SELECT 1"}},
+		{"SELECT 1;\n-- +migrate StatementBegin\nCREATE TRIGGER tr AFTER INSERT ON t BEGIN\n  UPDATE t SET a = 1;\n  UPDATE t SET b = 2;\nEND;\n-- +migrate StatementEnd\nSELECT 2;", true,
+			[]string{"SELECT 1", "CREATE TRIGGER tr AFTER INSERT ON t BEGIN\n  UPDATE t SET a = 1;\n  UPDATE t SET b = 2;\nEND", "SELECT 2"}},
+	}
+	for _, tt := range tests {
+		if got := splitSQL(tt.sql, tt.backslash); !slices.Equal(got, tt.want) {
+			t.Errorf("splitSQL(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
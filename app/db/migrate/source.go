@@ -0,0 +1,259 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version  uint64
+	Name     string
+	UpSQL    string // SQL 迁移
+	DownSQL  string
+	Up       GoFunc // Go 迁移
+	Down     GoFunc
+	Checksum string // SQL 迁移为 up 文件内容的 sha256, Go 迁移为空
+}
+
+// GoFunc Go 迁移函数, tx 为迁移所在的事务
+type GoFunc func(ctx context.Context, tx *gorm.DB) error
+
+func (m *Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+func (m *Migration) hasDown() bool {
+	return m.DownSQL != "" || m.Down != nil
+}
+
+// {version}_{name}.up.sql、{version}_{name}.down.sql
+var fileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// KeyOf 迁移文件所属的数据源 (db 配置的 key), file 为相对迁移根目录的路径
+// 根目录下的文件属于 default, 一级子目录名为 key, 更深的目录不是迁移文件 (返回空)
+//
+//	0001_init.up.sql        -> default
+//	order/0001_init.up.sql  -> order
+func KeyOf(file string) string {
+	dir := path.Dir(file)
+	switch {
+	case dir == ".":
+		return DefaultKey
+	case strings.Contains(dir, "/"):
+		return ""
+	}
+	return dir
+}
+
+// Sub 迁移根目录为 fsys 中的 dir, dir 为空或 "." 时为 fsys 本身
+//
+//	//go:embed db/schema
+//	var schema embed.FS
+//	migrate.Sub(schema, "db/schema")
+func Sub(fsys fs.FS, dir string) (fs.FS, error) {
+	if fsys == nil || dir == "" || dir == "." {
+		return fsys, nil
+	}
+	return fs.Sub(fsys, dir)
+}
+
+// Load 读取 fsys (迁移根目录, 见 Sub) 中数据源 key 的 SQL 迁移, 并合并 Register 注册的 Go 迁移, 按版本排序
+func Load(fsys fs.FS, key string) ([]*Migration, error) {
+	byVersion := make(map[uint64]*Migration)
+	get := func(version uint64, name string) (*Migration, error) {
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration version %d is duplicated: %s, %s", version, m.Name, name)
+		}
+		return m, nil
+	}
+
+	if fsys != nil {
+		err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || KeyOf(p) != key {
+				return err
+			}
+			match := fileRe.FindStringSubmatch(d.Name())
+			if match == nil {
+				return nil
+			}
+			version, err := strconv.ParseUint(match[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			data, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			m, err := get(version, match[2])
+			if err != nil {
+				return err
+			}
+			if match[3] == "up" {
+				m.UpSQL = string(data)
+				sum := sha256.Sum256(data)
+				m.Checksum = hex.EncodeToString(sum[:])
+			} else {
+				m.DownSQL = string(data)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	registryMu.RLock()
+	for _, gm := range registry[key] {
+		m, err := get(gm.Version, gm.Name)
+		if err != nil {
+			registryMu.RUnlock()
+			return nil, err
+		}
+		if m.UpSQL != "" {
+			registryMu.RUnlock()
+			return nil, fmt.Errorf("migration version %d has both sql and go migration", gm.Version)
+		}
+		m.Up, m.Down = gm.Up, gm.Down
+	}
+	registryMu.RUnlock()
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" && m.Up == nil {
+			return nil, fmt.Errorf("migration %s has no up migration", m)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Keys fsys (迁移根目录, 见 Sub) 和 Register 中有迁移的数据源
+func Keys(fsys fs.FS) ([]string, error) {
+	set := make(map[string]struct{})
+	if fsys != nil {
+		err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && fileRe.MatchString(d.Name()) && KeyOf(p) != "" {
+				set[KeyOf(p)] = struct{}{}
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	registryMu.RLock()
+	for k := range registry {
+		set[k] = struct{}{}
+	}
+	registryMu.RUnlock()
+
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string][]*Migration)
+)
+
+// Register 注册 Go 迁移 (数据迁移等 SQL 不方便实现的场景), 一般在 init 中调用
+// down 可以为空 (不支持回滚)
+func Register(key string, version uint64, name string, up, down GoFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[key] = append(registry[key], &Migration{Version: version, Name: name, Up: up, Down: down})
+}
+
+// splitSQL 按分号拆分多条语句, 忽略引号和注释中的分号
+// MySQL 驱动默认不支持一次执行多条语句
+// backslash 为 true 时引号中的 \ 为转义符 (MySQL), 否则按标准 SQL 只用两个引号转义
+// 触发器、存储过程等 BEGIN ... END 中包含分号的语句需要用 StatementBegin、StatementEnd 注释包围, 整体作为一条语句:
+//
+//	-- +migrate StatementBegin
+//	CREATE TRIGGER ... BEGIN ...; ...; END
+//	-- +migrate StatementEnd
+func splitSQL(sql string, backslash bool) []string {
+	var (
+		stmts []string
+		b     strings.Builder
+		quote rune
+		block bool // StatementBegin 和 StatementEnd 之间
+	)
+	flush := func() {
+		s := strings.TrimSpace(b.String())
+		if block { // 块的最后一个分号 (END;)
+			s = strings.TrimSpace(strings.TrimSuffix(s, ";"))
+		}
+		if s != "" {
+			stmts = append(stmts, s)
+		}
+		b.Reset()
+	}
+	runes := []rune(sql)
+	for i := 0; i < len(runes); i++ {
+		c, next := runes[i], rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		switch {
+		case quote != 0:
+			if c == '\\' && backslash && quote != '`' && next != 0 {
+				b.WriteRune(c)
+				i++
+				c = next
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && next == '-': // 行注释
+			start := i
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			switch strings.TrimSpace(string(runes[start+2 : i])) {
+			case "+migrate StatementBegin":
+				flush()
+				block = true
+			case "+migrate StatementEnd":
+				flush()
+				block = false
+			}
+			b.WriteRune('\n')
+			continue
+		case c == '/' && next == '*': // 块注释
+			for i += 2; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+			}
+			i++
+			b.WriteRune(' ')
+			continue
+		case c == ';' && !block:
+			flush()
+			continue
+		}
+		b.WriteRune(c)
+	}
+	flush()
+	return stmts
+}
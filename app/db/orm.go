@@ -3,8 +3,8 @@ package db
 import (
 	"fmt"
 
+	kitlog "github.com/bobacgo/kit/app/logger"
 	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
-	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
@@ -35,7 +35,8 @@ func NewDB(dialector gorm.Dialector, conf Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("get DB err: %w", err)
 	}
 
-	db.Use(otelgorm.NewPlugin()) // 使用 OpenTelemetry 插件
+	db.Use(otelgorm.NewPlugin(otelgorm.WithoutMetrics())) // 使用 OpenTelemetry 插件, 连接池指标见 useMetrics
+	useSwapPool(db, sqlDB)                                // 支持凭据轮换后重新连接
 
 	// 影响最大并发数。
 	// 过大可能导致数据库负载过高，过小会限制并发性能。
@@ -55,7 +56,7 @@ func NewDB(dialector gorm.Dialector, conf Config) (*gorm.DB, error) {
 }
 
 func Logger(conf Config) logger.Interface {
-	logger := zapgorm2.New(zap.L())
+	logger := zapgorm2.New(kitlog.NamedZap("db"))
 	logger.SetAsDefault() // optional: configure gorm to use this zapgorm.Logger for callbacks
 	if conf.SlowThreshold != "" {
 		logger.SlowThreshold = conf.SlowThreshold.TimeDuration() // 慢 SQL 阈值
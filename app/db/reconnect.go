@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// swapPool 可替换底层 *sql.DB 的连接池
+// 凭据轮换后新建 *sql.DB 并原子替换, 已持有的 *gorm.DB 不需要重新获取
+type swapPool struct {
+	cur atomic.Pointer[sql.DB]
+}
+
+func (p *swapPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.cur.Load().PrepareContext(ctx, query)
+}
+
+func (p *swapPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return p.cur.Load().ExecContext(ctx, query, args...)
+}
+
+func (p *swapPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return p.cur.Load().QueryContext(ctx, query, args...)
+}
+
+func (p *swapPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return p.cur.Load().QueryRowContext(ctx, query, args...)
+}
+
+func (p *swapPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return p.cur.Load().BeginTx(ctx, opts)
+}
+
+func (p *swapPool) GetDBConn() (*sql.DB, error) {
+	return p.cur.Load(), nil
+}
+
+func (p *swapPool) Ping() error {
+	return p.cur.Load().Ping()
+}
+
+// useSwapPool 将 gorm 的连接池替换为 swapPool, 必须在 db 被并发使用之前调用
+func useSwapPool(db *gorm.DB, sqlDB *sql.DB) {
+	pool := new(swapPool)
+	pool.cur.Store(sqlDB)
+	switch p := db.ConnPool.(type) {
+	case *gorm.PreparedStmtDB:
+		p.ConnPool = pool
+	case *sql.DB:
+		db.ConnPool = pool
+		db.Statement.ConnPool = pool
+	}
+}
+
+func swapPoolOf(db *gorm.DB) (*swapPool, bool) {
+	switch p := db.ConnPool.(type) {
+	case *gorm.PreparedStmtDB:
+		pool, ok := p.ConnPool.(*swapPool)
+		return pool, ok
+	case *swapPool:
+		return p, true
+	}
+	return nil, false
+}
+
+// Reconnect 使用新的连接信息 (e.g. 轮换后的密码) 重新连接实例 k 及其只读副本
+// 新连接 ping 成功后替换连接池, 旧连接池延迟到执行中的 SQL 结束后关闭 (见 closeOldPool)
+// 副本数量变化时返回错误, 需要重启
+func (m DBManager) Reconnect(k string, dc DialectorConfig) error {
+	db, ok := m[k]
+	if !ok {
+		return fmt.Errorf("db instance %s not found", k)
+	}
+	r, _ := db.Config.Plugins[resolverName].(*resolver)
+	if n := len(dc.Replicas); (r == nil && n > 0) || (r != nil && len(r.replicas) != n) {
+		return fmt.Errorf("db instance %s: replica count changed, restart required", k)
+	}
+	if err := swapConn(k, db, dc.Dialector, dc.Config); err != nil {
+		return err
+	}
+	if r != nil {
+		if err := r.reconnect(k, dc.Replicas, dc.Config); err != nil {
+			return err
+		}
+	}
+	log.Info(withPrefix(ComponentName, "instance %s reconnected", k))
+	return nil
+}
+
+// swapConn 新建连接池并替换 db 的连接池
+func swapConn(k string, db *gorm.DB, dialector gorm.Dialector, conf Config) error {
+	pool, ok := swapPoolOf(db)
+	if !ok {
+		return fmt.Errorf("db instance %s does not support reconnect", k)
+	}
+	newDB, err := NewDB(dialector, conf)
+	if err != nil {
+		return err
+	}
+	sqlDB, _ := newDB.DB()
+	old := pool.cur.Swap(sqlDB)
+	var stmts map[string]*gorm.Stmt
+	if p, ok := db.ConnPool.(*gorm.PreparedStmtDB); ok {
+		// 预编译语句绑定在旧连接池上, 之后的 SQL 在新连接池上重新预编译
+		// 不使用 Reset: 会立即关闭其他协程正在使用的语句
+		p.Mux.Lock()
+		stmts, p.Stmts = p.Stmts, make(map[string]*gorm.Stmt)
+		p.Mux.Unlock()
+	}
+	go closeOldPool(k, old, stmts, oldPoolGrace, oldPoolMaxWait)
+	return nil
+}
+
+var (
+	oldPoolGrace   = 5 * time.Second // 替换后等待已取得旧连接池的 SQL 开始执行
+	oldPoolMaxWait = time.Minute     // 之后等待旧连接池的连接归还, 超时后强制关闭
+)
+
+// closeOldPool 延迟关闭旧连接池和预编译语句
+// 替换前已取得旧连接池或语句的协程可能还没有开始执行, 立即关闭会返回 "sql: database is closed"
+func closeOldPool(k string, old *sql.DB, stmts map[string]*gorm.Stmt, grace, maxWait time.Duration) {
+	time.Sleep(grace)
+	for deadline := time.Now().Add(maxWait); old.Stats().InUse > 0 && time.Now().Before(deadline); {
+		time.Sleep(grace / 5)
+	}
+	for _, stmt := range stmts {
+		if stmt.Stmt != nil {
+			_ = stmt.Close() // 等待执行中的语句结束
+		}
+	}
+	if err := old.Close(); err != nil {
+		log.Error(withPrefix(ComponentName, "close old connection pool error"), "key", k, "err", err)
+	}
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestReconnect(t *testing.T) {
+	dir := t.TempDir()
+	conf := Config{Driver: "sqlite", MaxOpenConn: 2}
+	for _, name := range []string{"a", "b", "ra", "rb"} {
+		db, err := NewDB(sqlite.Open(filepath.Join(dir, name+".db")), conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Exec("CREATE TABLE t (name TEXT)").Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Exec("INSERT INTO t VALUES (?)", name).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	open := func(primary, replica string) DialectorConfig {
+		return DialectorConfig{
+			Dialector: sqlite.Open(filepath.Join(dir, primary+".db")),
+			Replicas:  []gorm.Dialector{sqlite.Open(filepath.Join(dir, replica+".db"))},
+			Config:    conf,
+		}
+	}
+	m, err := NewDBManager(map[string]DialectorConfig{defaultInstanceKey: open("a", "ra")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	db := m.Default() // 业务持有的 *gorm.DB 在重新连接后仍然可用
+	query := func(db *gorm.DB) string {
+		var name string
+		if err := db.Raw("SELECT name FROM t").Scan(&name).Error; err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+	primary := db.WithContext(ForcePrimary(context.Background()))
+	if p, r := query(primary), query(db); p != "a" || r != "ra" {
+		t.Fatalf("before reconnect got %s %s", p, r)
+	}
+
+	// 主库和副本都重新连接
+	grace := oldPoolGrace
+	oldPoolGrace = 50 * time.Millisecond
+	t.Cleanup(func() { oldPoolGrace = grace })
+	old, _ := db.DB()
+	if err := m.Reconnect(defaultInstanceKey, open("b", "rb")); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.Ping(); err != nil { // 已取得旧连接池的协程在等待期间仍然可以使用
+		t.Fatalf("old pool closed immediately: %v", err)
+	}
+	if p, r := query(primary), query(db); p != "b" || r != "rb" {
+		t.Errorf("after reconnect got %s %s, want b rb", p, r)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if err := old.Ping(); err == nil {
+		t.Error("old pool should be closed after the grace period")
+	}
+	if err := m.Reconnect(defaultInstanceKey, DialectorConfig{Dialector: sqlite.Open(filepath.Join(dir, "a.db")), Config: conf}); err == nil {
+		t.Error("expected error when replica count changed")
+	}
+	if err := m.Reconnect("missing", open("b", "rb")); err == nil {
+		t.Error("expected error for missing instance")
+	}
+}
@@ -0,0 +1,338 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 读写分离
+/*
+	配置了 replicas 时, 注册 gorm 插件 (与 gorm.io/plugin/dbresolver 相同, 在 query、row 回调之前切换连接池):
+		1.查询 (Find、First、Scan、Raw("SELECT ...")) 使用只读副本
+		2.写入、Exec、事务、加锁查询 (FOR UPDATE) 使用主库
+		3.ctx 经过 ForcePrimary 的查询使用主库, 需要 db.WithContext(ctx) 传递
+
+	副本定时健康检查, ping 失败或复制延迟超过 maxLag 时剔除, 恢复后重新加入
+	没有可用的副本时查询使用主库
+*/
+
+// Policy 只读副本选择策略
+type Policy string
+
+const (
+	PolicyRandom       Policy = "random"       // 随机
+	PolicyRoundRobin   Policy = "roundRobin"   // 轮询
+	PolicyLeastLatency Policy = "leastLatency" // 健康检查耗时最小
+)
+
+const (
+	resolverName       = "kit:db_resolver"
+	defaultHealthCheck = 10 * time.Second
+)
+
+type forcePrimaryKey struct{}
+
+// ForcePrimary 查询也使用主库 (e.g. 写入后立即读取, 避免复制延迟读到旧数据)
+//
+//	db.WithContext(db.ForcePrimary(ctx)).First(&user)
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+func isForcePrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	force, _ := ctx.Value(forcePrimaryKey{}).(bool)
+	return force
+}
+
+// replica 只读副本
+type replica struct {
+	name    string // 脱敏后的地址, 用于日志
+	db      *gorm.DB
+	healthy atomic.Bool
+	latency atomic.Int64 // 健康检查耗时 (ns), 指数移动平均
+}
+
+// resolver 读写分离插件
+type resolver struct {
+	conf     ResolverConfig
+	driver   string
+	replicas []*replica
+	next     atomic.Uint64 // 轮询计数
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// useResolver 连接只读副本并注册读写分离插件
+func useResolver(db *gorm.DB, replicas []gorm.Dialector, conf Config) error {
+	r := &resolver{
+		conf:   conf.Resolver,
+		driver: conf.Driver,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for i, d := range replicas {
+		rdb, err := NewDB(d, conf)
+		if err != nil {
+			r.closeReplicas()
+			return fmt.Errorf("replica %d: %w", i, err)
+		}
+		rep := &replica{name: maskSource(conf.Replicas, i), db: rdb}
+		rep.healthy.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+	if err := db.Use(r); err != nil {
+		r.closeReplicas()
+		return err
+	}
+	return nil
+}
+
+func (r *resolver) Name() string {
+	return resolverName
+}
+
+func (r *resolver) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register(resolverName, r.switchReplica); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register(resolverName, r.switchReplica); err != nil {
+		return err
+	}
+	r.check(context.Background()) // 启动时剔除延迟过大的副本
+	go r.run()
+	return nil
+}
+
+// switchReplica 查询切换到只读副本
+func (r *resolver) switchReplica(db *gorm.DB) {
+	stmt := db.Statement
+	// 事务 (ConnPool 为 *sql.Tx)、指定了连接 (db.Connection) 时不切换
+	if stmt.ConnPool != db.Config.ConnPool || isForcePrimary(stmt.Context) {
+		return
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return
+	}
+	if sql := stmt.SQL.String(); sql != "" && !isReadSQL(sql) {
+		return
+	}
+	if rep := r.pick(); rep != nil {
+		stmt.ConnPool = rep.db.Config.ConnPool
+	}
+}
+
+// isReadSQL Raw 语句是否为只读查询
+func isReadSQL(sql string) bool {
+	sql = strings.ToLower(strings.TrimSpace(sql))
+	if !strings.HasPrefix(sql, "select") {
+		return false
+	}
+	return !strings.Contains(sql, " for update") && !strings.Contains(sql, " for share") &&
+		!strings.Contains(sql, " lock in share mode")
+}
+
+// pick 按策略选择健康的副本, 都不可用时返回 nil
+func (r *resolver) pick() *replica {
+	n := len(r.replicas)
+	switch r.conf.Policy {
+	case PolicyRoundRobin:
+		start := int(r.next.Add(1) % uint64(n))
+		for i := range n {
+			if rep := r.replicas[(start+i)%n]; rep.healthy.Load() {
+				return rep
+			}
+		}
+	case PolicyLeastLatency:
+		var best *replica
+		for _, rep := range r.replicas {
+			if rep.healthy.Load() && (best == nil || rep.latency.Load() < best.latency.Load()) {
+				best = rep
+			}
+		}
+		return best
+	default: // random
+		healthy := 0
+		for _, rep := range r.replicas {
+			if rep.healthy.Load() {
+				healthy++
+			}
+		}
+		if healthy == 0 {
+			return nil
+		}
+		k := rand.IntN(healthy)
+		for _, rep := range r.replicas {
+			if rep.healthy.Load() {
+				if k == 0 {
+					return rep
+				}
+				k--
+			}
+		}
+	}
+	return nil
+}
+
+func (r *resolver) run() {
+	defer close(r.done)
+	interval := r.conf.HealthCheck.TimeDuration()
+	if interval <= 0 {
+		interval = defaultHealthCheck
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			r.check(ctx)
+			cancel()
+		}
+	}
+}
+
+// check 健康检查, ping 失败或复制延迟过大时剔除副本
+func (r *resolver) check(ctx context.Context) {
+	maxLag := r.conf.MaxLag.TimeDuration()
+	for _, rep := range r.replicas {
+		start := time.Now()
+		err := r.ping(ctx, rep)
+		if err == nil {
+			elapsed := int64(time.Since(start))
+			if old := rep.latency.Load(); old > 0 {
+				elapsed = (old*7 + elapsed*3) / 10
+			}
+			rep.latency.Store(elapsed)
+		}
+		var lag time.Duration
+		if err == nil && maxLag > 0 {
+			if lag, err = r.lag(ctx, rep); err == nil && lag > maxLag {
+				err = fmt.Errorf("replication lag %s exceeds %s", lag, maxLag)
+			}
+		}
+
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			log.Info(withPrefix(ComponentName, "replica recovered"), "replica", rep.name, "lag", lag)
+		} else {
+			log.Warn(withPrefix(ComponentName, "replica ejected"), "replica", rep.name, "err", err)
+		}
+	}
+}
+
+func (r *resolver) ping(ctx context.Context, rep *replica) error {
+	sqlDB, err := rep.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// lag 查询复制延迟
+func (r *resolver) lag(ctx context.Context, rep *replica) (time.Duration, error) {
+	db := rep.db.WithContext(ctx)
+	query := r.conf.LagQuery
+	switch {
+	case query == "" && r.driver == "mysql":
+		return mysqlLag(db)
+	case query == "" && r.driver == "postgres":
+		query = "SELECT COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)"
+	case query == "":
+		return 0, nil
+	}
+	var seconds float64
+	if err := db.Raw(query).Scan(&seconds).Error; err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// mysqlLag SHOW REPLICA STATUS 的 Seconds_Behind_Source (8.0.22 之前为 SHOW SLAVE STATUS 的 Seconds_Behind_Master)
+func mysqlLag(db *gorm.DB) (time.Duration, error) {
+	var rows []map[string]any
+	if err := db.Raw("SHOW REPLICA STATUS").Scan(&rows).Error; err != nil {
+		if err = db.Raw("SHOW SLAVE STATUS").Scan(&rows).Error; err != nil {
+			return 0, err
+		}
+	}
+	if len(rows) == 0 { // 不是副本
+		return 0, nil
+	}
+	for _, key := range []string{"Seconds_Behind_Source", "Seconds_Behind_Master"} {
+		v, ok := rows[0][key]
+		if !ok {
+			continue
+		}
+		if v == nil {
+			return 0, errors.New("replication is not running")
+		}
+		var seconds float64
+		if _, err := fmt.Sscan(fmt.Sprint(v), &seconds); err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return 0, nil
+}
+
+// reconnect 重新连接所有副本, 然后立即健康检查 (凭据失效期间被剔除的副本重新加入)
+func (r *resolver) reconnect(k string, replicas []gorm.Dialector, conf Config) error {
+	for i, d := range replicas {
+		if err := swapConn(k, r.replicas[i].db, d, conf); err != nil {
+			return fmt.Errorf("replica %s: %w", r.replicas[i].name, err)
+		}
+	}
+	r.check(context.Background())
+	return nil
+}
+
+// Close 停止健康检查并关闭副本连接
+func (r *resolver) Close() error {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+	})
+	return r.closeReplicas()
+}
+
+func (r *resolver) closeReplicas() error {
+	var errs []error
+	for _, rep := range r.replicas {
+		if sqlDB, err := rep.db.DB(); err == nil {
+			errs = append(errs, sqlDB.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// maskSource 副本地址脱敏 (user:****@host)
+func maskSource(sources []string, i int) string {
+	if i >= len(sources) {
+		return fmt.Sprintf("replica-%d", i)
+	}
+	src := sources[i]
+	if at := strings.LastIndex(src, "@"); at > 0 {
+		if colon := strings.Index(src[:at], ":"); colon >= 0 {
+			return src[:colon+1] + "****" + src[at:]
+		}
+	}
+	return src
+}
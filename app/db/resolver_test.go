@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestResolver(t *testing.T) {
+	dir := t.TempDir()
+	conf := Config{Driver: "sqlite", Resolver: ResolverConfig{Policy: PolicyRoundRobin, MaxLag: "1s", LagQuery: "SELECT lag FROM t"}}
+	for _, name := range []string{"primary", "r1", "r2"} {
+		db, err := NewDB(sqlite.Open(filepath.Join(dir, name+".db")), conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Exec("CREATE TABLE t (name TEXT, lag REAL)").Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Exec("INSERT INTO t VALUES (?, 0)", name).Error; err != nil {
+			t.Fatal(err)
+		}
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}
+
+	conf.Replicas = []string{"r1", "r2"}
+	m, err := NewDBManager(map[string]DialectorConfig{
+		defaultInstanceKey: {
+			Dialector: sqlite.Open(filepath.Join(dir, "primary.db")),
+			Replicas:  []gorm.Dialector{sqlite.Open(filepath.Join(dir, "r1.db")), sqlite.Open(filepath.Join(dir, "r2.db"))},
+			Config:    conf,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	db := m.Default()
+	read := func(db *gorm.DB) string {
+		var name string
+		if err := db.Raw("SELECT name FROM t").Scan(&name).Error; err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+
+	// 轮询副本
+	if a, b := read(db), read(db); a == b || a == "primary" || b == "primary" {
+		t.Errorf("expected round robin replicas, got %s %s", a, b)
+	}
+	var row struct{ Name string }
+	if err := db.Table("t").Take(&row).Error; err != nil || row.Name == "primary" {
+		t.Errorf("expected query from replica, got %s %v", row.Name, err)
+	}
+	// 强制主库、事务、写入
+	if got := read(db.WithContext(ForcePrimary(context.Background()))); got != "primary" {
+		t.Errorf("force primary got %s", got)
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if got := read(tx); got != "primary" {
+			t.Errorf("transaction got %s", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("UPDATE t SET lag = 5").Error; err != nil { // 只修改主库
+		t.Fatal(err)
+	}
+	if got := read(db.WithContext(ForcePrimary(context.Background()))); got != "primary" {
+		t.Errorf("force primary got %s", got)
+	}
+
+	// 延迟过大的副本被剔除, 都不可用时使用主库
+	r := db.Config.Plugins[resolverName].(*resolver)
+	r.replicas[0].db.Exec("UPDATE t SET lag = 5")
+	r.check(context.Background())
+	for range 3 {
+		if got := read(db); got != "r2" {
+			t.Errorf("expected r2 after r1 ejected, got %s", got)
+		}
+	}
+	r.replicas[1].db.Exec("UPDATE t SET lag = 5")
+	r.check(context.Background())
+	if got := read(db); got != "primary" {
+		t.Errorf("expected primary when no replica is healthy, got %s", got)
+	}
+	r.replicas[0].db.Exec("UPDATE t SET lag = 0")
+	r.check(context.Background())
+	if got := read(db); got != "r1" {
+		t.Errorf("expected r1 after recovered, got %s", got)
+	}
+}
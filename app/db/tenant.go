@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bobacgo/kit/app/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// 多租户隔离
+/*
+	租户 ID 来自 ctx (见 tenant.WithID), 使用 db.WithContext(ctx) 或 db.Tx(ctx, db)
+
+	1.column: 模型有租户列 (默认 tenant_id) 时, 查询、更新、删除添加 tenant_id = ? 条件, 创建时写入租户 ID
+	2.schema: 表名添加租户的 schema (默认 tenant_{id}.table), MySQL 中为数据库, SQLite 中为 ATTACH 的数据库
+	3.database: 每个租户一个数据源, 数据源的 key 为 tenant_{id} (TenantKeyPrefix), 见 DBManager.ForTenant
+
+	column、schema 模式下 ctx 中没有租户时返回 tenant.ErrMissing, 跨租户操作使用 tenant.Skip(ctx)
+	kit 的系统表 (migrate、outbox、audit) 不按租户隔离, 使用 tenant.Skip 读写, 所有租户共用数据源默认 schema 中的同一张表
+	原生 SQL (Raw、Exec) 不处理, column 模式下没有模型的查询 (Table) 不处理
+*/
+
+const tenantPluginName = "kit:db_tenant"
+
+// TenantMode 租户隔离方式
+type TenantMode string
+
+const (
+	TenantColumn   TenantMode = "column"
+	TenantSchema   TenantMode = "schema"
+	TenantDatabase TenantMode = "database"
+)
+
+// TenantConfig 多租户配置
+type TenantConfig struct {
+	Mode   TenantMode `mapstructure:"mode" validate:"omitempty,oneof=column schema database"` // 为空时不隔离
+	Column string     `mapstructure:"column" default:"tenant_id"`                             // column 模式的租户列
+	Schema string     `mapstructure:"schema" default:"tenant_%s"`                             // schema 模式的 schema 名, %s 为租户 ID
+}
+
+// TenantKeyPrefix database 模式中租户数据源 key 的前缀
+// 租户只能使用自己的数据源, 不会使用 default 或其他业务数据源 (e.g. 租户 ID 为 default、order)
+const TenantKeyPrefix = "tenant_"
+
+// ForTenant ctx 中租户的数据源 (database 模式, key 为 TenantKeyPrefix + 租户 ID), 跳过租户隔离时为默认数据源
+func (m DBManager) ForTenant(ctx context.Context) (*gorm.DB, error) {
+	if tenant.Skipped(ctx) {
+		return m.Default(), nil
+	}
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, tenant.ErrMissing
+	}
+	db, ok := m[TenantKeyPrefix+id]
+	if !ok {
+		return nil, fmt.Errorf("db instance for tenant %s not found", id)
+	}
+	return db.WithContext(ctx), nil
+}
+
+func useTenant(db *gorm.DB, conf TenantConfig) error {
+	switch conf.Mode {
+	case TenantColumn, TenantSchema:
+	default: // database 模式由 DBManager 选择数据源
+		return nil
+	}
+	if conf.Column == "" {
+		conf.Column = "tenant_id"
+	}
+	if conf.Schema == "" {
+		conf.Schema = "tenant_%s"
+	}
+	return db.Use(&tenantPlugin{conf: conf})
+}
+
+type tenantPlugin struct {
+	conf TenantConfig
+}
+
+func (p *tenantPlugin) Name() string {
+	return tenantPluginName
+}
+
+func (p *tenantPlugin) Initialize(db *gorm.DB) error {
+	create, update, fn := p.columnCreate, p.columnUpdate, p.columnWhere
+	if p.conf.Mode == TenantSchema {
+		create, update, fn = p.schema, p.schema, p.schema
+	}
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register(tenantPluginName, create),
+		cb.Query().Before("gorm:query").Register(tenantPluginName, fn),
+		cb.Update().Before("gorm:update").Register(tenantPluginName, update),
+		cb.Delete().Before("gorm:delete").Register(tenantPluginName, fn),
+		cb.Row().Before("gorm:row").Register(tenantPluginName, fn),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenantOf ctx 中的租户, skip 为 true 时不隔离
+func tenantOf(db *gorm.DB) (id string, skip bool) {
+	ctx := db.Statement.Context
+	if tenant.Skipped(ctx) {
+		return "", true
+	}
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		_ = db.AddError(fmt.Errorf("%s: %w", db.Statement.Table, tenant.ErrMissing))
+		return "", true
+	}
+	return id, false
+}
+
+// columnField 模型的租户列, 没有时为 nil
+func (p *tenantPlugin) columnField(db *gorm.DB) *schema.Field {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(p.conf.Column)
+}
+
+// columnCreate 写入租户 ID (覆盖传入的值), 不支持的创建方式返回错误
+func (p *tenantPlugin) columnCreate(db *gorm.DB) {
+	field := p.columnField(db)
+	if field == nil {
+		return
+	}
+	id, skip := tenantOf(db)
+	if skip {
+		return
+	}
+	stmt := db.Statement
+	switch dest := stmt.Dest.(type) { // Model(&T{}).Create(map)
+	case map[string]any:
+		setMapColumn(dest, field, id)
+		return
+	case *map[string]any:
+		setMapColumn(*dest, field, id)
+		return
+	case []map[string]any:
+		for _, m := range dest {
+			setMapColumn(m, field, id)
+		}
+		return
+	case *[]map[string]any:
+		for _, m := range *dest {
+			setMapColumn(m, field, id)
+		}
+		return
+	}
+	switch rv := stmt.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				_ = field.Set(stmt.Context, elem, id)
+			}
+		}
+	case reflect.Struct:
+		_ = field.Set(stmt.Context, rv, id)
+	default:
+		_ = db.AddError(fmt.Errorf("%s: unsupported create value %T for tenant column", stmt.Table, stmt.Dest))
+	}
+}
+
+// setMapColumn map 的 key 可以是列名或字段名, 都替换为列名
+func setMapColumn(m map[string]any, field *schema.Field, id string) {
+	delete(m, field.Name)
+	m[field.DBName] = id
+}
+
+// columnWhere 添加租户条件
+func (p *tenantPlugin) columnWhere(db *gorm.DB) {
+	field := p.columnField(db)
+	if field == nil {
+		return
+	}
+	id, skip := tenantOf(db)
+	if skip {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: id},
+	}})
+}
+
+// columnUpdate 添加租户条件, 不能修改租户列
+func (p *tenantPlugin) columnUpdate(db *gorm.DB) {
+	p.columnWhere(db)
+	if field := p.columnField(db); field != nil && !tenant.Skipped(db.Statement.Context) {
+		db.Statement.Omits = append(db.Statement.Omits, field.DBName)
+	}
+}
+
+func (p *tenantPlugin) schema(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Table == "" || strings.Contains(stmt.Table, ".") {
+		return
+	}
+	if stmt.TableExpr != nil { // Table("name") 可以处理, 其他表达式 (别名、子查询) 不处理
+		if stmt.TableExpr.SQL != stmt.Quote(stmt.Table) {
+			return
+		}
+		stmt.TableExpr = nil
+	}
+	id, skip := tenantOf(db)
+	if skip {
+		return
+	}
+	stmt.Table = fmt.Sprintf(p.conf.Schema, id) + "." + stmt.Table
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/bobacgo/kit/app/tenant"
+	"gorm.io/driver/sqlite"
+)
+
+type tenantItem struct {
+	ID       uint `gorm:"primaryKey"`
+	TenantID string
+	Name     string
+}
+
+func tenantCtx(t *testing.T, id string) context.Context {
+	ctx, err := tenant.WithID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+func TestTenantColumn(t *testing.T) {
+	m, err := NewDBManager(map[string]DialectorConfig{
+		defaultInstanceKey: {Dialector: sqlite.Open(filepath.Join(t.TempDir(), "column.db")), Config: Config{Driver: "sqlite", Tenant: TenantConfig{Mode: TenantColumn}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	db := m.Default()
+	if err := db.AutoMigrate(&tenantItem{}); err != nil {
+		t.Fatal(err)
+	}
+	t1, t2 := tenantCtx(t, "t1"), tenantCtx(t, "t2")
+
+	if err := db.WithContext(t1).Create([]*tenantItem{{Name: "a"}, {Name: "b", TenantID: "t2"}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(t2).Create(&tenantItem{Name: "c"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&tenantItem{Name: "x"}).Error; !errors.Is(err, tenant.ErrMissing) {
+		t.Fatal(err)
+	}
+
+	var items []tenantItem
+	if err := db.WithContext(t1).Order("name").Find(&items).Error; err != nil || len(items) != 2 || items[1].TenantID != "t1" {
+		t.Fatal(items, err)
+	}
+	// 不能修改、删除其他租户的数据
+	res := db.WithContext(t1).Model(&tenantItem{}).Where("name = ?", "c").Update("name", "hacked")
+	if res.Error != nil || res.RowsAffected != 0 {
+		t.Fatal(res.Error, res.RowsAffected)
+	}
+	res = db.WithContext(t1).Model(&tenantItem{}).Where("name = ?", "a").Updates(map[string]any{"name": "a1", "tenant_id": "t2"})
+	if res.Error != nil || res.RowsAffected != 1 {
+		t.Fatal(res.Error, res.RowsAffected)
+	}
+	if res = db.WithContext(t2).Where("1 = 1").Delete(&tenantItem{}); res.RowsAffected != 1 {
+		t.Fatal(res.Error, res.RowsAffected)
+	}
+	var n int64
+	if err := db.WithContext(tenant.Skip(context.Background())).Model(&tenantItem{}).Count(&n).Error; err != nil || n != 2 {
+		t.Fatal(n, err)
+	}
+	if err := db.WithContext(t1).Model(&tenantItem{}).Where("name = ?", "a1").Count(&n).Error; err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+
+	// map 创建时也写入租户 ID
+	if err := db.WithContext(t2).Model(&tenantItem{}).Create(map[string]any{"Name": "m", "TenantID": "t1"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(t2).Model(&tenantItem{}).Create(map[string]any{"name": "m", "tenant_id": "t1"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(t2).Model(&tenantItem{}).Where("name = ?", "m").Count(&n).Error; err != nil || n != 2 {
+		t.Fatal(n, err)
+	}
+}
+
+func TestTenantSchema(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewDBManager(map[string]DialectorConfig{
+		defaultInstanceKey: {Dialector: sqlite.Open(filepath.Join(dir, "main.db")), Config: Config{Driver: "sqlite", MaxOpenConn: 1, MaxIdleConn: 1, Tenant: TenantConfig{Mode: TenantSchema}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	db := m.Default()
+	// SQLite 中 schema 为 ATTACH 的数据库
+	for _, id := range []string{"t1", "t2"} {
+		if err := db.Exec(fmt.Sprintf("ATTACH DATABASE '%s' AS tenant_%s", filepath.Join(dir, id+".db"), id)).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Exec(fmt.Sprintf("CREATE TABLE tenant_%s.tenant_item (id INTEGER PRIMARY KEY, tenant_id TEXT, name TEXT)", id)).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	t1, t2 := tenantCtx(t, "t1"), tenantCtx(t, "t2")
+	if err := db.WithContext(t1).Create(&tenantItem{Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(t2).Table("tenant_item").Create(map[string]any{"name": "b"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	if err := db.WithContext(t1).Model(&tenantItem{}).Pluck("name", &names).Error; err != nil || fmt.Sprint(names) != "[a]" {
+		t.Fatal(names, err)
+	}
+	if err := db.WithContext(t2).Model(&tenantItem{}).Pluck("name", &names).Error; err != nil || fmt.Sprint(names) != "[b]" {
+		t.Fatal(names, err)
+	}
+	if err := db.Model(&tenantItem{}).Pluck("name", &names).Error; !errors.Is(err, tenant.ErrMissing) {
+		t.Fatal(err)
+	}
+}
+
+func TestTenantDatabase(t *testing.T) {
+	dir := t.TempDir()
+	cfgs := make(map[string]DialectorConfig)
+	for _, k := range []string{defaultInstanceKey, "order", TenantKeyPrefix + "t1", TenantKeyPrefix + "t2"} {
+		cfgs[k] = DialectorConfig{Dialector: sqlite.Open(filepath.Join(dir, k+".db")), Config: Config{Driver: "sqlite", Tenant: TenantConfig{Mode: TenantDatabase}}}
+	}
+	m, err := NewDBManager(cfgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+
+	for _, id := range []string{"t1", "t2"} {
+		db, err := m.ForTenant(tenantCtx(t, id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.AutoMigrate(&tenantItem{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&tenantItem{Name: id}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, _ := m.ForTenant(tenantCtx(t, "t2"))
+	var names []string
+	if err := db.Model(&tenantItem{}).Pluck("name", &names).Error; err != nil || fmt.Sprint(names) != "[t2]" {
+		t.Fatal(names, err)
+	}
+	// 租户 ID 与非租户数据源同名时不能使用该数据源
+	for _, id := range []string{"t3", defaultInstanceKey, "order"} {
+		if _, err := m.ForTenant(tenantCtx(t, id)); err == nil {
+			t.Fatalf("expected error for tenant %s", id)
+		}
+	}
+	if _, err := m.ForTenant(context.Background()); !errors.Is(err, tenant.ErrMissing) {
+		t.Fatal(err)
+	}
+}
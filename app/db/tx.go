@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// 上下文事务
+/*
+	事务保存在 ctx 中, biz、dao 层共享同一个事务, 不需要在每个方法签名中传递 tx
+
+	err := dbs.Transaction(ctx, func(ctx context.Context) error {
+		if err := userDao.Create(ctx, user); err != nil { // dao 中使用 db.FromContext(ctx, dbs) 获取事务
+			return err
+		}
+		db.AfterCommit(ctx, func(ctx context.Context) { // 提交成功后发布事件
+			producer.SendMessage(ctx, "user.created", data)
+		})
+		return nil
+	})
+
+	1.fn 返回错误或 panic 时回滚
+	2.嵌套调用 (同一个数据库) 使用 savepoint, 内层回滚不影响外层
+	3.AfterCommit 注册的回调在最外层事务提交后执行, 回滚 (包括内层 savepoint 回滚) 时丢弃
+	4.事务不能并发使用, fn 中不要在多个 goroutine 中使用同一个 ctx 执行 SQL
+*/
+
+type txKey struct{}
+
+// txState ctx 中的事务, 嵌套或多个数据库的事务组成链表
+type txState struct {
+	root  *gorm.Config // 事务所属的数据库 (同一个 *gorm.DB 的所有会话共享)
+	tx    *gorm.DB
+	prev  *txState
+	hooks []func(ctx context.Context)
+}
+
+func lookupTx(ctx context.Context, db *gorm.DB) *txState {
+	st, _ := ctx.Value(txKey{}).(*txState)
+	for ; st != nil; st = st.prev {
+		if st.root == db.Config {
+			return st
+		}
+	}
+	return nil
+}
+
+// Transaction 在 db 上执行事务, 事务保存在传给 fn 的 ctx 中
+// ctx 中已经有 db 的事务时使用 savepoint
+func Transaction(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error, opts ...*sql.TxOptions) error {
+	top, _ := ctx.Value(txKey{}).(*txState)
+	if parent := lookupTx(ctx, db); parent != nil {
+		return parent.tx.Transaction(func(tx *gorm.DB) error {
+			st := &txState{root: db.Config, tx: tx, prev: top}
+			if err := fn(context.WithValue(ctx, txKey{}, st)); err != nil {
+				return err
+			}
+			parent.hooks = append(parent.hooks, st.hooks...) // 随外层事务提交
+			return nil
+		})
+	}
+
+	st := &txState{root: db.Config, prev: top}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		st.tx = tx
+		return fn(context.WithValue(ctx, txKey{}, st))
+	}, opts...)
+	if err != nil {
+		return err
+	}
+	for _, hook := range st.hooks {
+		runHook(ctx, hook)
+	}
+	return nil
+}
+
+// Transaction 在默认数据库上执行事务, 见 Transaction
+func (m DBManager) Transaction(ctx context.Context, fn func(ctx context.Context) error, opts ...*sql.TxOptions) error {
+	return Transaction(ctx, m.Default(), fn, opts...)
+}
+
+// FromContext ctx 中默认数据库的事务, 没有事务时返回默认数据库
+func FromContext(ctx context.Context, m DBManager) *gorm.DB {
+	return Tx(ctx, m.Default())
+}
+
+// Tx ctx 中 db 的事务, 没有事务时返回 db
+func Tx(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if st := lookupTx(ctx, db); st != nil {
+		return st.tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// AfterCommit 注册事务提交后执行的回调 (e.g. 发送消息), ctx 中没有事务时立即执行
+// 回调的 ctx 不包含已提交的事务
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	st, _ := ctx.Value(txKey{}).(*txState)
+	if st == nil {
+		runHook(ctx, fn)
+		return
+	}
+	st.hooks = append(st.hooks, fn)
+}
+
+// runHook 回调 panic 不影响已提交的事务和其他回调
+func runHook(ctx context.Context, fn func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.ErrorContext(ctx, withPrefix(ComponentName, "after commit hook panic"), "err", fmt.Sprint(r))
+		}
+	}()
+	fn(ctx)
+}
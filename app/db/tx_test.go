@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+)
+
+func TestTransaction(t *testing.T) {
+	m, err := NewDBManager(map[string]DialectorConfig{
+		defaultInstanceKey: {Dialector: sqlite.Open(filepath.Join(t.TempDir(), "tx.db")), Config: Config{Driver: "sqlite"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	if err := m.Default().Exec("CREATE TABLE t (name TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	insert := func(ctx context.Context, name string) error {
+		return FromContext(ctx, m).Exec("INSERT INTO t VALUES (?)", name).Error
+	}
+	names := func() []string {
+		var names []string
+		m.Default().Raw("SELECT name FROM t ORDER BY name").Scan(&names)
+		return names
+	}
+
+	var committed []string
+	errRollback := errors.New("rollback")
+	err = m.Transaction(ctx, func(ctx context.Context) error {
+		if err := insert(ctx, "a"); err != nil {
+			return err
+		}
+		AfterCommit(ctx, func(context.Context) { committed = append(committed, "a") })
+
+		// 内层回滚到 savepoint, 回调丢弃
+		err := m.Transaction(ctx, func(ctx context.Context) error {
+			_ = insert(ctx, "b")
+			AfterCommit(ctx, func(context.Context) { committed = append(committed, "b") })
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Errorf("unexpected nested error %v", err)
+		}
+		// 内层提交, 回调随外层执行
+		err = m.Transaction(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func(context.Context) { committed = append(committed, "c") })
+			return insert(ctx, "c")
+		})
+		if len(committed) > 0 {
+			t.Error("hooks should run after outer commit")
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("unexpected rows %v", got)
+	}
+	if len(committed) != 2 || committed[0] != "a" || committed[1] != "c" {
+		t.Errorf("unexpected hooks %v", committed)
+	}
+
+	// 外层回滚
+	committed = nil
+	err = m.Transaction(ctx, func(ctx context.Context) error {
+		_ = insert(ctx, "d")
+		AfterCommit(ctx, func(context.Context) { committed = append(committed, "d") })
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) || len(names()) != 2 || len(committed) != 0 {
+		t.Errorf("rollback failed: %v %v %v", err, names(), committed)
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/bobacgo/kit/app/conf"
 	"github.com/bobacgo/kit/app/server"
+	"github.com/bobacgo/kit/app/server/admin"
 	"github.com/bobacgo/kit/app/validator"
 	"github.com/bobacgo/kit/enum"
 	pkgvalidator "github.com/go-playground/validator/v10"
@@ -66,7 +67,8 @@ func (srv *HttpServer) Start(ctx context.Context) error {
 		*valid = *validator.Get()
 	}
 
-	e.Use(gin.Logger())
+	e.Use(middleware.LogFields(srv.Opts.AppID()))
+	e.Use(middleware.AccessLog(cfg.Logger.Access))
 	e.Use(middleware.Recovery())
 	e.Use(middleware.LoggerResponseFail())
 	if cfg.Otel.Tracer.GrpcEndpoint != "" {
@@ -80,6 +82,7 @@ func (srv *HttpServer) Start(ctx context.Context) error {
 	srv.healthApi(e, cfg) // provide health API
 	srv.swaggerApi(e)     // provide swagger API
 	srv.pprofApi(e)       // provide pprof API
+	srv.adminApi(e, cfg)  // provide admin API
 
 	if srv.RegistryFn != nil {
 		srv.RegistryFn(e, srv.Opts) // register router
@@ -141,3 +144,24 @@ func (srv *HttpServer) pprofApi(e *gin.Engine) {
 	e.GET("/debug/pprof/mutex", gin.WrapF(pprof.Handler("mutex").ServeHTTP))
 	e.GET("/debug/pprof/threadcreate", gin.WrapF(pprof.Handler("threadcreate").ServeHTTP))
 }
+
+// adminApi 运维管理接口, 配置 server.admin.enabled 后注册
+// 访问地址: http://localhost:8080/debug/config?format=yaml
+func (srv *HttpServer) adminApi(e *gin.Engine, cfg *conf.Basic) {
+	if opts, ok := adminOptions(cfg); ok {
+		admin.RegisterGin(e.Group("/debug"), opts...)
+	}
+}
+
+// adminOptions 是否注册管理接口, 配置了 token 时校验调用方
+func adminOptions(cfg *conf.Basic) ([]admin.Option, bool) {
+	c := cfg.Server.Admin
+	if !c.Enabled {
+		return nil, false
+	}
+	if c.Token == "" {
+		slog.Warn("[admin] admin API is enabled without token, mutating operations are disabled", "env", cfg.Env)
+		return nil, true
+	}
+	return []admin.Option{admin.WithAuth(admin.TokenAuth("admin-token", c.Token))}, true
+}
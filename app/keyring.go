@@ -0,0 +1,43 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+
+	"github.com/bobacgo/kit/app/conf"
+	"github.com/bobacgo/kit/web/orm"
+)
+
+const compKeyring = "keyring"
+
+// useKeyring 配置了 security.ciphertext.dataKeys 时设置字段加密的密钥 (orm.SetKeyring)
+// 密钥无效时直接 panic, 避免启动后读写加密字段都失败
+func (o *AppOptions) useKeyring() {
+	cfg := o.conf.Security.Ciphertext
+	if len(cfg.DataKeys) == 0 {
+		return
+	}
+	k, err := orm.NewKeyring(cfg)
+	if err != nil {
+		log.Panicf("init data keys failed: %v", err)
+	}
+	orm.SetKeyring(k)
+	components[compKeyring] = struct{}{}
+	slog.Info(fmt.Sprintf(initDoneFmt, compKeyring))
+}
+
+// reloadKeyring 配置文件变化或密钥轮换后重新设置密钥
+// 新配置没有 dataKeys 或无效时保留之前的密钥 (删除密钥会导致已加密的数据无法读取)
+func reloadKeyring() {
+	cfg := conf.GetBasicConf().Security.Ciphertext
+	if len(cfg.DataKeys) == 0 {
+		return
+	}
+	k, err := orm.NewKeyring(cfg)
+	if err != nil {
+		slog.Error("[config] reload data keys error", "err", err)
+		return
+	}
+	orm.SetKeyring(k)
+}
@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobacgo/kit/pkg/tag"
+)
+
+// 访问日志 (http 中间件与 grpc 拦截器共用)
+/*
+	每个请求输出一条结构化日志 (模块 access, 级别可单独调整), 不经过 logger.dedup、rateLimit、sampling (见 throttledCore)
+	trace_id、span_id 由 traceHandler 从 ctx 中添加
+
+	包体记录默认关闭, 开启后按 maxSize 截断, 并按以下规则脱敏:
+		1.maskFields 配置的字段完全脱敏
+		2.RegisterMask 注册的结构体中带 mask 标签的字段按标签规则脱敏
+		3.非 json 包体无法按字段脱敏, 只记录大小
+		4.http 包体最多在内存中保存 maxSize 字节 (见 BodyCapture), 超过的包体无法按字段脱敏, 只记录大小
+*/
+
+const (
+	AccessModule = "access"
+
+	defaultMaxBodySize = 2048
+)
+
+// AccessConfig 访问日志配置
+type AccessConfig struct {
+	Disable    bool             `mapstructure:"disable"`                      // 关闭访问日志
+	SkipPaths  []string         `mapstructure:"skipPaths" yaml:"skipPaths"`   // 不记录的路由或 grpc 方法 (e.g. /health)
+	Body       AccessBodyConfig `mapstructure:"body"`                         // 包体记录
+	MaskFields []string         `mapstructure:"maskFields" yaml:"maskFields"` // 完全脱敏的 json 字段名 (e.g. password)
+}
+
+// AccessBodyConfig 包体记录配置
+type AccessBodyConfig struct {
+	Request  bool `mapstructure:"request"`                               // 记录请求包体
+	Response bool `mapstructure:"response"`                              // 记录响应包体
+	MaxSize  int  `mapstructure:"maxSize" yaml:"maxSize" default:"2048"` // 单位 byte, 超过部分截断
+}
+
+// Skip 是否不记录该路由
+func (c *AccessConfig) Skip(path string) bool {
+	return c.Disable || slices.Contains(c.SkipPaths, path)
+}
+
+// Access 一次请求的访问日志
+type Access struct {
+	Protocol  string        // http、grpc
+	Method    string        // GET、POST; grpc 为 unary、stream
+	Route     string        // 路由模板 (e.g. /users/:id); grpc 为完整方法名
+	Status    int           // http 状态码; grpc 状态码
+	Code      *int          // 业务码 (r.Response.Code), 没有时为 nil
+	Latency   time.Duration // 耗时
+	Bytes     int           // 响应大小
+	ClientIP  string
+	UserAgent string
+	Subject   string // jwt subject
+	Request   []byte // 请求包体 (已截断、脱敏)
+	Response  []byte // 响应包体 (已截断、脱敏)
+	Err       error
+	Level     slog.Level
+}
+
+var (
+	accessLog = namedUnthrottled(AccessModule) // 每个请求一条, 不经过限流
+
+	maskRulesMu sync.RWMutex
+	maskRules   = make(map[string]string) // json 字段名 -> 脱敏规则
+)
+
+// RegisterMask 注册包含 mask 标签的结构体 (e.g. 请求参数), 访问日志记录包体时按标签脱敏
+func RegisterMask(v ...any) {
+	maskRulesMu.Lock()
+	defer maskRulesMu.Unlock()
+	for _, item := range v {
+		maps.Copy(maskRules, tag.MaskRules(item))
+	}
+}
+
+// Redact 按配置脱敏并截断包体
+// 非 json 包体无法按字段脱敏, 只记录大小
+func (c *AccessConfig) Redact(data []byte) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	if !json.Valid(data) {
+		return []byte(fmt.Sprintf("(non-json body, %d bytes)", len(data)))
+	}
+	maskRulesMu.RLock()
+	rules := maps.Clone(maskRules)
+	maskRulesMu.RUnlock()
+	for _, field := range c.MaskFields {
+		rules[field] = "^.*$"
+	}
+	data = tag.MaskJSON(data, rules)
+	if c.Body.MaxSize > 0 && len(data) > c.Body.MaxSize {
+		data = append(data[:c.Body.MaxSize:c.Body.MaxSize], "...(truncated)"...)
+	}
+	return data
+}
+
+// BodyCapture 包体捕获, 最多保存 limit 字节, 其余只计数
+type BodyCapture struct {
+	buf   bytes.Buffer
+	limit int
+	size  int
+}
+
+// NewCapture 按 MaxSize 限制的包体捕获, MaxSize 为 0 时使用默认值
+func (c *AccessConfig) NewCapture() *BodyCapture {
+	limit := c.Body.MaxSize
+	if limit <= 0 {
+		limit = defaultMaxBodySize
+	}
+	return &BodyCapture{limit: limit}
+}
+
+func (b *BodyCapture) Write(p []byte) (int, error) {
+	b.size += len(p)
+	if n := b.limit - b.buf.Len(); n > 0 {
+		b.buf.Write(p[:min(n, len(p))])
+	}
+	return len(p), nil
+}
+
+// Size 写入的总字节数
+func (b *BodyCapture) Size() int {
+	return b.size
+}
+
+// RedactCapture 脱敏捕获的包体, 超过上限的包体只记录大小
+func (c *AccessConfig) RedactCapture(b *BodyCapture) []byte {
+	if b == nil || b.size == 0 {
+		return nil
+	}
+	if b.size > b.buf.Len() {
+		return []byte(fmt.Sprintf("(body too large, %d bytes)", b.size))
+	}
+	return c.Redact(b.buf.Bytes())
+}
+
+// LogAccess 输出访问日志
+func LogAccess(ctx context.Context, a *Access) {
+	if !accessLog.Enabled(ctx, a.Level) {
+		return
+	}
+	attrs := make([]slog.Attr, 0, 14)
+	attrs = append(attrs,
+		slog.String("protocol", a.Protocol),
+		slog.String("method", a.Method),
+		slog.String("route", a.Route),
+		slog.Int("status", a.Status),
+	)
+	if a.Code != nil {
+		attrs = append(attrs, slog.Int("code", *a.Code))
+	}
+	attrs = append(attrs,
+		slog.Duration("latency", a.Latency),
+		slog.Int("bytes", a.Bytes),
+	)
+	if a.ClientIP != "" {
+		attrs = append(attrs, slog.String("client_ip", a.ClientIP))
+	}
+	if a.UserAgent != "" {
+		attrs = append(attrs, slog.String("user_agent", a.UserAgent))
+	}
+	if a.Subject != "" {
+		attrs = append(attrs, slog.String("subject", a.Subject))
+	}
+	if len(a.Request) > 0 {
+		attrs = append(attrs, slog.String("request", string(a.Request)))
+	}
+	if len(a.Response) > 0 {
+		attrs = append(attrs, slog.String("response", string(a.Response)))
+	}
+	if a.Err != nil {
+		attrs = append(attrs, slog.String("error", a.Err.Error()))
+	}
+	msg := "[" + a.Protocol + "] " + strings.TrimSpace(a.Method+" "+a.Route)
+	accessLog.LogAttrs(ctx, a.Level, msg, attrs...)
+}
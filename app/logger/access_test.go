@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccess(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	var core zapcore.Core = obs
+	baseCore.Store(&core)
+	t.Cleanup(func() { baseCore.Store(nil) })
+	SetLevel(LogLevel_Info)
+
+	type LoginReq struct {
+		Username string `json:"username"`
+		Phone    string `json:"phone" mask:""`
+		Password string `json:"password"`
+	}
+	RegisterMask(LoginReq{})
+	cfg := AccessConfig{MaskFields: []string{"password"}, Body: AccessBodyConfig{MaxSize: 64}}
+
+	body := string(cfg.Redact([]byte(`{"username":"admin","phone":"13800138000","password":"123456"}`)))
+	if strings.Contains(body, "123456") || strings.Contains(body, "13800138000") || !strings.Contains(body, "admin") {
+		t.Errorf("body not redacted: %s", body)
+	}
+	if body := cfg.Redact([]byte(`{"username":"` + strings.Repeat("a", 100) + `"}`)); len(body) != 64+len("...(truncated)") {
+		t.Errorf("body not truncated: %s", body)
+	}
+	if body := string(cfg.Redact([]byte("password=123456"))); strings.Contains(body, "123456") {
+		t.Errorf("non-json body should not be logged: %s", body)
+	}
+
+	capture := cfg.NewCapture()
+	_, _ = capture.Write([]byte(`{"password":`))
+	_, _ = capture.Write([]byte(`"123456"}`))
+	if body := string(cfg.RedactCapture(capture)); strings.Contains(body, "123456") || !strings.Contains(body, "password") {
+		t.Errorf("captured body not redacted: %s", body)
+	}
+	capture = cfg.NewCapture()
+	_, _ = capture.Write([]byte(`{"password":"` + strings.Repeat("1", 1000) + `"}`))
+	if body := string(cfg.RedactCapture(capture)); body != "(body too large, 1015 bytes)" || capture.buf.Len() != 64 {
+		t.Errorf("oversized body should only log size: %s", body)
+	}
+
+	code := 400
+	LogAccess(context.Background(), &Access{Protocol: "http", Method: "POST", Route: "/login", Status: 200, Code: &code, Latency: time.Millisecond, Level: slog.LevelWarn})
+	entries := logs.TakeAll()
+	if len(entries) != 1 || entries[0].Message != "[http] POST /login" || entries[0].LoggerName != AccessModule {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	fields := entries[0].ContextMap()
+	if fields["route"] != "/login" || fields["code"] != int64(400) || fields["status"] != int64(200) {
+		t.Errorf("unexpected fields %+v", fields)
+	}
+	if cfg.Skip("/login") || !(&AccessConfig{SkipPaths: []string{"/health"}}).Skip("/health") {
+		t.Error("unexpected skip")
+	}
+}
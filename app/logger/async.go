@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 异步写入
+/*
+	logger.async 配置后文件和控制台异步写入 (sinks 始终异步), 与 sink 共用缓冲队列 (见 sink_buffer.go)
+	队列满时按 dropPolicy 处理, 丢弃的条数见 Dropped() 和指标 logger.dropped (属性 sink)
+	Close 之后的日志不再进入队列, 直接同步写入
+	服务退出时 (App.Run 返回前, 所有 afterStop 之后) 调用 Close 写入队列中剩余的日志
+	Panic、Fatal 级别的日志写入时立即刷新队列, 进程退出前不会丢失
+*/
+
+const meterName = "github.com/bobacgo/kit/app/logger"
+
+var (
+	droppedMu  sync.Mutex
+	droppedMap = make(map[string]*atomic.Uint64) // sink 名称 -> 累计丢弃的条数
+
+	metricOnce sync.Once
+)
+
+// newAsyncCore 编码后放入缓冲队列, 由后台协程写入 ws
+// ws 实现了 io.Closer 时, 关闭返回的 io.Closer 会一并关闭
+func newAsyncCore(name string, enc zapcore.Encoder, ws zapcore.WriteSyncer, level zapcore.LevelEnabler, conf BufferConfig) (zapcore.Core, io.Closer) {
+	bw := newBufferedWriter(name, writerBatch{ws}, conf)
+	return &sinkCore{LevelEnabler: level, enc: enc, out: bw}, bw
+}
+
+func droppedCounter(name string) *atomic.Uint64 {
+	metricOnce.Do(registerDroppedMetric)
+	droppedMu.Lock()
+	defer droppedMu.Unlock()
+	c, ok := droppedMap[name]
+	if !ok {
+		c = new(atomic.Uint64)
+		droppedMap[name] = c
+	}
+	return c
+}
+
+// Dropped 各 sink 队列满时累计丢弃的日志条数
+func Dropped() map[string]uint64 {
+	droppedMu.Lock()
+	defer droppedMu.Unlock()
+	out := make(map[string]uint64, len(droppedMap))
+	for name, c := range droppedMap {
+		out[name] = c.Load()
+	}
+	return out
+}
+
+// registerDroppedMetric 使用全局 MeterProvider, 未配置时为 noop
+func registerDroppedMetric() {
+	meter := otel.Meter(meterName)
+	_, err := meter.Int64ObservableCounter("logger.dropped",
+		metric.WithDescription("number of log entries dropped by async sinks"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			droppedMu.Lock()
+			counters := maps.Clone(droppedMap)
+			droppedMu.Unlock()
+			for name, c := range counters {
+				o.Observe(int64(c.Load()), metric.WithAttributes(attribute.String("sink", name)))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+}
+
+// Close 刷新并关闭所有输出 (去重汇总、sink、异步队列、日志文件), 服务停止时调用
+// 之后的日志同步写入: 日志文件会重新打开, sink 重新连接
+func Close() error {
+	_ = zap.L().Sync() // 标准输出 Sync 会返回 invalid argument, 忽略
+	sinkMu.Lock()
+	closers := sinkClosers
+	sinkClosers = nil
+	sinkMu.Unlock()
+
+	var errs []error
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestAsyncClose(t *testing.T) {
+	dir := t.TempDir()
+	defaultLogger := slog.Default()
+	t.Cleanup(func() {
+		baseCore.Store(nil)
+		slog.SetDefault(defaultLogger)
+	})
+
+	conf := NewConfig(WithFilepath(dir), WithFilename("async"), WithAsync(&BufferConfig{Size: 16, FlushInterval: "1h"}))
+	InitZapLogger(conf)
+	for range 10 {
+		slog.Info("async line")
+	}
+	if err := Close(); err != nil { // 未到刷新间隔, 关闭时写入
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "async.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "async line"); n != 10 {
+		t.Errorf("got %d lines, want 10", n)
+	}
+
+	dropped := Dropped()[string(SinkFile)]
+	slog.Info("after close") // 队列已关闭, 同步写入
+	data, err = os.ReadFile(filepath.Join(dir, "async.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "after close") || Dropped()[string(SinkFile)] != dropped {
+		t.Errorf("log after close not written, dropped = %v", Dropped())
+	}
+}
+
+func TestAsyncFatalFlush(t *testing.T) {
+	dir := t.TempDir()
+	defaultLogger := slog.Default()
+	t.Cleanup(func() {
+		_ = Close()
+		baseCore.Store(nil)
+		slog.SetDefault(defaultLogger)
+	})
+
+	InitZapLogger(NewConfig(WithFilepath(dir), WithFilename("async"), WithAsync(&BufferConfig{Size: 16, FlushInterval: "1h"})))
+	slog.Info("before panic")
+	zap.L().DPanic("dpanic line") // 生产模式下不 panic, 级别高于 Error 时立即写入
+	data, err := os.ReadFile(filepath.Join(dir, "async.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "before panic") || !strings.Contains(string(data), "dpanic line") {
+		t.Errorf("unexpected log file %q", data)
+	}
+}
@@ -1,10 +1,7 @@
 package logger
 
 import (
-	"fmt"
 	"os"
-	"path/filepath"
-	"time"
 
 	"github.com/spf13/pflag"
 )
@@ -23,30 +20,49 @@ type Config struct {
 	LevelCh    chan LogLevel `mapstructure:"-" json:"-" yaml:"-"`
 	TimeFormat string        `mapstructure:"timeFormat" yaml:"timeFormat" default:"2006-01-02 15:04:05"`
 
-	// 完整的文件路径名
+	// 完整的文件路径名 (当前文件 {filepath}/{filename}.{fileExtension}, 见 rotate.go)
 	Filepath        string `mapstructure:"filepath"`
 	Filename        string `mapstructure:"filename" default:"server"`
-	FilenameSuffix  string `mapstructure:"filenameSuffix" yaml:"filenameSuffix" default:"2006-01-02-150405"`
+	FilenameSuffix  string `mapstructure:"filenameSuffix" yaml:"filenameSuffix" default:"2006-01-02-150405"` // 备份文件名中的时间格式
 	FileExtension   string `mapstructure:"fileExtension" yaml:"fileExtension" default:"log"`
 	FileJsonEncoder bool   `mapstructure:"fileJsonEncoder" yaml:"fileJsonEncoder"`
 
-	FileMaxSize  uint16 `mapstructure:"fileSizeMax" yaml:"fileSizeMax" default:"10"`     // 单位是MB 默认值是 10MB
-	FileMaxAge   uint16 `mapstructure:"fileAgeMax" yaml:"fileAgeMax" default:"180"`      // 留存天数
-	FileCompress bool   `mapstructure:"fileCompress" yaml:"fileCompress" default:"true"` // 是否归档压缩
+	FileRotate       RotateInterval `mapstructure:"fileRotate" yaml:"fileRotate" default:"daily" validate:"omitempty,oneof=none daily hourly"` // 按时间切割
+	FileMaxSize      uint16         `mapstructure:"fileSizeMax" yaml:"fileSizeMax" default:"10"`                                               // 单位是MB 默认值是 10MB
+	FileMaxAge       uint16         `mapstructure:"fileAgeMax" yaml:"fileAgeMax" default:"180"`                                                // 留存天数
+	FileMaxBackups   uint16         `mapstructure:"fileBackupsMax" yaml:"fileBackupsMax" default:"30"`                                         // 备份数量
+	FileMaxTotalSize uint32         `mapstructure:"fileTotalSizeMax" yaml:"fileTotalSizeMax"`                                                  // 所有日志文件的总大小 (MB), 0 不限制
+	FileCompress     bool           `mapstructure:"fileCompress" yaml:"fileCompress" default:"true"`                                           // 是否归档压缩
+	FileSymlink      bool           `mapstructure:"fileSymlink" yaml:"fileSymlink"`                                                            // 写入带时间的文件, 当前文件名为软链接
+
+	// 日志输出, 为空时输出到文件和控制台 (见 sink.go)
+	// file 类型使用上面的 file* 配置, 最多一个 (多个会在同一路径上各自切割)
+	Sinks []SinkConfig `mapstructure:"sinks" validate:"single=Type file,dive"`
+
+	// 日志脱敏, 为空时启用默认的检测器 (见 redact.go)
+	Redact *RedactConfig `mapstructure:"redact"`
+
+	// 文件和控制台异步写入, 为空时同步写入 (见 async.go)
+	Async *BufferConfig `mapstructure:"async"`
+
+	// 日志限流, 为空不启用 (见 throttle.go)
+	Sampling  *SamplingConfig  `mapstructure:"sampling"`                   // 采样
+	Dedup     *DedupConfig     `mapstructure:"dedup"`                      // 去重
+	RateLimit *RateLimitConfig `mapstructure:"rateLimit" yaml:"rateLimit"` // 按调用位置限速
+
+	// 访问日志 (见 access.go)
+	Access AccessConfig `mapstructure:"access"`
+
+	// 从上游 (x-log-*) 接受的上下文日志字段, 为空时只接受 request_id; user、tenant、app_id 不会从上游读取 (见 fields.go)
+	PropagateFields []string `mapstructure:"propagateFields" yaml:"propagateFields"`
+
+	appName string // 服务名称 (sink 的标签、syslog APP-NAME 等)
 }
 
 func (c *Config) SetLevel(level LogLevel) {
 	c.LevelCh <- level
 }
 
-// xxx/logs/xxx-service-2006-01-01-150405.log
-func (c *Config) makeFilename() string {
-	if c.FilenameSuffix != "" {
-		c.FilenameSuffix = "-" + time.Now().Format(c.FilenameSuffix)
-	}
-	return filepath.Join(c.Filepath, fmt.Sprintf("%s%s.%s", c.Filename, c.FilenameSuffix, c.FileExtension))
-}
-
 type Option func(*Config)
 
 func NewConfig(opts ...Option) Config {
@@ -56,9 +72,12 @@ func NewConfig(opts ...Option) Config {
 		Filename:       filenameDefault,
 		FilenameSuffix: filenameSuffixDefault,
 		FileExtension:  fileExtensionDefault,
+		FileRotate:     RotateDaily,
 		FileMaxSize:    10,
 		FileMaxAge:     6 * 30,
+		FileMaxBackups: 30,
 		FileCompress:   true,
+		Access:         AccessConfig{Body: AccessBodyConfig{MaxSize: 2048}},
 		LevelCh:        make(chan LogLevel, 1),
 	}
 	for _, opt := range opts {
@@ -89,7 +108,7 @@ func WithFilepath(filepath string) Option {
 	}
 }
 
-// WithFilename 文件名(文件前缀), 随机部分 main-service-2023-11-04
+// WithFilename 文件名(文件前缀) main-service.log
 func WithFilename(filename string) Option {
 	return func(o *Config) {
 		if filename != "" {
@@ -98,7 +117,7 @@ func WithFilename(filename string) Option {
 	}
 }
 
-// WithFilenameSuffix 文件后缀名, 随机部分 main-service-2023-11-04
+// WithFilenameSuffix 备份文件名中的时间格式 main-service-2023-11-04.log
 func WithFilenameSuffix(filenameSuffix string) Option {
 	return func(o *Config) {
 		o.FilenameSuffix = filenameSuffix
@@ -135,6 +154,97 @@ func WithFileMaxAge(maxAge uint16) Option {
 	}
 }
 
+// WithFileRotate 按时间切割 (none、daily、hourly)
+func WithFileRotate(rotate RotateInterval) Option {
+	return func(o *Config) {
+		o.FileRotate = rotate
+	}
+}
+
+// WithFileMaxBackups 备份文件保留数量, 0 不限制
+func WithFileMaxBackups(maxBackups uint16) Option {
+	return func(o *Config) {
+		o.FileMaxBackups = maxBackups
+	}
+}
+
+// WithFileMaxTotalSize 日志文件总大小 (MB), 超过时删除最旧的备份, 0 不限制
+func WithFileMaxTotalSize(maxTotalSize uint32) Option {
+	return func(o *Config) {
+		o.FileMaxTotalSize = maxTotalSize
+	}
+}
+
+// WithFileSymlink 写入带时间的文件, 当前文件名为指向它的软链接
+func WithFileSymlink(symlink bool) Option {
+	return func(o *Config) {
+		o.FileSymlink = symlink
+	}
+}
+
+// WithSinks 日志输出
+func WithSinks(sinks ...SinkConfig) Option {
+	return func(o *Config) {
+		o.Sinks = sinks
+	}
+}
+
+// WithRedact 日志脱敏
+func WithRedact(redact *RedactConfig) Option {
+	return func(o *Config) {
+		o.Redact = redact
+	}
+}
+
+// WithAsync 文件和控制台异步写入
+func WithAsync(async *BufferConfig) Option {
+	return func(o *Config) {
+		o.Async = async
+	}
+}
+
+// WithSampling 日志采样
+func WithSampling(sampling *SamplingConfig) Option {
+	return func(o *Config) {
+		o.Sampling = sampling
+	}
+}
+
+// WithDedup 相同日志去重
+func WithDedup(dedup *DedupConfig) Option {
+	return func(o *Config) {
+		o.Dedup = dedup
+	}
+}
+
+// WithRateLimit 按调用位置限速
+func WithRateLimit(rateLimit *RateLimitConfig) Option {
+	return func(o *Config) {
+		o.RateLimit = rateLimit
+	}
+}
+
+// WithAccess 访问日志
+func WithAccess(access AccessConfig) Option {
+	return func(o *Config) {
+		o.Access = access
+	}
+}
+
+// WithPropagateFields 从上游接受的上下文日志字段, 为空时只接受 request_id
+func WithPropagateFields(fields ...string) Option {
+	return func(o *Config) {
+		o.PropagateFields = fields
+	}
+}
+
+// withAppName 服务名称
+func withAppName(appName string) Option {
+	return func(o *Config) {
+		o.appName = appName
+	}
+}
+
 // WithFileCompress 是否归档压缩
 func WithFileCompress(compress bool) Option {
 	return func(o *Config) {
@@ -162,7 +272,7 @@ func New(appName string, logCfg Config) Config {
 		opts = append(opts, WithTimeFormat(logCfg.TimeFormat))
 	}
 	if appName != "" {
-		opts = append(opts, WithFilename(appName))
+		opts = append(opts, WithFilename(appName), withAppName(appName))
 	}
 	if logCfg.Filepath != "" {
 		opts = append(opts, WithFilepath(logCfg.Filepath))
@@ -179,12 +289,28 @@ func New(appName string, logCfg Config) Config {
 	if logCfg.FileMaxAge > 0 {
 		opts = append(opts, WithFileMaxAge(logCfg.FileMaxAge))
 	}
+	if logCfg.FileRotate != "" {
+		opts = append(opts, WithFileRotate(logCfg.FileRotate))
+	}
+	if logCfg.FileMaxBackups > 0 {
+		opts = append(opts, WithFileMaxBackups(logCfg.FileMaxBackups))
+	}
+	if logCfg.FileMaxTotalSize > 0 {
+		opts = append(opts, WithFileMaxTotalSize(logCfg.FileMaxTotalSize))
+	}
+	if logCfg.FileSymlink {
+		opts = append(opts, WithFileSymlink(logCfg.FileSymlink))
+	}
 	if logCfg.FileJsonEncoder {
 		opts = append(opts, WithFileJsonEncoder(logCfg.FileJsonEncoder))
 	}
 	if logCfg.FileCompress {
 		opts = append(opts, WithFileCompress(logCfg.FileCompress))
 	}
+	if len(logCfg.Sinks) > 0 {
+		opts = append(opts, WithSinks(logCfg.Sinks...))
+	}
+	opts = append(opts, WithRedact(logCfg.Redact), WithAsync(logCfg.Async), WithSampling(logCfg.Sampling), WithDedup(logCfg.Dedup), WithRateLimit(logCfg.RateLimit), WithAccess(logCfg.Access), WithPropagateFields(logCfg.PropagateFields...))
 
 	cfg := NewConfig(opts...)
 	// 初始化日志配置
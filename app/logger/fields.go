@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// 上下文日志字段
+/*
+	logger.WithFields(ctx, slog.String("tenant", "t1")) 添加的字段会输出到使用该 ctx 的每条日志中 (由 traceHandler 添加)
+
+	跨服务传递 (http header、grpc metadata、kafka header) 时:
+		1.key 为 x-log-{字段名}, 字段名中的 _ 转换为 - (e.g. request_id -> x-log-request-id)
+		2.值转换为字符串
+	http 中间件、grpc 拦截器、kafka 生产者和消费者会自动传递, 见 InjectFields、ExtractFields
+
+	上游的值由客户端控制, 读取时:
+		1.只接受 Config.PropagateFields 中的字段, 为空时只接受 request_id
+		2.user、tenant、app_id 标识调用方, 始终由本服务设置, 不从上游读取
+		3.最多读取 16 个字段, 超过 256 字节的值忽略
+*/
+
+const (
+	FieldsPrefix = "x-log-"
+
+	// 常用字段
+	FieldRequestID = "request_id"
+	FieldAppID     = "app_id"
+	FieldTenant    = "tenant"
+	FieldUser      = "user"
+)
+
+const (
+	maxPropagatedFields = 16
+	maxPropagatedValue  = 256
+)
+
+// reservedFields 不从上游读取的字段
+var reservedFields = []string{FieldUser, FieldTenant, FieldAppID}
+
+// propagated 从上游接受的字段
+var propagated atomic.Pointer[[]string]
+
+// setPropagateFields 设置从上游接受的字段, 为空时只接受 request_id
+func setPropagateFields(names []string) {
+	if len(names) == 0 {
+		names = []string{FieldRequestID}
+	}
+	allow := make([]string, 0, len(names))
+	for _, name := range names {
+		if !slices.Contains(reservedFields, name) {
+			allow = append(allow, name)
+		}
+	}
+	propagated.Store(&allow)
+}
+
+func propagatable(name string) bool {
+	if allow := propagated.Load(); allow != nil {
+		return slices.Contains(*allow, name)
+	}
+	return name == FieldRequestID
+}
+
+type fieldsKey struct{}
+
+// WithFields 添加上下文日志字段, 相同 key 的字段后添加的覆盖之前的
+func WithFields(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	old := Fields(ctx)
+	fields := make([]slog.Attr, len(old), len(old)+len(attrs))
+	copy(fields, old)
+	for _, a := range attrs {
+		replaced := false
+		for i := range fields {
+			if fields[i].Key == a.Key {
+				fields[i], replaced = a, true
+				break
+			}
+		}
+		if !replaced {
+			fields = append(fields, a)
+		}
+	}
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// Fields 上下文日志字段
+func Fields(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	return fields
+}
+
+// FieldValue 获取上下文日志字段的值 (字符串), 不存在时为空
+func FieldValue(ctx context.Context, key string) string {
+	for _, a := range Fields(ctx) {
+		if a.Key == key {
+			return a.Value.String()
+		}
+	}
+	return ""
+}
+
+// InjectFields 写入跨服务传递的字段
+func InjectFields(ctx context.Context, carrier propagation.TextMapCarrier) {
+	for _, a := range Fields(ctx) {
+		carrier.Set(FieldsPrefix+strings.ReplaceAll(a.Key, "_", "-"), a.Value.String())
+	}
+}
+
+// validPropagated 上游传递的值不超过 maxPropagatedValue, 只包含可打印字符 (防止伪造日志行)
+func validPropagated(v string) bool {
+	return len(v) <= maxPropagatedValue && utf8.ValidString(v) && strings.IndexFunc(v, func(r rune) bool { return !unicode.IsPrint(r) }) < 0
+}
+
+// ValidRequestID 上游传递的请求 ID 是否可用, 校验同 x-log-* 字段, 不可用时应重新生成
+func ValidRequestID(id string) bool {
+	return id != "" && validPropagated(id)
+}
+
+// ExtractFields 读取上游传递的字段, 只接受配置允许的字段
+func ExtractFields(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	var attrs []slog.Attr
+	for _, k := range carrier.Keys() {
+		name, ok := strings.CutPrefix(strings.ToLower(k), FieldsPrefix)
+		if !ok || name == "" {
+			continue
+		}
+		name = strings.ReplaceAll(name, "-", "_")
+		v := carrier.Get(k)
+		if !propagatable(name) || !validPropagated(v) {
+			continue
+		}
+		if attrs = append(attrs, slog.String(name, v)); len(attrs) == maxPropagatedFields {
+			break
+		}
+	}
+	return WithFields(ctx, attrs...)
+}
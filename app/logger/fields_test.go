@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFields(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	var core zapcore.Core = obs
+	baseCore.Store(&core)
+	t.Cleanup(func() { baseCore.Store(nil) })
+	SetLevel(LogLevel_Info)
+
+	ctx := WithFields(context.Background(), slog.String(FieldTenant, "t1"), slog.String(FieldRequestID, "r1"))
+	ctx = WithFields(ctx, slog.String(FieldTenant, "t2"))
+	Named("test-fields").InfoContext(ctx, "hello")
+	entries := logs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if fields := entries[0].ContextMap(); fields[FieldTenant] != "t2" || fields[FieldRequestID] != "r1" {
+		t.Errorf("unexpected fields %+v", fields)
+	}
+
+	// http header -> 下游
+	header := http.Header{}
+	InjectFields(ctx, propagation.HeaderCarrier(header))
+	if header.Get("X-Log-Request-Id") != "r1" {
+		t.Errorf("unexpected header %v", header)
+	}
+	header.Set("X-Log-User", "admin")
+	header.Set("X-Log-Order-Id", "o1")
+	header.Set("X-Log-Biz", strings.Repeat("a", maxPropagatedValue+1))
+	header.Set("X-Log-Note", "n1\nfake log line")
+	got := ExtractFields(context.Background(), propagation.HeaderCarrier(header))
+	if FieldValue(got, FieldRequestID) != "r1" || len(Fields(got)) != 1 {
+		t.Errorf("only request_id should be accepted by default, got %+v", Fields(got))
+	}
+
+	// 身份字段不从上游读取, 超长或包含控制字符的值忽略
+	setPropagateFields([]string{FieldRequestID, FieldUser, FieldTenant, "order_id", "biz", "note"})
+	t.Cleanup(func() { setPropagateFields(nil) })
+	got = ExtractFields(context.Background(), propagation.HeaderCarrier(header))
+	if FieldValue(got, "order_id") != "o1" || FieldValue(got, FieldUser) != "" || FieldValue(got, FieldTenant) != "" || FieldValue(got, "biz") != "" || FieldValue(got, "note") != "" {
+		t.Errorf("unexpected fields %+v", Fields(got))
+	}
+	if ValidRequestID("") || ValidRequestID("r1\r\nx") || ValidRequestID(strings.Repeat("a", maxPropagatedValue+1)) || !ValidRequestID("r1") {
+		t.Error("unexpected request id validation")
+	}
+}
+
+func TestNewPropagateFields(t *testing.T) {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() {
+		_ = Close()
+		baseCore.Store(nil)
+		slog.SetDefault(defaultLogger)
+		setPropagateFields(nil)
+	})
+	New("", Config{Filepath: t.TempDir(), PropagateFields: []string{FieldRequestID, "order_id"}})
+
+	header := http.Header{}
+	header.Set("X-Log-Request-Id", "r1")
+	header.Set("X-Log-Order-Id", "o1")
+	got := ExtractFields(context.Background(), propagation.HeaderCarrier(header))
+	if FieldValue(got, FieldRequestID) != "r1" || FieldValue(got, "order_id") != "o1" {
+		t.Errorf("unexpected fields %+v", Fields(got))
+	}
+}
@@ -0,0 +1,256 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/exp/zapslog"
+	"go.uber.org/zap/zapcore"
+)
+
+// 模块日志级别
+/*
+	logger.Named("db") 获取模块的 logger, 模块没有单独设置级别时跟随全局级别 (logger.level)
+	SetModuleLevel 可在运行时单独调整某个模块的级别, 并可在 ttl 后自动恢复
+
+		logger.SetModuleLevel("kafka", logger.LogLevel_Debug, 10*time.Minute)
+*/
+
+const RootModule = "root" // 全局级别
+
+var (
+	baseCore atomic.Pointer[zapcore.Core] // InitZapLogger 创建的 core, 未初始化时为 nil
+
+	modulesMu sync.Mutex
+	modules   = make(map[string]*moduleLevel)
+)
+
+// ModuleLevel 模块的日志级别
+type ModuleLevel struct {
+	Name      string     `json:"name"`
+	Level     LogLevel   `json:"level"`
+	Inherited bool       `json:"inherited"`           // 是否跟随全局级别
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // 自动恢复的时间
+}
+
+type moduleLevel struct {
+	name  string
+	set   atomic.Bool
+	level zap.AtomicLevel
+
+	mu        sync.Mutex
+	timer     *time.Timer
+	expiresAt time.Time
+	base      zapcore.Level // RootModule: 临时级别 (ttl) 到期或 Reset 时恢复的级别, timer 不为 nil 时有效
+}
+
+func (m *moduleLevel) Enabled(l zapcore.Level) bool {
+	if m.set.Load() {
+		return m.level.Enabled(l)
+	}
+	return atomicLevel.Enabled(l)
+}
+
+func (m *moduleLevel) info() ModuleLevel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	info := ModuleLevel{Name: m.name, Inherited: !m.set.Load()}
+	if info.Inherited {
+		info.Level = LogLevel(atomicLevel.Level().String())
+	} else {
+		info.Level = LogLevel(m.level.Level().String())
+	}
+	if !m.expiresAt.IsZero() {
+		expiresAt := m.expiresAt
+		info.ExpiresAt = &expiresAt
+	}
+	return info
+}
+
+func module(name string) *moduleLevel {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	m, ok := modules[name]
+	if !ok {
+		m = &moduleLevel{name: name, level: zap.NewAtomicLevel()}
+		modules[name] = m
+	}
+	return m
+}
+
+// Named 获取模块的 logger (e.g. "db"、"kafka"、"rpc")
+// 日志中会带上 logger=name, 级别可通过 SetModuleLevel 单独调整
+func Named(name string) *slog.Logger {
+	h := zapslog.NewHandler(&moduleCore{mod: module(name)}, zapslog.WithName(name), zapslog.WithCaller(true), zapslog.AddStacktraceAt(16))
+	return slog.New(&traceHandler{handler: h})
+}
+
+// namedUnthrottled 不经过限流的模块 logger (e.g. 访问日志每个请求一条, 不能去重、限速、采样)
+func namedUnthrottled(name string) *slog.Logger {
+	h := zapslog.NewHandler(&moduleCore{mod: module(name), unthrottled: true}, zapslog.WithName(name), zapslog.WithCaller(true), zapslog.AddStacktraceAt(16))
+	return slog.New(&traceHandler{handler: h})
+}
+
+// NamedZap 获取模块的 zap logger
+func NamedZap(name string) *zap.Logger {
+	return zap.New(&moduleCore{mod: module(name)}, zap.AddCaller()).Named(name)
+}
+
+// SetModuleLevel 设置模块的日志级别, ttl > 0 时到期自动恢复为跟随全局级别
+// name 为 RootModule 时修改全局级别, ttl 到期或 ResetModuleLevel 时恢复为第一次设置临时级别之前的级别
+// (连续设置多个临时级别时不会恢复为中间的临时级别, 不带 ttl 的设置成为新的基础级别)
+func SetModuleLevel(name string, level LogLevel, ttl time.Duration) error {
+	lvl, err := zapcore.ParseLevel(level.String())
+	if err != nil || level == "" {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	if name == "" {
+		return errors.New("module name is empty")
+	}
+	m := module(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var revert func()
+	if name == RootModule {
+		if m.timer == nil {
+			m.base = atomicLevel.Level()
+		}
+		atomicLevel.SetLevel(lvl)
+		revert = func() { atomicLevel.SetLevel(m.base) }
+	} else {
+		m.level.SetLevel(lvl)
+		m.set.Store(true)
+		revert = func() { m.set.Store(false) }
+	}
+
+	m.stopTimer()
+	if ttl > 0 {
+		m.expiresAt = time.Now().Add(ttl)
+		var timer *time.Timer
+		timer = time.AfterFunc(ttl, func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if m.timer != timer { // 已被重新设置
+				return
+			}
+			revert()
+			m.timer, m.expiresAt = nil, time.Time{}
+			slog.Info("[logger] module level reverted", "module", name)
+		})
+		m.timer = timer
+	}
+	return nil
+}
+
+func (m *moduleLevel) stopTimer() {
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	m.expiresAt = time.Time{}
+}
+
+// ResetModuleLevel 模块恢复为跟随全局级别
+// RootModule 只能取消临时级别 (恢复为设置之前的级别), 没有临时级别时返回错误
+func ResetModuleLevel(name string) error {
+	modulesMu.Lock()
+	m, ok := modules[name]
+	modulesMu.Unlock()
+	if !ok {
+		if name == RootModule {
+			return errors.New("root level has no temporary level to reset")
+		}
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if name == RootModule {
+		if m.timer == nil {
+			return errors.New("root level has no temporary level to reset")
+		}
+		atomicLevel.SetLevel(m.base)
+		m.stopTimer()
+		return nil
+	}
+	m.stopTimer()
+	m.set.Store(false)
+	return nil
+}
+
+// ModuleLevels 全局及所有模块的日志级别
+func ModuleLevels() []ModuleLevel {
+	module(RootModule)
+	modulesMu.Lock()
+	list := make([]*moduleLevel, 0, len(modules))
+	for _, m := range modules {
+		list = append(list, m)
+	}
+	modulesMu.Unlock()
+
+	levels := make([]ModuleLevel, 0, len(list))
+	for _, m := range list {
+		info := m.info()
+		if m.name == RootModule {
+			info.Inherited = false
+		}
+		levels = append(levels, info)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].Name == RootModule || levels[j].Name == RootModule {
+			return levels[i].Name == RootModule
+		}
+		return levels[i].Name < levels[j].Name
+	})
+	return levels
+}
+
+// moduleCore 按模块级别过滤, 写入当前的 baseCore
+// InitZapLogger 重新初始化后, 已获取的模块 logger 自动使用新的 core
+type moduleCore struct {
+	mod         *moduleLevel
+	core        zapcore.Core // With 之后绑定的 core, nil 表示使用 baseCore
+	unthrottled bool         // 使用限流之前的 core, 见 throttledCore
+}
+
+func (c *moduleCore) current() zapcore.Core {
+	if c.core != nil {
+		return c.core
+	}
+	if core := baseCore.Load(); core != nil {
+		if t, ok := (*core).(*throttledCore); ok && c.unthrottled {
+			return t.raw
+		}
+		return *core
+	}
+	return zapcore.NewNopCore()
+}
+
+func (c *moduleCore) Enabled(l zapcore.Level) bool {
+	return c.mod.Enabled(l) && c.current().Enabled(l)
+}
+
+func (c *moduleCore) With(fields []zapcore.Field) zapcore.Core {
+	return &moduleCore{mod: c.mod, core: c.current().With(fields), unthrottled: c.unthrottled}
+}
+
+func (c *moduleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.mod.Enabled(ent.Level) {
+		return ce
+	}
+	return c.current().Check(ent, ce)
+}
+
+func (c *moduleCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().Write(ent, fields)
+}
+
+func (c *moduleCore) Sync() error {
+	return c.current().Sync()
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestModuleLevel(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	var core zapcore.Core = obs
+	baseCore.Store(&core)
+	t.Cleanup(func() { baseCore.Store(nil) })
+	SetLevel(LogLevel_Info)
+
+	db, kafka := Named("test-db"), Named("test-kafka")
+	db.Debug("db debug")
+	if logs.Len() != 0 {
+		t.Fatal("debug should follow global info level")
+	}
+
+	if err := SetModuleLevel("test-db", LogLevel_Debug, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	db.Debug("db debug")
+	kafka.Debug("kafka debug")
+	entries := logs.TakeAll()
+	if len(entries) != 1 || entries[0].Message != "db debug" || entries[0].LoggerName != "test-db" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	var info ModuleLevel
+	for _, l := range ModuleLevels() {
+		if l.Name == "test-db" {
+			info = l
+		}
+	}
+	if info.Level != LogLevel_Debug || info.Inherited || info.ExpiresAt == nil {
+		t.Errorf("unexpected module level %+v", info)
+	}
+
+	time.Sleep(100 * time.Millisecond) // ttl 到期自动恢复
+	db.Debug("db debug")
+	if logs.Len() != 0 {
+		t.Error("module level should revert after ttl")
+	}
+
+	if err := SetModuleLevel("test-kafka", LogLevel_Error, 0); err != nil {
+		t.Fatal(err)
+	}
+	kafka.InfoContext(context.Background(), "kafka info")
+	ResetModuleLevel("test-kafka")
+	kafka.Info("kafka info")
+	if entries := logs.TakeAll(); len(entries) != 1 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	if err := SetModuleLevel("test-db", "trace", 0); err == nil {
+		t.Error("expected invalid level error")
+	}
+
+	// 全局级别连续设置临时级别, 到期恢复为最初的级别
+	if err := ResetModuleLevel(RootModule); err == nil {
+		t.Error("expected error resetting root without a temporary level")
+	}
+	for _, l := range []LogLevel{LogLevel_Debug, LogLevel_Error} {
+		if err := SetModuleLevel(RootModule, l, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ResetModuleLevel(RootModule); err != nil || atomicLevel.Level() != zapcore.InfoLevel {
+		t.Errorf("root level = %s, err = %v", atomicLevel.Level(), err)
+	}
+	_ = SetModuleLevel(RootModule, LogLevel_Debug, 50*time.Millisecond)
+	_ = SetModuleLevel(RootModule, LogLevel_Error, 50*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if atomicLevel.Level() != zapcore.InfoLevel {
+		t.Errorf("root level should revert to info, got %s", atomicLevel.Level())
+	}
+}
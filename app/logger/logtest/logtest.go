@@ -0,0 +1,157 @@
+// Package logtest 测试中捕获日志并断言
+/*
+	func TestXxx(t *testing.T) {
+		logs := logtest.New(t) // 替换全局 logger (zap.L、slog.Default、模块 logger), 测试结束时恢复
+		...
+		logtest.RequireLogged(t, slog.LevelError, "[validator] registerTrans", "locale", "en")
+		records := logs.Filter(slog.LevelInfo, "")
+	}
+
+	替换的是全局 logger, 不能与 t.Parallel 一起使用
+*/
+package logtest
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bobacgo/kit/app/logger"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Record 捕获的一条日志
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Logger  string // 模块名称, 全局 logger 为空
+	Message string
+	Attrs   map[string]any // 嵌套的 group 为 map[string]any
+}
+
+// Recorder 捕获全局 logger 的日志 (包含 debug 级别)
+type Recorder struct {
+	logs *observer.ObservedLogs
+}
+
+var recorders sync.Map // testing.TB -> *Recorder
+
+// New 替换全局 logger, 通过 t.Cleanup 恢复
+func New(t testing.TB) *Recorder {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	restore := logger.ReplaceCore(core)
+	logger.SetLevel(logger.LogLevel_Debug)
+
+	r := &Recorder{logs: logs}
+	recorders.Store(t, r)
+	t.Cleanup(func() {
+		recorders.Delete(t)
+		restore()
+	})
+	return r
+}
+
+// Records 捕获的所有日志
+func (r *Recorder) Records() []Record {
+	entries := r.logs.All()
+	records := make([]Record, 0, len(entries))
+	for _, e := range entries {
+		records = append(records, Record{
+			Time:    e.Time,
+			Level:   slogLevel(e.Level),
+			Logger:  e.LoggerName,
+			Message: e.Message,
+			Attrs:   e.ContextMap(),
+		})
+	}
+	return records
+}
+
+// Reset 清空捕获的日志
+func (r *Recorder) Reset() {
+	r.logs.TakeAll()
+}
+
+// Filter 按级别、消息 (包含即可, 为空不过滤) 和属性过滤
+// attrs 与 slog 相同: key-value 交替或 slog.Attr, 值按 fmt.Sprint 比较 (e.g. error 与其字符串相等)
+func (r *Recorder) Filter(level slog.Level, msg string, attrs ...any) []Record {
+	want := argsToAttrs(attrs)
+	var out []Record
+	for _, rec := range r.Records() {
+		if rec.Level == level && strings.Contains(rec.Message, msg) && matchAttrs(rec.Attrs, want) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// RequireLogged 断言至少有一条匹配的日志, 见 Filter
+func (r *Recorder) RequireLogged(t testing.TB, level slog.Level, msg string, attrs ...any) {
+	t.Helper()
+	if len(r.Filter(level, msg, attrs...)) > 0 {
+		return
+	}
+	var b strings.Builder
+	for _, rec := range r.Records() {
+		fmt.Fprintf(&b, "\n\t%s %q %v", rec.Level, rec.Message, rec.Attrs)
+	}
+	t.Fatalf("no %s log matching %q %v, captured:%s", level, msg, argsToAttrs(attrs), b.String())
+}
+
+// RequireLogged 使用 New(t) 创建的 Recorder 断言, 见 Recorder.RequireLogged
+func RequireLogged(t testing.TB, level slog.Level, msg string, attrs ...any) {
+	t.Helper()
+	r, ok := recorders.Load(t)
+	if !ok {
+		t.Fatal("logtest.New(t) must be called before RequireLogged")
+	}
+	r.(*Recorder).RequireLogged(t, level, msg, attrs...)
+}
+
+func slogLevel(l zapcore.Level) slog.Level {
+	switch {
+	case l <= zapcore.DebugLevel:
+		return slog.LevelDebug
+	case l == zapcore.InfoLevel:
+		return slog.LevelInfo
+	case l == zapcore.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// argsToAttrs 与 slog.Logger.Log 的参数相同
+func argsToAttrs(args []any) []slog.Attr {
+	var attrs []slog.Attr
+	for len(args) > 0 {
+		switch v := args[0].(type) {
+		case slog.Attr:
+			attrs, args = append(attrs, v), args[1:]
+		case string:
+			if len(args) == 1 {
+				attrs, args = append(attrs, slog.String("!BADKEY", v)), nil
+				continue
+			}
+			attrs, args = append(attrs, slog.Any(v, args[1])), args[2:]
+		default:
+			attrs, args = append(attrs, slog.Any("!BADKEY", v)), args[1:]
+		}
+	}
+	return attrs
+}
+
+func matchAttrs(got map[string]any, want []slog.Attr) bool {
+	for _, a := range want {
+		v, ok := got[a.Key]
+		if !ok || fmt.Sprint(v) != fmt.Sprint(a.Value.Resolve().Any()) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package logtest
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"testing"
+
+	"github.com/bobacgo/kit/app/logger"
+	"go.uber.org/zap"
+)
+
+// fatalTB 记录 Fatal 的消息, 只结束 fatalMsg 中的协程, 不结束测试
+type fatalTB struct {
+	testing.TB
+	msg string
+}
+
+func (f *fatalTB) Helper() {}
+
+func (f *fatalTB) Fatal(args ...any) {
+	f.msg = fmt.Sprint(args...)
+	runtime.Goexit()
+}
+
+func (f *fatalTB) Fatalf(format string, args ...any) {
+	f.msg = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// fatalMsg 执行 fn 并返回 Fatal 的消息, 没有 Fatal 时为空
+func fatalMsg(t *testing.T, fn func(tb testing.TB)) string {
+	f := &fatalTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(f)
+	}()
+	<-done
+	return f.msg
+}
+
+func TestRequireLogged(t *testing.T) {
+	logs := New(t)
+	slog.Error("[validator] registerTrans", "locale", "en", "err", errors.New("boom"))
+	logger.Named("db").Debug("slow query", "rows", 3)
+
+	RequireLogged(t, slog.LevelError, "registerTrans", "locale", "en")
+	logs.RequireLogged(t, slog.LevelError, "", slog.String("err", "boom")) // error 按字符串比较
+	logs.RequireLogged(t, slog.LevelDebug, "slow query", "rows", 3)
+	if recs := logs.Filter(slog.LevelDebug, ""); len(recs) != 1 || recs[0].Logger != "db" {
+		t.Errorf("got %+v, want one db record", recs)
+	}
+
+	for _, c := range []struct {
+		level slog.Level
+		msg   string
+		attrs []any
+	}{
+		{slog.LevelInfo, "registerTrans", nil},                    // 级别不同
+		{slog.LevelError, "registerTransX", nil},                  // 消息不包含
+		{slog.LevelError, "registerTrans", []any{"locale", "zh"}}, // 属性值不同
+		{slog.LevelError, "registerTrans", []any{"missing", 1}},   // 属性不存在
+	} {
+		msg := fatalMsg(t, func(tb testing.TB) { logs.RequireLogged(tb, c.level, c.msg, c.attrs...) })
+		if msg == "" {
+			t.Errorf("%s %q %v: expected failure", c.level, c.msg, c.attrs)
+		}
+	}
+
+	logs.Reset()
+	if recs := logs.Records(); len(recs) != 0 {
+		t.Errorf("got %d records after Reset", len(recs))
+	}
+}
+
+func TestRequireLoggedWithoutNew(t *testing.T) {
+	if msg := fatalMsg(t, func(tb testing.TB) { RequireLogged(tb, slog.LevelError, "") }); msg == "" {
+		t.Error("expected failure without New")
+	}
+}
+
+func TestCleanupRestoresGlobals(t *testing.T) {
+	prevZap, prevSlog, prevLevel := zap.L(), slog.Default(), rootLevel()
+	var logs *Recorder
+	t.Run("capture", func(t *testing.T) {
+		logs = New(t)
+		if slog.Default() == prevSlog || zap.L() == prevZap {
+			t.Fatal("global logger not replaced")
+		}
+	})
+	if zap.L() != prevZap || slog.Default() != prevSlog {
+		t.Error("global logger not restored")
+	}
+	if lvl := rootLevel(); lvl != prevLevel {
+		t.Errorf("root level %s, want %s", lvl, prevLevel)
+	}
+	slog.Error("after cleanup")
+	if recs := logs.Records(); len(recs) != 0 {
+		t.Errorf("captured %+v after cleanup", recs)
+	}
+}
+
+func rootLevel() logger.LogLevel {
+	return logger.ModuleLevels()[0].Level
+}
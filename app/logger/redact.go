@@ -0,0 +1,266 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bobacgo/kit/pkg/tag"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// 日志脱敏
+/*
+	默认开启, 对所有输出 (slog、zap) 生效, 每个 sink 可单独配置 (e.g. 审计 sink 关闭脱敏保留原始值)
+
+	1.结构体类型的字段 (slog.Any、zap.Any) 按 mask 标签脱敏 (见 tag.Desensitize)
+	2.检测器在编码后的日志中匹配敏感信息并替换
+
+		phone     手机号     13800138000 -> 138****8000
+		email     邮箱       alice@example.com -> a****@example.com
+		idcard    身份证号   (校验位正确) 110101199003071233 -> 110101********1233
+		bankcard  银行卡号   (Luhn 校验) 6222020200112233446 -> 622202*********3446
+		jwt       JWT        eyJhbGciOi.eyJzdWIiOi.sig -> eyJhbGciOi.***
+
+		idcard、bankcard 需要在 detectors 中显式启用, 18、19 位的数字 ID (e.g. uid.Snowflake) 和纳秒时间戳
+		有一定比例能通过校验, 默认开启会破坏这些用于排查问题的标识
+
+	3.patterns 自定义正则, 匹配的内容全部替换为 *
+*/
+
+const (
+	DetectorPhone    = "phone"
+	DetectorEmail    = "email"
+	DetectorIDCard   = "idcard"
+	DetectorBankCard = "bankcard"
+	DetectorJWT      = "jwt"
+)
+
+// RedactConfig 日志脱敏配置
+type RedactConfig struct {
+	Disable   bool     `mapstructure:"disable"`                                                         // 关闭脱敏
+	Detectors []string `mapstructure:"detectors" validate:"dive,oneof=phone email idcard bankcard jwt"` // 启用的检测器, 为空时启用 phone、email、jwt
+	Patterns  []string `mapstructure:"patterns"`                                                        // 自定义正则
+}
+
+type detector struct {
+	re    *regexp.Regexp
+	valid func(s string) bool // 为空时不校验
+	mask  func(s string) string
+}
+
+// detectors 内置检测器, 按顺序匹配 (身份证号先于银行卡号)
+var detectors = []struct {
+	name  string
+	optIn bool // 只在 Detectors 中显式配置时启用
+	detector
+}{
+	{DetectorJWT, false, detector{re: regexp.MustCompile(`\beyJ[\w-]+\.eyJ[\w-]+\.[\w-]+`), mask: maskJWT}},
+	{DetectorEmail, false, detector{re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), mask: maskEmail}},
+	{DetectorIDCard, true, detector{re: regexp.MustCompile(`\b\d{17}[\dXx]\b`), valid: validIDCard, mask: keep(6, 4)}},
+	{DetectorBankCard, true, detector{re: regexp.MustCompile(`\b\d{16,19}\b`), valid: validLuhn, mask: keep(6, 4)}},
+	{DetectorPhone, false, detector{re: regexp.MustCompile(`\b1[3-9]\d{9}\b`), mask: keep(3, 4)}},
+}
+
+// redactor 按配置脱敏, 为 nil 时不脱敏
+type redactor struct {
+	detectors []detector
+}
+
+// newRedactor sink 的配置优先, 为空时使用全局配置
+func newRedactor(conf *RedactConfig, global *RedactConfig) *redactor {
+	if conf == nil {
+		conf = global
+	}
+	if conf == nil {
+		conf = &RedactConfig{}
+	}
+	if conf.Disable {
+		return nil
+	}
+	r := new(redactor)
+	for _, d := range detectors {
+		if len(conf.Detectors) == 0 && !d.optIn || slices.Contains(conf.Detectors, d.name) {
+			r.detectors = append(r.detectors, d.detector)
+		}
+	}
+	for _, p := range conf.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[logger] invalid redact pattern %q: %v\n", p, err)
+			continue
+		}
+		r.detectors = append(r.detectors, detector{re: re, mask: func(s string) string { return strings.Repeat("*", len(s)) }})
+	}
+	return r
+}
+
+func (r *redactor) redact(data []byte) []byte {
+	for _, d := range r.detectors {
+		data = d.re.ReplaceAllFunc(data, func(m []byte) []byte {
+			if d.valid != nil && !d.valid(string(m)) {
+				return m
+			}
+			return []byte(d.mask(string(m)))
+		})
+	}
+	return data
+}
+
+func (r *redactor) redactString(s string) string {
+	return string(r.redact([]byte(s)))
+}
+
+// maskField 结构体字段按 mask 标签脱敏, 字符串按检测器脱敏
+func (r *redactor) maskField(f zapcore.Field) zapcore.Field {
+	switch f.Type {
+	case zapcore.ReflectType:
+		f.Interface = desensitize(f.Interface)
+	case zapcore.StringType:
+		f.String = r.redactString(f.String)
+	}
+	return f
+}
+
+func (r *redactor) maskFields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		out[i] = r.maskField(f)
+	}
+	return out
+}
+
+var maskedTypes sync.Map // reflect.Type -> 是否包含 mask 标签
+
+// desensitize 只处理包含 mask 标签的结构体, 避免每条日志都复制
+func desensitize(v any) any {
+	typ := reflect.TypeOf(v)
+	if typ == nil {
+		return v
+	}
+	masked, ok := maskedTypes.Load(typ)
+	if !ok {
+		masked = len(tag.MaskRules(v)) > 0
+		maskedTypes.Store(typ, masked)
+	}
+	if !masked.(bool) {
+		return v
+	}
+	return tag.Desensitize(v)
+}
+
+// redactEncoder 编码前按 mask 标签脱敏, 编码后按检测器脱敏 (包含 With 添加的字段和嵌套的值)
+type redactEncoder struct {
+	zapcore.Encoder
+	r *redactor
+}
+
+func withRedact(enc zapcore.Encoder, r *redactor) zapcore.Encoder {
+	if r == nil {
+		return enc
+	}
+	return &redactEncoder{Encoder: enc, r: r}
+}
+
+func (e *redactEncoder) Clone() zapcore.Encoder {
+	return &redactEncoder{Encoder: e.Encoder.Clone(), r: e.r}
+}
+
+func (e *redactEncoder) AddReflected(key string, obj any) error {
+	return e.Encoder.AddReflected(key, desensitize(obj))
+}
+
+func (e *redactEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, e.r.maskFields(fields))
+	if err != nil {
+		return buf, err
+	}
+	if out := e.r.redact(buf.Bytes()); !bytes.Equal(out, buf.Bytes()) {
+		buf.Reset()
+		_, _ = buf.Write(out)
+	}
+	return buf, nil
+}
+
+// redactCore 用于不经过 Encoder 的 core (e.g. otlp), 只处理消息和顶层字段
+type redactCore struct {
+	zapcore.Core
+	r *redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.maskFields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.r.redactString(ent.Message)
+	return c.Core.Write(ent, c.r.maskFields(fields))
+}
+
+// keep 保留前 head 位和后 tail 位
+func keep(head, tail int) func(string) string {
+	return func(s string) string {
+		if len(s) <= head+tail {
+			return strings.Repeat("*", len(s))
+		}
+		return s[:head] + strings.Repeat("*", len(s)-head-tail) + s[len(s)-tail:]
+	}
+}
+
+func maskEmail(s string) string {
+	name, domain, _ := strings.Cut(s, "@")
+	if len(name) <= 1 {
+		return "*@" + domain
+	}
+	return name[:1] + strings.Repeat("*", len(name)-1) + "@" + domain
+}
+
+func maskJWT(s string) string {
+	header, _, _ := strings.Cut(s, ".")
+	return header + ".***"
+}
+
+// validIDCard 18 位身份证号校验位 (GB 11643)
+func validIDCard(s string) bool {
+	weights := [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(s[i]-'0') * w
+	}
+	check := "10X98765432"[sum%11]
+	last := s[17]
+	if last == 'x' {
+		last = 'X'
+	}
+	return last == check
+}
+
+// validLuhn 银行卡号 Luhn 校验
+func validLuhn(s string) bool {
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		d := int(s[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRedact(t *testing.T) {
+	type User struct {
+		Name     string    `json:"name"`
+		Password string    `json:"password" mask:"^.*$"`
+		Created  time.Time `json:"created"`
+	}
+	created := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	all := &RedactConfig{Detectors: []string{DetectorPhone, DetectorEmail, DetectorIDCard, DetectorBankCard, DetectorJWT}}
+	enc := withRedact(setJSONEncoder(timeFormatDefault, true), newRedactor(nil, all))
+	l := zap.New(zapcore.NewCore(enc, zapcore.AddSync(&buf), zapcore.DebugLevel)).With(zap.String("token", "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.abc"))
+	l.Info("user 13800138000 login",
+		zap.String("email", "alice@example.com"),
+		zap.String("idcard", "110101199003071233"),
+		zap.String("card", "6222020200112233446"),
+		zap.String("order", "6222020200112233445"), // Luhn 校验不通过, 不是银行卡号
+		zap.Any("user", User{Name: "alice", Password: "123456", Created: created}),
+	)
+	out := buf.String()
+	for _, want := range []string{"138****8000", "a****@example.com", "110101********1233", "622202*********3446", "6222020200112233445", "eyJhbGciOiJIUzI1NiJ9.***", `"password":"******"`, "2024-05-01T00:00:00Z"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in %s", want, out)
+		}
+	}
+	for _, raw := range []string{"13800138000", "alice@example.com", "123456\"", "eyJzdWIiOiIxIn0"} {
+		if strings.Contains(out, raw) {
+			t.Errorf("%s not redacted in %s", raw, out)
+		}
+	}
+
+	// 默认不启用数字检测器, 不影响数字 ID
+	ids := "110101199003071233 6222020200112233446 bob@example.com"
+	if got := newRedactor(nil, nil).redactString(ids); got != "110101199003071233 6222020200112233446 b**@example.com" {
+		t.Errorf("got %s", got)
+	}
+
+	// sink 单独关闭脱敏
+	if r := newRedactor(&RedactConfig{Disable: true}, &RedactConfig{}); r != nil {
+		t.Error("redactor should be disabled")
+	}
+	r := newRedactor(nil, &RedactConfig{Detectors: []string{DetectorEmail}, Patterns: []string{`secret-\w+`}})
+	if got := r.redactString("13800138000 bob@example.com secret-abc"); got != "13800138000 b**@example.com **********" {
+		t.Errorf("got %s", got)
+	}
+}
@@ -0,0 +1,451 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 日志文件切割
+/*
+	1.按时间 (daily、hourly) 和大小 (fileSizeMax) 切割, 满足任一条件即切割
+	2.当前文件名固定为 {filename}.{ext}, 切割后的备份文件为 {filename}-{filenameSuffix}.{ext}
+	3.fileSymlink 开启时日志直接写入带时间的文件, {filename}.{ext} 为指向它的软链接
+	4.备份文件在后台压缩 (.gz) 并按数量、天数、总大小清理 (总大小包含当前文件)
+	5.切割、压缩、清理失败通过 slog 输出, 不影响日志写入
+*/
+
+// RotateInterval 按时间切割的周期
+type RotateInterval string
+
+const (
+	RotateNone   RotateInterval = "none" // 只按大小切割
+	RotateDaily  RotateInterval = "daily"
+	RotateHourly RotateInterval = "hourly"
+)
+
+// period 返回 t 所在周期的开始时间, 不按时间切割时为零值
+func (ri RotateInterval) period(t time.Time) time.Time {
+	switch ri {
+	case RotateDaily:
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	case RotateHourly:
+		return t.Truncate(time.Hour)
+	}
+	return time.Time{}
+}
+
+const compressSuffix = ".gz"
+
+type rotator struct {
+	dir, name, ext string // 当前文件 {dir}/{name}{ext}
+	timeFormat     string // 备份文件名中的时间格式
+	interval       RotateInterval
+	maxSize        int64         // 单个文件大小, 0 不限制
+	maxAge         time.Duration // 备份留存时长, 0 不限制
+	maxBackups     int           // 备份数量, 0 不限制
+	maxTotal       int64         // 总大小, 0 不限制
+	compress       bool
+	symlink        bool
+
+	mu     sync.Mutex
+	file   *os.File
+	path   string    // 正在写入的文件
+	size   int64     // 正在写入的文件大小
+	period time.Time // 正在写入的文件所在周期
+
+	now     func() time.Time
+	millCh  chan struct{}
+	errCh   chan error
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+func newRotator(conf Config) *rotator {
+	ext := ""
+	if conf.FileExtension != "" {
+		ext = "." + conf.FileExtension
+	}
+	r := &rotator{
+		dir:        conf.Filepath,
+		name:       conf.Filename,
+		ext:        ext,
+		timeFormat: conf.FilenameSuffix,
+		interval:   conf.FileRotate,
+		maxSize:    int64(conf.FileMaxSize) << 20,
+		maxAge:     time.Duration(conf.FileMaxAge) * 24 * time.Hour,
+		maxBackups: int(conf.FileMaxBackups),
+		maxTotal:   int64(conf.FileMaxTotalSize) << 20,
+		compress:   conf.FileCompress,
+		symlink:    conf.FileSymlink,
+		now:        time.Now,
+		millCh:     make(chan struct{}, 1),
+		errCh:      make(chan error, 16),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	if r.timeFormat == "" {
+		r.timeFormat = filenameSuffixDefault
+	}
+	go r.run()
+	r.mill() // 清理启动前遗留的备份
+	return r
+}
+
+// current 固定的当前文件名 (fileSymlink 开启时为软链接)
+func (r *rotator) current() string {
+	return filepath.Join(r.dir, r.name+r.ext)
+}
+
+func (r *rotator) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if r.file == nil {
+		if err := r.openExisting(now); err != nil {
+			r.report(err)
+			if err := r.openNew(now); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if r.shouldRotate(now, int64(len(p))) {
+		if err := r.rotate(now); err != nil { // 切割失败继续写入当前文件
+			r.report(err)
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotator) shouldRotate(now time.Time, n int64) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.maxSize > 0 && r.size+n > r.maxSize {
+		return true
+	}
+	return r.interval.period(now).After(r.period)
+}
+
+// openExisting 打开上次写入的文件继续写入, 已过期或超过大小时先切割
+func (r *rotator) openExisting(now time.Time) error {
+	path := r.current()
+	if r.symlink {
+		target, err := os.Readlink(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return r.openNew(now)
+			}
+			return err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(r.dir, target)
+		}
+		path = target
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return r.openNew(now)
+	}
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("log file %s is not a regular file", path)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	r.file, r.path, r.size = f, path, info.Size()
+	r.period = r.interval.period(info.ModTime())
+	if r.shouldRotate(now, 0) {
+		return r.rotate(now)
+	}
+	return nil
+}
+
+// openNew 创建新的文件
+func (r *rotator) openNew(now time.Time) error {
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return err
+	}
+	path := r.current()
+	if r.symlink {
+		path = r.backupName(now)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+	r.file, r.path, r.size, r.period = f, path, size, r.interval.period(now)
+	if r.symlink {
+		return r.link(path)
+	}
+	return nil
+}
+
+// link 原子地更新软链接
+func (r *rotator) link(target string) error {
+	tmp := r.current() + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(filepath.Base(target), tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.current())
+}
+
+func (r *rotator) rotate(now time.Time) error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return err
+		}
+		r.file = nil
+	}
+	if !r.symlink { // 当前文件重命名为备份文件, 时间取文件所在周期
+		at := now
+		if !r.period.IsZero() {
+			at = r.period
+		}
+		if err := os.Rename(r.path, r.backupName(at)); err != nil && !os.IsNotExist(err) {
+			return errors.Join(err, r.openNew(now)) // 重新打开当前文件继续追加
+		}
+	}
+	if err := r.openNew(now); err != nil {
+		return err
+	}
+	r.mill()
+	return nil
+}
+
+// backupName {dir}/{name}-{time}{ext}, 已存在时追加序号
+func (r *rotator) backupName(t time.Time) string {
+	base := filepath.Join(r.dir, r.name+"-"+t.Format(r.timeFormat))
+	name := base + r.ext
+	for i := 1; exists(name) || exists(name+compressSuffix); i++ {
+		name = fmt.Sprintf("%s.%d%s", base, i, r.ext)
+	}
+	return name
+}
+
+// isBackup 文件名是否为 backupName 生成的 {name}-{time}[.N]{ext}[.gz]
+// 只有前缀相同的其他文件 (e.g. 同目录下其他服务的 order-api.log) 不是备份文件
+func (r *rotator) isBackup(name string) bool {
+	rest, ok := strings.CutPrefix(name, r.name+"-")
+	if !ok {
+		return false
+	}
+	rest = strings.TrimSuffix(rest, compressSuffix)
+	if rest, ok = strings.CutSuffix(rest, r.ext); !ok {
+		return false
+	}
+	if _, err := time.Parse(r.timeFormat, rest); err == nil {
+		return true
+	}
+	i := strings.LastIndexByte(rest, '.')
+	if i < 0 {
+		return false
+	}
+	if n, err := strconv.Atoi(rest[i+1:]); err != nil || n < 1 {
+		return false
+	}
+	_, err := time.Parse(r.timeFormat, rest[:i])
+	return err == nil
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+func (r *rotator) mill() {
+	select {
+	case r.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// report 不能在持有锁时输出日志 (会写回 rotator), 交给后台协程
+func (r *rotator) report(err error) {
+	select {
+	case r.errCh <- err:
+	default:
+	}
+}
+
+func (r *rotator) run() {
+	defer close(r.stopped)
+	for {
+		select {
+		case <-r.done:
+			return
+		case err := <-r.errCh:
+			slog.Error("[logger] rotate log file error", "file", r.current(), "error", err)
+		case <-r.millCh:
+			if err := r.millRun(); err != nil {
+				slog.Error("[logger] clean log backups error", "dir", r.dir, "error", err)
+			}
+		}
+	}
+}
+
+type backupFile struct {
+	path string
+	info os.FileInfo
+}
+
+// backups 备份文件, 按修改时间从新到旧排序
+func (r *rotator) backups() ([]backupFile, error) {
+	entries, err := os.ReadDir(r.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	active := r.path
+	r.mu.Unlock()
+	if active == "" && r.symlink { // 还未写入时, 软链接指向的文件也在使用中
+		if target, err := os.Readlink(r.current()); err == nil {
+			active = filepath.Join(r.dir, filepath.Base(target))
+		}
+	}
+
+	var files []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !r.isBackup(name) {
+			continue
+		}
+		path := filepath.Join(r.dir, name)
+		if path == active {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, backupFile{path: path, info: info})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].info.ModTime().After(files[j].info.ModTime())
+	})
+	return files, nil
+}
+
+// millRun 压缩并清理备份文件
+func (r *rotator) millRun() error {
+	files, err := r.backups()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	if r.compress {
+		for i, f := range files {
+			if strings.HasSuffix(f.path, compressSuffix) {
+				continue
+			}
+			if err := compressFile(f.path, f.info); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if info, err := os.Stat(f.path + compressSuffix); err == nil {
+				files[i] = backupFile{path: f.path + compressSuffix, info: info}
+			}
+		}
+	}
+
+	r.mu.Lock()
+	total := r.size
+	r.mu.Unlock()
+	now := r.now()
+	for i, f := range files {
+		total += f.info.Size()
+		if (r.maxBackups > 0 && i >= r.maxBackups) ||
+			(r.maxAge > 0 && now.Sub(f.info.ModTime()) > r.maxAge) ||
+			(r.maxTotal > 0 && total > r.maxTotal) {
+			if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compressFile 压缩为 .gz 并删除原文件, 保留修改时间用于排序
+func compressFile(path string, info os.FileInfo) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + compressSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path+compressSuffix); err != nil {
+		return err
+	}
+	_ = os.Chtimes(path+compressSuffix, info.ModTime(), info.ModTime())
+	return os.Remove(path)
+}
+
+func (r *rotator) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+func (r *rotator) Close() error {
+	r.once.Do(func() { close(r.done) })
+	<-r.stopped
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
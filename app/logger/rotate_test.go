@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRotator 不启动后台协程, 由测试同步调用 millRun
+func testRotator(dir string, clock *time.Time) *rotator {
+	stopped := make(chan struct{})
+	close(stopped)
+	return &rotator{
+		dir: dir, name: "app", ext: ".log", timeFormat: "2006-01-02-15",
+		interval: RotateDaily, maxSize: 64, maxBackups: 2, compress: true,
+		now:    func() time.Time { return *clock },
+		millCh: make(chan struct{}, 1), errCh: make(chan error, 16),
+		done: make(chan struct{}), stopped: stopped,
+	}
+}
+
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotator(t *testing.T) {
+	dir := t.TempDir()
+	// 备份按修改时间排序, 时钟与真实时间一致
+	today := time.Now()
+	clock := today.Add(-24 * time.Hour)
+	yesterday := "app-" + RotateDaily.period(clock).Format("2006-01-02-15")
+	r := testRotator(dir, &clock)
+
+	line := []byte(strings.Repeat("a", 39) + "\n")
+	_, _ = r.Write(line)
+	_, _ = r.Write(line) // 超过 64 byte 按大小切割
+	if got := listDir(t, dir); len(got) != 2 || got[0] != yesterday+".log" || got[1] != "app.log" {
+		t.Fatalf("size rotation: %v", got)
+	}
+
+	clock = today
+	_, _ = r.Write(line) // 跨天按时间切割
+	if err := r.millRun(); err != nil {
+		t.Fatal(err)
+	}
+	got := listDir(t, dir)
+	if len(got) != 3 || got[0] != yesterday+".1.log.gz" || got[1] != yesterday+".log.gz" {
+		t.Fatalf("daily rotation: %v", got)
+	}
+
+	// 重启后继续写入当前文件, 超过备份数量时删除最旧的
+	_ = r.Close()
+	r = testRotator(dir, &clock)
+	_, _ = r.Write(line) // 当前文件已有内容, 超过大小切割
+	if err := r.millRun(); err != nil {
+		t.Fatal(err)
+	}
+	if got := listDir(t, dir); len(got) != 3 || got[0] != yesterday+".1.log.gz" || got[1] != "app-"+RotateDaily.period(today).Format("2006-01-02-15")+".log.gz" {
+		t.Fatalf("retention: %v", got)
+	}
+	_ = r.Close()
+}
+
+func TestRotatorSymlink(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	r := testRotator(dir, &clock)
+	r.symlink, r.interval, r.compress, r.maxTotal = true, RotateHourly, false, 100
+
+	line := []byte(strings.Repeat("a", 39) + "\n")
+	_, _ = r.Write(line)
+	clock = clock.Add(time.Hour)
+	_, _ = r.Write(line)
+	target, err := os.Readlink(filepath.Join(dir, "app.log"))
+	if err != nil || target != "app-2024-05-01-11.log" {
+		t.Fatalf("symlink target %s, %v", target, err)
+	}
+
+	clock = clock.Add(time.Hour)
+	_, _ = r.Write(line)
+	_, _ = r.Write(line)
+	if err := r.millRun(); err != nil { // 总大小超过 100 byte 删除最旧的
+		t.Fatal(err)
+	}
+	if got := listDir(t, dir); len(got) != 3 || got[0] != "app-2024-05-01-12.1.log" || got[1] != "app-2024-05-01-12.log" {
+		t.Fatalf("total size retention: %v", got)
+	}
+	_ = r.Close()
+}
+
+func TestRotatorBackups(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	r := testRotator(dir, &clock)
+	for _, name := range []string{"app-2024-05-01-09.log", "app-2024-05-01-09.2.log.gz", "app-api.log", "app-api-2024-05-01-09.log", "app-2024-05-01-09.x.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := r.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, filepath.Base(f.path))
+	}
+	sort.Strings(got)
+	// 前缀相同的其他服务的日志不是备份文件
+	if len(got) != 2 || got[0] != "app-2024-05-01-09.2.log.gz" || got[1] != "app-2024-05-01-09.log" {
+		t.Fatalf("unexpected backups %v", got)
+	}
+}
@@ -0,0 +1,276 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bobacgo/kit/app/types"
+	"go.uber.org/zap/zapcore"
+)
+
+// 日志输出 (sink)
+/*
+	logger.sinks 为空时输出到文件和控制台 (兼容旧配置), 配置后只输出到配置的 sink
+
+		stdout  标准输出 (容器环境推荐)
+		file    文件, 使用 logger 的 file* 配置
+		syslog  syslog (RFC 5424) over UDP/TCP
+		http    批量推送, 支持 Loki、Elasticsearch bulk 格式
+		kafka   Kafka topic (由 kafka 包通过 RegisterSink 注册, 需要引入 github.com/bobacgo/kit/app/mq/kafka, 使用 app/conf 时已引入)
+		otlp    OTLP logs exporter (gRPC)
+
+	每个 sink 有独立的级别、编码、脱敏和缓冲队列, 队列满时按 dropPolicy 处理
+*/
+
+type SinkType string
+
+const (
+	SinkStdout SinkType = "stdout"
+	SinkFile   SinkType = "file"
+	SinkSyslog SinkType = "syslog"
+	SinkHTTP   SinkType = "http"
+	SinkKafka  SinkType = "kafka"
+	SinkOTLP   SinkType = "otlp"
+)
+
+const (
+	EncoderJSON    = "json"
+	EncoderConsole = "console"
+)
+
+type DropPolicy string
+
+const (
+	DropNew    DropPolicy = "drop_new"    // 丢弃新的日志
+	DropOldest DropPolicy = "drop_oldest" // 丢弃最旧的日志
+	Block      DropPolicy = "block"       // 阻塞等待, 只支持内置的 stdout、file、syslog、http (写入时不会输出日志)
+)
+
+type SinkConfig struct {
+	Type    SinkType      `mapstructure:"type" validate:"oneof=stdout file syslog http kafka otlp"`
+	Level   LogLevel      `mapstructure:"level" validate:"omitempty,oneof=debug info warn error"` // sink 的最低级别, 为空时不额外过滤 (全局和模块级别先生效)
+	Encoder string        `mapstructure:"encoder" validate:"omitempty,oneof=json console" default:"json"`
+	Buffer  BufferConfig  `mapstructure:"buffer"`
+	Redact  *RedactConfig `mapstructure:"redact"` // 脱敏, 为空时使用全局配置 (e.g. 审计 sink 配置 disable 保留原始值)
+
+	Syslog SyslogConfig    `mapstructure:"syslog"`
+	HTTP   HTTPSinkConfig  `mapstructure:"http"`
+	Kafka  KafkaSinkConfig `mapstructure:"kafka"`
+	OTLP   OTLPSinkConfig  `mapstructure:"otlp"`
+}
+
+// BufferConfig 缓冲队列
+type BufferConfig struct {
+	Size          int            `mapstructure:"size" validate:"gte=0" default:"4096"`                                // 队列容量 (条)
+	BatchSize     int            `mapstructure:"batchSize" yaml:"batchSize" validate:"gte=0" default:"256"`           // 每批写入的条数
+	FlushInterval types.Duration `mapstructure:"flushInterval" yaml:"flushInterval" validate:"duration" default:"1s"` // 最长刷新间隔
+	DropPolicy    DropPolicy     `mapstructure:"dropPolicy" yaml:"dropPolicy" validate:"omitempty,oneof=drop_new drop_oldest block" default:"drop_new"`
+}
+
+type KafkaSinkConfig struct {
+	Addrs    []string       `mapstructure:"addrs"` // Kafka 服务器地址列表
+	Topic    string         `mapstructure:"topic"`
+	Producer map[string]any `mapstructure:"producer"` // 生产者配置 (acks、压缩、重试等), 同 kafka.ProducerConfig, 由 kafka 包解析
+}
+
+// SinkEntry 编码后的一条日志
+type SinkEntry struct {
+	Level zapcore.Level
+	Time  time.Time
+	Data  []byte
+}
+
+// BatchWriter 批量写入编码后的日志
+type BatchWriter interface {
+	WriteBatch(entries []SinkEntry) error
+}
+
+// SinkFactory 创建 sink 的写入器
+type SinkFactory func(conf Config, sc SinkConfig) (BatchWriter, error)
+
+var (
+	sinkFactoriesMu sync.RWMutex
+	sinkFactories   = make(map[SinkType]SinkFactory)
+)
+
+// RegisterSink 注册 sink 类型, 用于依赖其他组件的 sink (避免 logger 依赖具体组件)
+// 写入器实现了 Close() error 时, 关闭 sink 时会调用
+// 注册的 sink 不支持 Block, 配置为 Block 时使用 DropOldest
+func RegisterSink(typ SinkType, factory SinkFactory) {
+	sinkFactoriesMu.Lock()
+	defer sinkFactoriesMu.Unlock()
+	sinkFactories[typ] = factory
+}
+
+// newSinkCores 按配置创建 sink, 返回的 io.Closer 用于刷新并关闭
+// 创建失败的 sink 会被忽略; 全部失败时输出到标准输出
+func newSinkCores(conf Config, level zapcore.LevelEnabler) ([]zapcore.Core, []io.Closer) {
+	var (
+		cores   []zapcore.Core
+		closers []io.Closer
+	)
+	var file bool
+	for i, sc := range conf.Sinks {
+		if sc.Type == SinkFile {
+			if file { // 配置校验已拒绝, 代码中通过 WithSinks 设置时忽略
+				fmt.Fprintf(os.Stderr, "[logger] init sink %d (%s) error: only one file sink is supported\n", i, sc.Type)
+				continue
+			}
+			file = true
+		}
+		core, closer, err := newSinkCore(conf, sc, sinkLevel(sc.Level, level))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[logger] init sink %d (%s) error: %v\n", i, sc.Type, err)
+			continue
+		}
+		cores = append(cores, core)
+		closers = append(closers, closer)
+	}
+	if len(cores) == 0 {
+		cores = append(cores, zapcore.NewCore(sinkEncoder(conf, SinkConfig{Type: SinkStdout}), zapcore.Lock(os.Stdout), level))
+	}
+	return cores, closers
+}
+
+func newSinkCore(conf Config, sc SinkConfig, level zapcore.LevelEnabler) (zapcore.Core, io.Closer, error) {
+	var (
+		w   BatchWriter
+		err error
+	)
+	switch sc.Type {
+	case SinkOTLP:
+		core, closer, err := newOTLPCore(conf, sc.OTLP, sc.Buffer, level)
+		if r := newRedactor(sc.Redact, conf.Redact); r != nil && err == nil {
+			core = &redactCore{Core: core, r: r}
+		}
+		return core, closer, err
+	case SinkStdout:
+		w = writerBatch{zapcore.Lock(os.Stdout)}
+	case SinkFile:
+		w = writerBatch{setLoggerWriter(conf)}
+	case SinkSyslog:
+		w, err = newSyslogWriter(conf.appName, sc.Syslog)
+	case SinkHTTP:
+		w, err = newHTTPWriter(conf.appName, sc.HTTP)
+	default:
+		sinkFactoriesMu.RLock()
+		factory, ok := sinkFactories[sc.Type]
+		sinkFactoriesMu.RUnlock()
+		if !ok && sc.Type == SinkKafka {
+			return nil, nil, errors.New(`kafka sink is not registered, import "github.com/bobacgo/kit/app/mq/kafka"`)
+		}
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown sink type %q", sc.Type)
+		}
+		w, err = factory(conf, sc)
+		// 注册的 sink (e.g. kafka) 写入时可能通过全局日志输出, 再次进入自己的队列, 阻塞时会死锁
+		if sc.Buffer.DropPolicy == Block {
+			fmt.Fprintf(os.Stderr, "[logger] sink %s does not support drop policy %s, use %s\n", sc.Type, Block, DropOldest)
+			sc.Buffer.DropPolicy = DropOldest
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	bw := newBufferedWriter(string(sc.Type), w, sc.Buffer)
+	return &sinkCore{LevelEnabler: level, enc: sinkEncoder(conf, sc), out: bw}, bw, nil
+}
+
+func sinkLevel(l LogLevel, global zapcore.LevelEnabler) zapcore.LevelEnabler {
+	if l == "" {
+		return global
+	}
+	lvl, err := zapcore.ParseLevel(l.String())
+	if err != nil {
+		return global
+	}
+	return lvl
+}
+
+func sinkEncoder(conf Config, sc SinkConfig) zapcore.Encoder {
+	var enc zapcore.Encoder
+	switch {
+	case sc.Type == SinkHTTP || sc.Type == SinkKafka: // 下游按 JSON 解析
+		enc = setJSONEncoder(conf.TimeFormat, true)
+	case sc.Encoder == EncoderConsole && sc.Type == SinkStdout:
+		enc = setConsoleEncoder(conf.TimeFormat)
+	default:
+		enc = setJSONEncoder(conf.TimeFormat, sc.Encoder != EncoderConsole)
+	}
+	return withRedact(enc, newRedactor(sc.Redact, conf.Redact))
+}
+
+// sinkCore 编码日志并放入 sink 的缓冲队列
+type sinkCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	out *bufferedWriter
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &sinkCore{LevelEnabler: c.LevelEnabler, enc: c.enc.Clone(), out: c.out}
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *sinkCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sinkCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, buf.Len())
+	copy(data, buf.Bytes())
+	buf.Free()
+	c.out.Put(SinkEntry{Level: ent.Level, Time: ent.Time, Data: data})
+	if ent.Level > zapcore.ErrorLevel {
+		// 同 zapcore.ioCore, Panic、Fatal 之后进程可能退出, 立即写入队列中的日志
+		_ = c.Sync()
+	}
+	return nil
+}
+
+func (c *sinkCore) Sync() error {
+	return c.out.Sync()
+}
+
+// writerBatch 适配 io.Writer
+type writerBatch struct {
+	w zapcore.WriteSyncer
+}
+
+func (w writerBatch) WriteBatch(entries []SinkEntry) error {
+	var errs []error
+	for _, e := range entries {
+		if _, err := w.w.Write(e.Data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (w writerBatch) Close() error {
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// closeSinks 刷新并关闭上一次初始化的 sink
+func closeSinks(closers []io.Closer) {
+	for _, c := range closers {
+		_ = c.Close()
+	}
+}
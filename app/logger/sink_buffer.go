@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	bufferSizeDefault    = 4096
+	batchSizeDefault     = 256
+	flushIntervalDefault = time.Second
+)
+
+// bufferedWriter sink 的缓冲队列 (环形队列), 后台按批写入
+// 队列满时按 DropPolicy 处理, 丢弃的条数定期输出到 stderr, 并计入 Dropped 指标
+type bufferedWriter struct {
+	name      string
+	w         BatchWriter
+	size      int
+	batchSize int
+	interval  time.Duration
+	policy    DropPolicy
+
+	mu       sync.Mutex
+	notFull  *sync.Cond
+	queue    *ring
+	closed   bool
+	writeMu  sync.Mutex // 保证批次按顺序写入
+	notify   chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	dropped  atomic.Uint64
+	total    *atomic.Uint64 // 同名 sink 累计丢弃的条数 (重新初始化不清零)
+	reported uint64
+}
+
+func newBufferedWriter(name string, w BatchWriter, conf BufferConfig) *bufferedWriter {
+	b := &bufferedWriter{
+		name:      name,
+		w:         w,
+		size:      conf.Size,
+		batchSize: conf.BatchSize,
+		interval:  conf.FlushInterval.TimeDuration(),
+		policy:    conf.DropPolicy,
+		notify:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	if b.size <= 0 {
+		b.size = bufferSizeDefault
+	}
+	if b.batchSize <= 0 {
+		b.batchSize = batchSizeDefault
+	}
+	if b.interval <= 0 {
+		b.interval = flushIntervalDefault
+	}
+	b.queue = newRing(b.size)
+	b.total = droppedCounter(name)
+	b.notFull = sync.NewCond(&b.mu)
+	go b.loop()
+	return b
+}
+
+// Put 放入队列
+func (b *bufferedWriter) Put(e SinkEntry) {
+	b.mu.Lock()
+	for b.queue.full() && !b.closed {
+		switch b.policy {
+		case DropOldest:
+			b.queue.pop()
+			b.drop()
+		case Block:
+			b.notFull.Wait()
+			continue
+		default: // DropNew
+			b.mu.Unlock()
+			b.drop()
+			return
+		}
+	}
+	if b.closed {
+		b.mu.Unlock()
+		b.writeSync(e)
+		return
+	}
+	b.queue.push(e)
+	full := b.queue.len() >= b.batchSize
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *bufferedWriter) drop() {
+	b.dropped.Add(1)
+	b.total.Add(1)
+}
+
+// Dropped 丢弃的条数
+func (b *bufferedWriter) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+func (b *bufferedWriter) loop() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.flush()
+			b.report()
+		case <-b.notify:
+			b.flush()
+		}
+	}
+}
+
+// flush 写入队列中所有的日志
+func (b *bufferedWriter) flush() error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	for {
+		b.mu.Lock()
+		batch := b.queue.take(b.batchSize)
+		if len(batch) == 0 {
+			b.mu.Unlock()
+			return nil
+		}
+		b.notFull.Broadcast()
+		b.mu.Unlock()
+
+		if err := b.w.WriteBatch(batch); err != nil {
+			// 写入失败时不能再写入日志, 避免循环
+			fmt.Fprintf(os.Stderr, "[logger] sink %s write %d entries error: %v\n", b.name, len(batch), err)
+		}
+	}
+}
+
+// writeSync 关闭后同步写入, 保证退出过程中的日志 (e.g. 停止服务失败的错误) 不丢失
+func (b *bufferedWriter) writeSync(e SinkEntry) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	if err := b.w.WriteBatch([]SinkEntry{e}); err != nil {
+		fmt.Fprintf(os.Stderr, "[logger] sink %s write error: %v\n", b.name, err)
+	}
+}
+
+func (b *bufferedWriter) report() {
+	if d := b.dropped.Load(); d > b.reported {
+		fmt.Fprintf(os.Stderr, "[logger] sink %s dropped %d entries (total %d)\n", b.name, d-b.reported, d)
+		b.reported = d
+	}
+}
+
+func (b *bufferedWriter) Sync() error {
+	return b.flush()
+}
+
+// Close 停止后台写入, 刷新剩余的日志并关闭 sink
+// 之后 Put 的日志同步写入 sink (sink 在下次写入时重新连接或打开)
+func (b *bufferedWriter) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.notFull.Broadcast()
+	b.mu.Unlock()
+
+	close(b.done)
+	<-b.stopped
+	err := b.flush()
+	b.report()
+	if c, ok := b.w.(interface{ Close() error }); ok {
+		b.writeMu.Lock()
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		b.writeMu.Unlock()
+	}
+	return err
+}
+
+// ring 固定容量的环形队列
+type ring struct {
+	buf  []SinkEntry
+	head int // 最旧的元素
+	n    int
+}
+
+func newRing(size int) *ring {
+	return &ring{buf: make([]SinkEntry, size)}
+}
+
+func (r *ring) len() int   { return r.n }
+func (r *ring) full() bool { return r.n == len(r.buf) }
+
+func (r *ring) push(e SinkEntry) {
+	r.buf[(r.head+r.n)%len(r.buf)] = e
+	r.n++
+}
+
+// pop 移除最旧的元素
+func (r *ring) pop() SinkEntry {
+	e := r.buf[r.head]
+	r.buf[r.head] = SinkEntry{} // 释放引用
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+	return e
+}
+
+// take 按顺序取出最多 limit 个元素
+func (r *ring) take(limit int) []SinkEntry {
+	n := min(r.n, limit)
+	if n == 0 {
+		return nil
+	}
+	out := make([]SinkEntry, n)
+	for i := range out {
+		out[i] = r.pop()
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bobacgo/kit/app/types"
+)
+
+const (
+	HTTPFormatLoki          = "loki"
+	HTTPFormatElasticsearch = "elasticsearch"
+)
+
+type HTTPSinkConfig struct {
+	URL     string            `mapstructure:"url" validate:"omitempty,url"`                                        // loki: http://127.0.0.1:3100/loki/api/v1/push, es: http://127.0.0.1:9200/_bulk
+	Format  string            `mapstructure:"format" validate:"omitempty,oneof=loki elasticsearch" default:"loki"` // 推送格式
+	Labels  map[string]string `mapstructure:"labels"`                                                              // loki stream 标签, 默认包含 app、level
+	Index   string            `mapstructure:"index"`                                                               // es 索引名, 默认为服务名称
+	Headers map[string]string `mapstructure:"headers"`                                                             // e.g. Authorization
+	Timeout types.Duration    `mapstructure:"timeout" validate:"duration" default:"5s"`
+}
+
+// httpWriter 批量推送到 Loki 或 Elasticsearch
+type httpWriter struct {
+	conf    HTTPSinkConfig
+	appName string
+	client  *http.Client
+}
+
+func newHTTPWriter(appName string, conf HTTPSinkConfig) (*httpWriter, error) {
+	if conf.URL == "" {
+		return nil, errors.New("http sink url is empty")
+	}
+	if conf.Format == "" {
+		conf.Format = HTTPFormatLoki
+	}
+	if conf.Index == "" {
+		conf.Index = appName
+	}
+	return &httpWriter{
+		conf:    conf,
+		appName: appName,
+		client:  &http.Client{Timeout: conf.Timeout.TimeDuration()},
+	}, nil
+}
+
+func (w *httpWriter) WriteBatch(entries []SinkEntry) error {
+	var body []byte
+	contentType := "application/json"
+	switch w.conf.Format {
+	case HTTPFormatElasticsearch:
+		body, contentType = w.bulk(entries), "application/x-ndjson"
+	default:
+		body = w.loki(entries)
+	}
+	req, err := http.NewRequest(http.MethodPost, w.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range w.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("http sink status %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// loki 按级别分组为 stream
+func (w *httpWriter) loki(entries []SinkEntry) []byte {
+	streams := make(map[string]*lokiStream)
+	order := make([]string, 0, 4)
+	for _, e := range entries {
+		level := e.Level.String()
+		s, ok := streams[level]
+		if !ok {
+			labels := map[string]string{"app": w.appName, "level": level}
+			for k, v := range w.conf.Labels {
+				labels[k] = v
+			}
+			s = &lokiStream{Stream: labels}
+			streams[level] = s
+			order = append(order, level)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(e.Time.UnixNano(), 10), strings.TrimRight(string(e.Data), "\n")})
+	}
+	push := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, level := range order {
+		push.Streams = append(push.Streams, streams[level])
+	}
+	data, _ := json.Marshal(push)
+	return data
+}
+
+// bulk Elasticsearch bulk API (NDJSON)
+func (w *httpWriter) bulk(entries []SinkEntry) []byte {
+	action, _ := json.Marshal(map[string]any{"index": map[string]string{"_index": w.conf.Index}})
+	var buf bytes.Buffer
+	for _, e := range entries {
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(bytes.TrimRight(e.Data, "\n"))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.uber.org/zap/zapcore"
+)
+
+type OTLPSinkConfig struct {
+	Endpoint string            `mapstructure:"endpoint"` // 127.0.0.1:4317 (gRPC)
+	Insecure bool              `mapstructure:"insecure"`
+	Headers  map[string]string `mapstructure:"headers"`
+}
+
+// newOTLPCore 通过 OTLP logs exporter 导出
+// 缓冲由 sdk 的 BatchProcessor 负责, 队列满时丢弃最旧的日志 (dropPolicy 不生效)
+func newOTLPCore(conf Config, sc OTLPSinkConfig, buf BufferConfig, level zapcore.LevelEnabler) (zapcore.Core, io.Closer, error) {
+	opts := []otlploggrpc.Option{otlploggrpc.WithEndpoint(sc.Endpoint)}
+	if sc.Insecure {
+		opts = append(opts, otlploggrpc.WithInsecure())
+	}
+	if len(sc.Headers) > 0 {
+		opts = append(opts, otlploggrpc.WithHeaders(sc.Headers))
+	}
+	exp, err := otlploggrpc.New(context.Background(), opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bopts []sdklog.BatchProcessorOption
+	if buf.Size > 0 {
+		bopts = append(bopts, sdklog.WithMaxQueueSize(buf.Size))
+	}
+	if buf.BatchSize > 0 {
+		bopts = append(bopts, sdklog.WithExportMaxBatchSize(buf.BatchSize))
+	}
+	if d := buf.FlushInterval.TimeDuration(); d > 0 {
+		bopts = append(bopts, sdklog.WithExportInterval(d))
+	}
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exp, bopts...)),
+		sdklog.WithResource(resource.NewSchemaless(semconv.ServiceName(conf.appName))),
+	)
+	core := otelzap.NewCore(conf.appName, otelzap.WithLoggerProvider(provider))
+	return &levelCore{Core: core, level: level}, otlpCloser{provider}, nil
+}
+
+// levelCore 为 core 增加级别过滤
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	return c.level.Enabled(l) && c.Core.Enabled(l)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+type otlpCloser struct {
+	provider *sdklog.LoggerProvider
+}
+
+func (c otlpCloser) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return c.provider.Shutdown(ctx)
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type SyslogConfig struct {
+	Network  string `mapstructure:"network" validate:"omitempty,oneof=udp tcp" default:"udp"`
+	Addr     string `mapstructure:"addr"`                                          // 127.0.0.1:514
+	Facility int    `mapstructure:"facility" validate:"gte=0,lte=23" default:"16"` // 默认 local0
+	Tag      string `mapstructure:"tag"`                                           // APP-NAME, 默认为服务名称
+}
+
+// syslogWriter RFC 5424 格式, TCP 使用 octet-counting 分帧 (RFC 6587)
+type syslogWriter struct {
+	conf     SyslogConfig
+	hostname string
+	conn     net.Conn
+}
+
+func newSyslogWriter(appName string, conf SyslogConfig) (*syslogWriter, error) {
+	if conf.Addr == "" {
+		return nil, errors.New("syslog addr is empty")
+	}
+	if conf.Network == "" {
+		conf.Network = "udp"
+	}
+	if conf.Tag == "" {
+		conf.Tag = appName
+	}
+	if conf.Tag == "" {
+		conf.Tag = "-"
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &syslogWriter{conf: conf, hostname: hostname}, nil
+}
+
+func (w *syslogWriter) WriteBatch(entries []SinkEntry) error {
+	if w.conn == nil { // 延迟连接, 断开后在下一批重连
+		conn, err := net.DialTimeout(w.conf.Network, w.conf.Addr, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	var errs []error
+	for _, e := range entries {
+		if _, err := w.conn.Write(w.format(e)); err != nil {
+			errs = append(errs, err)
+			_ = w.conn.Close()
+			w.conn = nil
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// format <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (w *syslogWriter) format(e SinkEntry) []byte {
+	pri := w.conf.Facility*8 + severity(e.Level)
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d - - %s", pri, e.Time.Format(time.RFC3339Nano), w.hostname, w.conf.Tag, os.Getpid(),
+		bytes.TrimRight(e.Data, "\n"))
+	if w.conf.Network == "tcp" {
+		return []byte(strconv.Itoa(len(msg)) + " " + msg)
+	}
+	return []byte(msg)
+}
+
+func (w *syslogWriter) Close() error {
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// severity zap 级别转换为 syslog severity
+func severity(l zapcore.Level) int {
+	switch l {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		return 2
+	default: // fatal
+		return 0
+	}
+}
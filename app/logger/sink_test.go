@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// blockingWriter 在 release 之前阻塞写入, 用于填满队列
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	got     []string
+}
+
+func (w *blockingWriter) WriteBatch(entries []SinkEntry) error {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, e := range entries {
+		w.got = append(w.got, string(e.Data))
+	}
+	return nil
+}
+
+func TestBufferedWriterDropPolicy(t *testing.T) {
+	tests := []struct {
+		policy DropPolicy
+		want   []string
+	}{
+		{policy: DropNew, want: []string{"1", "2"}},
+		{policy: DropOldest, want: []string{"3", "4"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			w := &blockingWriter{release: make(chan struct{})}
+			b := newBufferedWriter("test", w, BufferConfig{Size: 2, BatchSize: 100, FlushInterval: "1h", DropPolicy: tt.policy})
+			for _, s := range []string{"1", "2", "3", "4"} {
+				b.Put(SinkEntry{Data: []byte(s)})
+			}
+			if b.Dropped() != 2 {
+				t.Errorf("dropped = %d, want 2", b.Dropped())
+			}
+			close(w.release)
+			if err := b.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(w.got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", w.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSinkFlushFatal(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	close(w.release)
+	b := newBufferedWriter("test", w, BufferConfig{BatchSize: 100, FlushInterval: "1h"})
+	defer b.Close()
+	core := &sinkCore{LevelEnabler: zapcore.DebugLevel, enc: setJSONEncoder(timeFormatDefault, true), out: b}
+
+	_ = core.Write(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "error"}, nil)
+	if len(w.got) != 0 {
+		t.Fatalf("error should be buffered, got %v", w.got)
+	}
+	_ = core.Write(zapcore.Entry{Level: zapcore.FatalLevel, Message: "fatal"}, nil)
+	if len(w.got) != 2 { // 未到刷新间隔, 写入 Fatal 时立即写入
+		t.Fatalf("fatal should be flushed, got %v", w.got)
+	}
+}
+
+func TestRegisteredSinkRefusesBlock(t *testing.T) {
+	RegisterSink("test-block", func(Config, SinkConfig) (BatchWriter, error) {
+		return writerBatch{zapcore.AddSync(io.Discard)}, nil
+	})
+	core, closer, err := newSinkCore(Config{}, SinkConfig{Type: "test-block", Buffer: BufferConfig{DropPolicy: Block}}, zapcore.DebugLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	if p := core.(*sinkCore).out.policy; p != DropOldest {
+		t.Errorf("policy = %s, want %s", p, DropOldest)
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies = make(map[string]string)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.URL.Path] = string(data)
+		mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	conf := NewConfig(withAppName("demo"), WithSinks(
+		SinkConfig{Type: SinkHTTP, HTTP: HTTPSinkConfig{URL: srv.URL + "/loki/api/v1/push", Labels: map[string]string{"env": "test"}, Headers: map[string]string{"Authorization": "Bearer t"}}},
+		SinkConfig{Type: SinkHTTP, Level: LogLevel_Error, HTTP: HTTPSinkConfig{URL: srv.URL + "/_bulk", Format: HTTPFormatElasticsearch, Headers: map[string]string{"Authorization": "Bearer t"}}},
+	))
+	cores, closers := newSinkCores(conf, zapcore.DebugLevel)
+	log := zap.New(zapcore.NewTee(cores...))
+	log.Info("hello", zap.String("k", "v"))
+	log.Error("boom")
+	closeSinks(closers)
+
+	var push struct {
+		Streams []lokiStream `json:"streams"`
+	}
+	if err := json.Unmarshal([]byte(bodies["/loki/api/v1/push"]), &push); err != nil {
+		t.Fatal(err, bodies)
+	}
+	if len(push.Streams) != 2 || push.Streams[0].Stream["app"] != "demo" || push.Streams[0].Stream["env"] != "test" ||
+		push.Streams[0].Stream["level"] != "info" || !strings.Contains(push.Streams[0].Values[0][1], `"k":"v"`) {
+		t.Errorf("unexpected loki push %+v", push)
+	}
+
+	lines := strings.Split(strings.TrimSpace(bodies["/_bulk"]), "\n")
+	if len(lines) != 2 || lines[0] != `{"index":{"_index":"demo"}}` || !strings.Contains(lines[1], `"msg":"boom"`) {
+		t.Errorf("unexpected bulk body %q", bodies["/_bulk"])
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	conf := NewConfig(withAppName("demo"), WithSinks(SinkConfig{Type: SinkSyslog, Syslog: SyslogConfig{Addr: pc.LocalAddr().String(), Facility: 16}}))
+	cores, closers := newSinkCores(conf, zapcore.InfoLevel)
+	log := zap.New(zapcore.NewTee(cores...))
+	log.Warn("disk full")
+	log.Debug("ignored")
+	closeSinks(closers)
+
+	_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<132>1 ") || !strings.Contains(msg, " demo ") || !strings.Contains(msg, "disk full") {
+		t.Errorf("unexpected syslog message %q", msg)
+	}
+}
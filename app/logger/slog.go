@@ -7,8 +7,8 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-// traceHandler 是一个添加 trace ID 和 span ID 的 slog handler
-// traceHandler is a slog handler that adds trace ID and span ID
+// traceHandler 是一个添加 trace ID、span ID 和上下文日志字段的 slog handler
+// traceHandler is a slog handler that adds trace ID, span ID and context fields
 type traceHandler struct {
 	handler slog.Handler
 }
@@ -18,8 +18,8 @@ func (h *traceHandler) Enabled(ctx context.Context, level slog.Level) bool {
 }
 
 func (h *traceHandler) Handle(ctx context.Context, record slog.Record) error {
-	// 从 context 中提取 trace 信息
-	// Extract trace information from context
+	// 从 context 中提取 trace 信息和上下文日志字段 (见 fields.go)
+	// Extract trace information and context fields from context
 	if ctx != nil {
 		spanCtx := trace.SpanContextFromContext(ctx)
 		if spanCtx.IsValid() {
@@ -28,6 +28,7 @@ func (h *traceHandler) Handle(ctx context.Context, record slog.Record) error {
 				slog.String("span_id", spanCtx.SpanID().String()),
 			)
 		}
+		record.AddAttrs(Fields(ctx)...)
 	}
 	return h.handler.Handle(ctx, record)
 }
@@ -44,6 +45,8 @@ func (h *traceHandler) WithGroup(name string) slog.Handler {
 	}
 }
 
+// InitSlog 设置默认的 slog handler
+// 脱敏在每个输出的编码器中处理, 可按 sink 配置 (见 redact.go)
 func InitSlog(h slog.Handler) {
 	// 包装原始 handler，添加 trace 支持
 	// Wrap the original handler with trace support
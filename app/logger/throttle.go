@@ -0,0 +1,359 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bobacgo/kit/app/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 日志限流
+/*
+	作用于 InitZapLogger 创建的 core, zap.L()、slog 以及 Named 模块 logger 都会生效; 访问日志 (access 模块) 不限流
+	处理顺序: 去重 -> 按调用位置限速 -> 采样
+
+		dedup     窗口内相同的日志 (级别 + 消息 + 调用位置) 只输出第一条, 窗口结束时输出 "(repeated N times)" 汇总
+		rateLimit 每个调用位置 (file:line) 的令牌桶限速, 恢复输出时带上 rate_limited 字段 (期间丢弃的条数)
+		sampling  每个 tick 内相同消息先输出 first 条, 之后每 thereafter 条输出一条 (按级别配置)
+*/
+
+type SamplingConfig struct {
+	Tick   types.Duration             `mapstructure:"tick" validate:"duration" default:"1s"`
+	Levels map[LogLevel]SamplingLevel `mapstructure:"levels"` // 未配置的级别不采样 e.g. {info: {first: 100, thereafter: 100}}
+}
+
+type SamplingLevel struct {
+	First      int `mapstructure:"first" validate:"gte=0"`
+	Thereafter int `mapstructure:"thereafter" validate:"gte=0"`
+}
+
+type DedupConfig struct {
+	Window types.Duration `mapstructure:"window" validate:"duration" default:"10s"` // 去重窗口
+}
+
+type RateLimitConfig struct {
+	PerSecond float64 `mapstructure:"perSecond" yaml:"perSecond" validate:"gte=0"` // 每个调用位置每秒的条数, 0 不限速
+	Burst     int     `mapstructure:"burst" validate:"gte=0"`                      // 突发条数, 默认等于 perSecond
+}
+
+// newThrottleCore 按配置包装 core, 返回的 io.Closer 用于输出剩余的去重汇总
+func newThrottleCore(core zapcore.Core, conf Config) (zapcore.Core, []io.Closer) {
+	var closers []io.Closer
+	if s := conf.Sampling; s != nil && len(s.Levels) > 0 {
+		core = newLevelSampler(core, s)
+	}
+	if rl := conf.RateLimit; rl != nil && rl.PerSecond > 0 {
+		core = newRateLimitCore(core, rl)
+	}
+	if d := conf.Dedup; d != nil && d.Window.TimeDuration() > 0 {
+		dc := newDedupCore(core, d.Window.TimeDuration())
+		core = dc
+		closers = append(closers, dc.state)
+	}
+	return core, closers
+}
+
+// throttledCore 限流后的 core, raw 为限流之前的 core, 供不限流的模块使用 (见 moduleCore)
+type throttledCore struct {
+	zapcore.Core
+	raw zapcore.Core
+}
+
+func (c *throttledCore) With(fields []zapcore.Field) zapcore.Core {
+	return &throttledCore{Core: c.Core.With(fields), raw: c.raw.With(fields)}
+}
+
+// passthrough 由包装的 core 在 Check 中决定是否输出, Write 时交给内部 core 检查级别等并写入
+func passthrough(inner zapcore.Core, ent zapcore.Entry, fields []zapcore.Field) error {
+	if ce := inner.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// ---------- sampling ----------
+
+// levelSampler 按级别使用不同的采样参数
+type levelSampler struct {
+	zapcore.Core
+	samplers map[zapcore.Level]zapcore.Core
+}
+
+func newLevelSampler(core zapcore.Core, conf *SamplingConfig) *levelSampler {
+	tick := conf.Tick.TimeDuration()
+	if tick <= 0 {
+		tick = time.Second
+	}
+	s := &levelSampler{Core: core, samplers: make(map[zapcore.Level]zapcore.Core)}
+	for l, rule := range conf.Levels {
+		lvl, err := zapcore.ParseLevel(l.String())
+		if err != nil || rule.First <= 0 {
+			continue
+		}
+		s.samplers[lvl] = zapcore.NewSamplerWithOptions(core, tick, rule.First, rule.Thereafter)
+	}
+	return s
+}
+
+func (s *levelSampler) With(fields []zapcore.Field) zapcore.Core {
+	clone := &levelSampler{Core: s.Core.With(fields), samplers: make(map[zapcore.Level]zapcore.Core, len(s.samplers))}
+	for l, c := range s.samplers {
+		clone.samplers[l] = c.With(fields)
+	}
+	return clone
+}
+
+func (s *levelSampler) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c, ok := s.samplers[ent.Level]; ok {
+		return c.Check(ent, ce)
+	}
+	return s.Core.Check(ent, ce)
+}
+
+// ---------- rate limit ----------
+
+// bucketSweepInterval 清理空闲令牌桶的间隔
+const bucketSweepInterval = time.Minute
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*bucket // 调用位置 -> 令牌桶
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+// allow 返回是否允许输出, 以及上次允许之后丢弃的条数
+func (r *rateLimiter) allow(key string, now time.Time) (bool, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now.Sub(r.lastSweep) >= bucketSweepInterval {
+		r.sweep(now)
+	}
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{tokens: r.burst, last: now}
+		r.buckets[key] = b
+	}
+	b.tokens = min(r.burst, b.tokens+now.Sub(b.last).Seconds()*r.rate)
+	b.last = now
+	if b.tokens < 1 {
+		b.dropped++
+		return false, 0
+	}
+	b.tokens--
+	dropped := b.dropped
+	b.dropped = 0
+	return true, dropped
+}
+
+// sweep 删除已经恢复满令牌且没有待上报丢弃条数的桶, 与新建的桶等价
+func (r *rateLimiter) sweep(now time.Time) {
+	r.lastSweep = now
+	for key, b := range r.buckets {
+		if b.dropped == 0 && b.tokens+now.Sub(b.last).Seconds()*r.rate >= r.burst {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+// rateLimitCore 按调用位置限速
+type rateLimitCore struct {
+	zapcore.Core
+	limiter *rateLimiter
+}
+
+func newRateLimitCore(core zapcore.Core, conf *RateLimitConfig) *rateLimitCore {
+	burst := float64(conf.Burst)
+	if burst <= 0 {
+		burst = max(conf.PerSecond, 1)
+	}
+	return &rateLimitCore{Core: core, limiter: &rateLimiter{rate: conf.PerSecond, burst: burst, buckets: make(map[string]*bucket)}}
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), limiter: c.limiter}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	ok, dropped := c.limiter.allow(callSite(ent), ent.Time)
+	if !ok {
+		return ce
+	}
+	if dropped == 0 {
+		return c.Core.Check(ent, ce)
+	}
+	return ce.AddCore(ent, &droppedCore{Core: c.Core, dropped: dropped})
+}
+
+// droppedCore 写入时带上限速期间丢弃的条数
+type droppedCore struct {
+	zapcore.Core
+	dropped int
+}
+
+func (c *droppedCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return passthrough(c.Core, ent, append(fields[:len(fields):len(fields)], zap.Int("rate_limited", c.dropped)))
+}
+
+// pkgDir 本包的目录, 用于跳过本包的栈帧
+var pkgDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return path.Dir(file)
+}()
+
+// callSite 输出日志的调用位置 (file:line)
+// zap、zapslog 在 core.Check 之后才设置 Entry.Caller, 所以这里自行查找栈,
+// 跳过 zap、slog、gorm 和本包的栈帧
+func callSite(ent zapcore.Entry) string {
+	if ent.Caller.Defined {
+		return fmt.Sprintf("%s:%d", ent.Caller.File, ent.Caller.Line)
+	}
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !loggingFrame(f) {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return ent.LoggerName + ":" + ent.Message
+}
+
+// loggingFrame 日志库内部的栈帧
+func loggingFrame(f runtime.Frame) bool {
+	for _, prefix := range []string{"go.uber.org/zap", "log/slog.", "moul.io/zapgorm2", "gorm.io/"} {
+		if strings.HasPrefix(f.Function, prefix) {
+			return true
+		}
+	}
+	return path.Dir(f.File) == pkgDir && !strings.HasSuffix(f.File, "_test.go")
+}
+
+// ---------- dedup ----------
+
+type dedupKey struct {
+	level  zapcore.Level
+	msg    string
+	caller string
+}
+
+type dedupEntry struct {
+	first    time.Time
+	repeated int
+	ent      zapcore.Entry
+	core     zapcore.Core // 输出第一条日志的 core, 汇总也写入该 core
+}
+
+type dedupState struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[dedupKey]*dedupEntry
+	done   chan struct{}
+	once   sync.Once
+}
+
+// flush 输出窗口已结束 (all 为 true 时全部) 的汇总
+func (s *dedupState) flush(now time.Time, all bool) {
+	s.mu.Lock()
+	var expired []*dedupEntry
+	for k, e := range s.seen {
+		if all || now.Sub(e.first) >= s.window {
+			delete(s.seen, k)
+			if e.repeated > 0 {
+				expired = append(expired, e)
+			}
+		}
+	}
+	s.mu.Unlock()
+	for _, e := range expired {
+		e.summary(now)
+	}
+}
+
+func (e *dedupEntry) summary(now time.Time) {
+	ent := e.ent
+	ent.Time = now
+	ent.Message = fmt.Sprintf("%s (repeated %d times)", e.ent.Message, e.repeated)
+	_ = passthrough(e.core, ent, []zapcore.Field{zap.Int("repeated", e.repeated)})
+}
+
+func (s *dedupState) loop() {
+	ticker := time.NewTicker(s.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.flush(now, false)
+		}
+	}
+}
+
+// Close 停止后台汇总并输出剩余的汇总
+func (s *dedupState) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+		s.flush(time.Now(), true)
+	})
+	return nil
+}
+
+// dedupCore 窗口内相同的日志只输出一次
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+}
+
+func newDedupCore(core zapcore.Core, window time.Duration) *dedupCore {
+	s := &dedupState{window: window, seen: make(map[dedupKey]*dedupEntry), done: make(chan struct{})}
+	go s.loop()
+	return &dedupCore{Core: core, state: s}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	key := dedupKey{level: ent.Level, msg: ent.Message, caller: callSite(ent)}
+	s := c.state
+	s.mu.Lock()
+	prev, ok := s.seen[key]
+	if ok && ent.Time.Sub(prev.first) < s.window {
+		prev.repeated++
+		s.mu.Unlock()
+		return ce
+	}
+	s.seen[key] = &dedupEntry{first: ent.Time, ent: ent, core: c.Core}
+	s.mu.Unlock()
+
+	if ok && prev.repeated > 0 { // 上一个窗口的汇总还没有输出
+		prev.summary(ent.Time)
+	}
+	return c.Core.Check(ent, ce)
+}
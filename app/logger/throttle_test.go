@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/exp/zapslog"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSampling(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	core, _ := newThrottleCore(obs, Config{Sampling: &SamplingConfig{
+		Tick:   "1m",
+		Levels: map[LogLevel]SamplingLevel{LogLevel_Info: {First: 2, Thereafter: 5}},
+	}})
+	log := zap.New(core)
+	for i := 0; i < 12; i++ {
+		log.Info("sampled")
+		log.Error("not sampled")
+	}
+	if n := logs.FilterMessage("sampled").Len(); n != 4 { // 前 2 条, 之后第 5、10 条
+		t.Errorf("sampled info = %d, want 4", n)
+	}
+	if n := logs.FilterMessage("not sampled").Len(); n != 12 {
+		t.Errorf("error = %d, want 12", n)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	core, _ := newThrottleCore(obs, Config{RateLimit: &RateLimitConfig{PerSecond: 0.001, Burst: 3}})
+	log := zap.New(core)
+	kafkaDown := func() {
+		log.Error("kafka down") // 同一个调用位置
+	}
+	for i := 0; i < 10; i++ {
+		kafkaDown()
+	}
+	log.Error("other site")
+	if n := logs.FilterMessage("kafka down").Len(); n != 3 {
+		t.Errorf("rate limited = %d, want 3", n)
+	}
+	if logs.FilterMessage("other site").Len() != 1 {
+		t.Error("other call site should not be limited")
+	}
+
+	// 恢复输出时带上丢弃的条数
+	rl := core.(*rateLimitCore)
+	for _, b := range rl.limiter.buckets {
+		b.tokens = 1
+	}
+	kafkaDown()
+	kafkaDown()
+	entries := logs.FilterField(zap.Int("rate_limited", 7)).All()
+	if len(entries) != 1 {
+		t.Errorf("expected rate_limited=7 field, got %+v", logs.All())
+	}
+}
+
+func TestRateLimitCallSite(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	core, _ := newThrottleCore(obs, Config{RateLimit: &RateLimitConfig{PerSecond: 0.001, Burst: 3}})
+	log := zap.New(core)
+	for i := 0; i < 5; i++ {
+		log.Error("kafka down")
+		log.Error("kafka down") // 相同的消息, 不同的调用位置
+		slog.New(zapslog.NewHandler(core)).Error("kafka down")
+	}
+	if n := logs.FilterMessage("kafka down").Len(); n != 9 {
+		t.Errorf("entries = %d, want 9", n)
+	}
+
+	// 空闲的令牌桶会被清理
+	rl := core.(*rateLimitCore).limiter
+	if len(rl.buckets) != 3 {
+		t.Fatalf("buckets = %d, want 3", len(rl.buckets))
+	}
+	for _, b := range rl.buckets {
+		b.dropped = 0
+	}
+	rl.allow("other", time.Now().Add(time.Hour*24))
+	if len(rl.buckets) != 1 {
+		t.Errorf("idle buckets should be evicted, got %d", len(rl.buckets))
+	}
+}
+
+func TestDedup(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	core, closers := newThrottleCore(obs, Config{Dedup: &DedupConfig{Window: "1m"}})
+	log := zap.New(core, zap.AddCaller())
+	for i := 0; i < 5; i++ {
+		log.Warn("consume error", zap.Int("i", i))
+	}
+	log.Info("another")
+	if logs.Len() != 2 {
+		t.Fatalf("entries = %d, want 2", logs.Len())
+	}
+	closeSinks(closers) // 关闭时输出汇总
+	var summary observer.LoggedEntry
+	for _, e := range logs.All() {
+		if strings.Contains(e.Message, "repeated") {
+			summary = e
+		}
+	}
+	if summary.Message != "consume error (repeated 4 times)" || summary.Level != zapcore.WarnLevel {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestAccessNotThrottled(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	throttled, closers := newThrottleCore(obs, Config{
+		Dedup:     &DedupConfig{Window: "1m"},
+		RateLimit: &RateLimitConfig{PerSecond: 0.001, Burst: 1},
+		Sampling:  &SamplingConfig{Tick: "1m", Levels: map[LogLevel]SamplingLevel{LogLevel_Info: {First: 1, Thereafter: 100}}},
+	})
+	var core zapcore.Core = &throttledCore{Core: throttled, raw: obs}
+	baseCore.Store(&core)
+	t.Cleanup(func() {
+		closeSinks(closers)
+		baseCore.Store(nil)
+	})
+	SetLevel(LogLevel_Info)
+
+	// 同一个路由的不同请求
+	for i := 0; i < 5; i++ {
+		LogAccess(context.Background(), &Access{Protocol: "http", Method: "GET", Route: "/users/:id", Status: 200 + i, Level: slog.LevelInfo})
+	}
+	if n := logs.FilterMessage("[http] GET /users/:id").Len(); n != 5 {
+		t.Errorf("access entries = %d, want 5", n)
+	}
+	for i := 0; i < 5; i++ {
+		Named("test-access").Info("same")
+	}
+	if n := logs.FilterMessage("same").Len(); n != 1 {
+		t.Errorf("other modules should be throttled, got %d", n)
+	}
+}
@@ -1,16 +1,24 @@
 package logger
 
 import (
+	"io"
+	"log"
+	"log/slog"
 	"os"
+	"sync"
 
-	"github.com/natefinch/lumberjack"
 	"go.uber.org/zap"
 	"go.uber.org/zap/exp/zapslog"
 	"go.uber.org/zap/zapcore"
 )
 
-// atomicLevel 动态更新限制日志打印级别
-var atomicLevel zap.AtomicLevel
+var (
+	// atomicLevel 动态更新限制日志打印级别 (全局级别, 模块没有单独设置时也使用)
+	atomicLevel = zap.NewAtomicLevel()
+	// sinkClosers 上一次初始化的 sink 和限流 core, 重新初始化或 Close 时关闭
+	sinkMu      sync.Mutex
+	sinkClosers []io.Closer
+)
 
 func SetLevel(l LogLevel) {
 	if l == "" {
@@ -20,28 +28,68 @@ func SetLevel(l LogLevel) {
 }
 
 func InitZapLogger(conf Config) {
-	atomicLevel = zap.NewAtomicLevel()
 	go func() {
 		for level := range conf.LevelCh {
 			_ = atomicLevel.UnmarshalText([]byte(level))
 		}
 	}()
-	fileCore := zapcore.NewCore( // 输出到日志文件
-		setJSONEncoder(conf.TimeFormat, conf.FileJsonEncoder),
-		setLoggerWriter(conf),
-		atomicLevel,
-	)
-	consoleCore := zapcore.NewCore( // 输出到控制台
-		setConsoleEncoder(conf.TimeFormat),
-		zapcore.Lock(os.Stdout),
-		atomicLevel,
-	)
+	setPropagateFields(conf.PropagateFields)
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	closeSinks(sinkClosers)
+	sinkClosers = nil
+
+	// 级别由 moduleCore 按全局/模块级别过滤, 底层 core 不再限制
+	all := zapcore.DebugLevel
+	var cores []zapcore.Core
+	if len(conf.Sinks) > 0 {
+		cores, sinkClosers = newSinkCores(conf, all)
+	} else {
+		r := newRedactor(nil, conf.Redact)
+		fileEnc, fileWriter := withRedact(setJSONEncoder(conf.TimeFormat, conf.FileJsonEncoder), r), setLoggerWriter(conf) // 输出到日志文件
+		consoleEnc, console := withRedact(setConsoleEncoder(conf.TimeFormat), r), zapcore.Lock(os.Stdout)                  // 输出到控制台
+		// 异步写入时, 关闭队列会一并关闭日志文件
+		if conf.Async != nil {
+			fileCore, fileCloser := newAsyncCore(string(SinkFile), fileEnc, fileWriter, all, *conf.Async)
+			consoleCore, consoleCloser := newAsyncCore(string(SinkStdout), consoleEnc, console, all, *conf.Async)
+			cores = []zapcore.Core{fileCore, consoleCore}
+			sinkClosers = []io.Closer{fileCloser, consoleCloser}
+		} else {
+			cores = []zapcore.Core{zapcore.NewCore(fileEnc, fileWriter, all), zapcore.NewCore(consoleEnc, console, all)}
+			sinkClosers = []io.Closer{fileWriter}
+		}
+	}
 
-	core := zapcore.NewTee(fileCore, consoleCore)
+	tee := zapcore.NewTee(cores...)
+	throttled, closers := newThrottleCore(tee, conf)
+	sinkClosers = append(closers, sinkClosers...) // 先输出去重汇总, 再关闭 sink
+	setCore(&throttledCore{Core: throttled, raw: tee})
+}
+
+func setCore(tee zapcore.Core) {
+	baseCore.Store(&tee)
+	core := &moduleCore{mod: module(RootModule), core: tee}
 	zap.ReplaceGlobals(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2))) // 替换全局的logger实例，后续在其他包中只需使用zap.L()调用即可
 	InitSlog(zapslog.NewHandler(core, zapslog.WithCaller(true), zapslog.AddStacktraceAt(16)))
 }
 
+// ReplaceCore 替换全局 logger (zap.L、slog.Default、模块 logger) 的底层 core, 返回恢复函数
+// 用于测试捕获日志, 见 logtest 包
+func ReplaceCore(core zapcore.Core) (restore func()) {
+	prevBase, prevZap, prevSlog, prevLevel := baseCore.Load(), zap.L(), slog.Default(), atomicLevel.Level()
+	prevOut, prevFlags := log.Writer(), log.Flags()
+	setCore(core)
+	return func() {
+		baseCore.Store(prevBase)
+		zap.ReplaceGlobals(prevZap)
+		slog.SetDefault(prevSlog)
+		// slog.SetDefault 会把标准库 log 的输出指向新的 handler, 恢复为默认 handler 时不会还原
+		log.SetOutput(prevOut)
+		log.SetFlags(prevFlags)
+		atomicLevel.SetLevel(prevLevel)
+	}
+}
+
 func setConsoleEncoder(timeFormat string) zapcore.Encoder {
 	ec := setEncoderConf(timeFormat)
 	ec.EncodeLevel = zapcore.CapitalColorLevelEncoder // 终端输出 日志级别有颜色
@@ -63,15 +111,6 @@ func setEncoderConf(timeFmt string) zapcore.EncoderConfig {
 	return ec
 }
 
-func setLoggerWriter(conf Config) zapcore.WriteSyncer {
-	fName := conf.makeFilename()
-	return zapcore.AddSync(
-		&lumberjack.Logger{
-			Filename:   fName,                 // 要写入的日志文件
-			MaxSize:    int(conf.FileMaxSize), // 日志文件的大小（M）
-			MaxAge:     int(conf.FileMaxAge),  // 存留天数
-			MaxBackups: 1,                     // 备份数量
-			Compress:   conf.FileCompress,     // 压缩
-			LocalTime:  true,                  // 默认 UTC 时间
-		})
+func setLoggerWriter(conf Config) *rotator {
+	return newRotator(conf) // 按时间和大小切割, 见 rotate.go
 }
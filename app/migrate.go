@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"github.com/bobacgo/kit/app/db/migrate"
+)
+
+// WithMigrations 启动前执行数据库迁移 (embed.FS), dir 为 fsys 中的迁移根目录, 目录结构见 migrate 包
+// 依赖 WithMustDB, 每个数据源 (db 配置的 key) 的迁移加锁执行, 多个实例同时启动时只有一个执行
+// db 配置 dryRun 为 true 时只输出 SQL
+func WithMigrations(fsys fs.FS, dir string) AppOption {
+	return func(o *AppOptions) {
+		o.beforeStart = append(o.beforeStart, func(ctx context.Context) error {
+			fsys, err := migrate.Sub(fsys, dir)
+			if err != nil {
+				return fmt.Errorf("load migrations: %w", err)
+			}
+			keys, err := migrate.Keys(fsys)
+			if err != nil {
+				return fmt.Errorf("load migrations: %w", err)
+			}
+			ctx = context.WithoutCancel(ctx) // 迁移和等待锁的时间可能超过启动超时
+			for _, key := range keys {
+				gdb := o.db.Get(key)
+				if gdb == nil {
+					return fmt.Errorf("migrations for db %q: db not found, use WithMustDB", key)
+				}
+				m, err := migrate.New(gdb, key, fsys)
+				if err != nil {
+					return fmt.Errorf("load migrations for db %q: %w", key, err)
+				}
+				if err := m.Up(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
@@ -3,13 +3,16 @@ package kafka
 import (
 	"context"
 	"fmt"
-	"log/slog"
 	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/bobacgo/kit/app/logger"
 	"github.com/bobacgo/kit/pkg/uid"
+	"go.opentelemetry.io/otel/propagation"
 )
 
+var log = logger.Named("kafka")
+
 type Subscriber struct {
 	*ConsumerInfo
 	Topic string
@@ -26,13 +29,36 @@ const (
 )
 
 // ConsumerInfo 消费者处理器信息
+// MessageHandler 和 Handler 设置一个, 都设置时使用 MessageHandler
 type ConsumerInfo struct {
-	Handler ConsumeHandlerFunc
-	Mode    ConsumerMode
+	Handler        ConsumeHandlerFunc
+	MessageHandler MessageHandlerFunc
+	Mode           ConsumerMode
 }
 
+// ConsumeHandlerFunc 自行读取 claim 的消息, 需要调用 MessageContext 读取上下文日志字段
 type ConsumeHandlerFunc func(sarama.ConsumerGroupSession, sarama.ConsumerGroupClaim) error
 
+// MessageHandlerFunc 处理一条消息, ctx 中包含生产者传递的上下文日志字段 (见 MessageContext)
+// 返回 nil 时提交 offset; 返回错误时结束本次消费, 重新加入消费者组后从未提交的 offset 继续 (至少一次)
+type MessageHandlerFunc func(ctx context.Context, msg *sarama.ConsumerMessage) error
+
+// MessageContext 读取生产者传递的上下文日志字段 (见 logger.WithFields), 使用 Handler 时在处理消息前调用
+//
+//	for msg := range claim.Messages() {
+//		ctx := kafka.MessageContext(session.Context(), msg)
+//		slog.InfoContext(ctx, "consume", "offset", msg.Offset)
+//	}
+func MessageContext(ctx context.Context, msg *sarama.ConsumerMessage) context.Context {
+	carrier := make(propagation.MapCarrier, len(msg.Headers))
+	for _, h := range msg.Headers {
+		if h != nil {
+			carrier[string(h.Key)] = string(h.Value)
+		}
+	}
+	return logger.ExtractFields(ctx, carrier)
+}
+
 type consumerServer struct {
 	subs     map[string]sarama.ConsumerGroup // 每个主题一个消费者组
 	handlers map[string]*ConsumerInfo        // 每个主题的处理器信息
@@ -124,19 +150,19 @@ func (srv *consumerServer) consumeLoop(topics ...string) {
 
 		// 消费消息
 		if err := consumer.Consume(context.Background(), topics, srv); err != nil {
-			slog.Error("[kafka] 消费消息出错", "topics", topics, "error", err)
+			log.Error("[kafka] 消费消息出错", "topics", topics, "error", err)
 		}
 	}
 }
 
 // 实现 sarama.ConsumerGroupHandler 接口
 func (srv *consumerServer) Setup(session sarama.ConsumerGroupSession) error {
-	slog.Info("[kafka] 正在监听:", "topics", session.Claims())
+	log.Info("[kafka] 正在监听:", "topics", session.Claims())
 	return nil
 }
 
 func (srv *consumerServer) Cleanup(session sarama.ConsumerGroupSession) error {
-	slog.Info("[kafka] 取消监听:", "topics", session.Claims(), "member_id", session.MemberID())
+	log.Info("[kafka] 取消监听:", "topics", session.Claims(), "member_id", session.MemberID())
 	return nil
 }
 
@@ -145,5 +171,23 @@ func (srv *consumerServer) ConsumeClaim(session sarama.ConsumerGroupSession, cla
 	if !ok {
 		return fmt.Errorf("未找到主题 %s 的处理器", claim.Topic())
 	}
-	return info.Handler(session, claim)
+	if info.MessageHandler == nil {
+		return info.Handler(session, claim)
+	}
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			ctx := MessageContext(session.Context(), msg)
+			if err := info.MessageHandler(ctx, msg); err != nil {
+				log.ErrorContext(ctx, "[kafka] 处理消息出错", "topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset, "error", err)
+				return err
+			}
+			session.MarkMessage(msg, "")
+		case <-session.Context().Done():
+			return nil
+		}
+	}
 }
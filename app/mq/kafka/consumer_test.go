@@ -0,0 +1,60 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/bobacgo/kit/app/logger"
+)
+
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	msgs chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string                            { return topic }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.msgs }
+
+func TestMessageHandler(t *testing.T) {
+	claim := &fakeClaim{msgs: make(chan *sarama.ConsumerMessage, 3)}
+	for i, rid := range []string{"r1", "r2", "r3"} {
+		claim.msgs <- &sarama.ConsumerMessage{Topic: topic, Offset: int64(i), Headers: []*sarama.RecordHeader{
+			{Key: []byte("x-log-request-id"), Value: []byte(rid)},
+		}}
+	}
+	close(claim.msgs)
+
+	var got []string
+	errStop := errors.New("stop")
+	srv := &consumerServer{handlers: map[string]*ConsumerInfo{topic: {
+		MessageHandler: func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+			got = append(got, logger.FieldValue(ctx, logger.FieldRequestID))
+			if msg.Offset == 1 {
+				return errStop
+			}
+			return nil
+		},
+	}}}
+	session := &fakeSession{ctx: context.Background()}
+	if err := srv.ConsumeClaim(session, claim); !errors.Is(err, errStop) {
+		t.Fatal(err)
+	}
+	// 处理失败的消息不提交
+	if len(got) != 2 || got[0] != "r1" || got[1] != "r2" || len(session.marked) != 1 || session.marked[0] != 0 {
+		t.Fatal(got, session.marked)
+	}
+}
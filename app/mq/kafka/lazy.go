@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	lazyMinBackoff = time.Second
+	lazyMaxBackoff = time.Minute
+)
+
+// LazyProducer 在第一次使用时创建生产者, Kafka 不可用时不影响服务启动
+// 创建失败后在退避间隔内直接返回上次的错误, 不会每次调用都重新连接
+type LazyProducer struct {
+	addrs []string
+	conf  ProducerConfig
+
+	mu      sync.Mutex
+	pub     *ProducerServer
+	err     error         // 上次创建失败的错误
+	backoff time.Duration // 当前退避间隔, 每次失败翻倍
+	retryAt time.Time     // 下次允许创建的时间
+	now     func() time.Time
+}
+
+func NewLazyProducer(addrs []string, conf ProducerConfig) *LazyProducer {
+	return &LazyProducer{addrs: addrs, conf: conf, now: time.Now}
+}
+
+// Config 生产者配置
+func (p *LazyProducer) Config() ProducerConfig {
+	return p.conf
+}
+
+// Get 返回生产者, 未创建时创建
+func (p *LazyProducer) Get() (*ProducerServer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pub != nil {
+		return p.pub, nil
+	}
+	if p.err != nil && p.now().Before(p.retryAt) {
+		return nil, p.err
+	}
+	pub, err := NewProducer(p.addrs, &p.conf)
+	if err != nil {
+		p.backoff = min(max(p.backoff*2, lazyMinBackoff), lazyMaxBackoff)
+		p.err, p.retryAt = err, p.now().Add(p.backoff)
+		return nil, err
+	}
+	p.pub, p.err, p.backoff = pub, nil, 0
+	return p.pub, nil
+}
+
+// Close 关闭已创建的生产者, 之后调用 Get 重新创建
+func (p *LazyProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err, p.backoff = nil, 0
+	if p.pub == nil {
+		return nil
+	}
+	err := p.pub.Close()
+	p.pub = nil
+	return err
+}
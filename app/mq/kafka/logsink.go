@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bobacgo/kit/app/logger"
+	"github.com/mitchellh/mapstructure"
+)
+
+func init() {
+	logger.RegisterSink(logger.SinkKafka, newLogSink)
+}
+
+// logSink 日志 sink, 批量发送到 Kafka topic
+type logSink struct {
+	conf logger.KafkaSinkConfig
+	pub  *LazyProducer
+}
+
+func newLogSink(_ logger.Config, sc logger.SinkConfig) (logger.BatchWriter, error) {
+	if len(sc.Kafka.Addrs) == 0 || sc.Kafka.Topic == "" {
+		return nil, errors.New("kafka sink addrs or topic is empty")
+	}
+	var producer ProducerConfig
+	if err := mapstructure.WeakDecode(sc.Kafka.Producer, &producer); err != nil {
+		return nil, fmt.Errorf("kafka sink producer config: %w", err)
+	}
+	return &logSink{conf: sc.Kafka, pub: NewLazyProducer(sc.Kafka.Addrs, producer)}, nil
+}
+
+func (w *logSink) WriteBatch(entries []logger.SinkEntry) error {
+	pub, err := w.pub.Get()
+	if err != nil {
+		return err
+	}
+	values := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		values = append(values, bytes.TrimRight(e.Data, "\n"))
+	}
+	return pub.SendMessages(context.Background(), w.conf.Topic, values)
+}
+
+func (w *logSink) Close() error {
+	return w.pub.Close()
+}
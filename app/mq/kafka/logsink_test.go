@@ -0,0 +1,26 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/bobacgo/kit/app/logger"
+)
+
+func TestLogSinkProducerConfig(t *testing.T) {
+	w, err := newLogSink(logger.Config{}, logger.SinkConfig{Kafka: logger.KafkaSinkConfig{
+		Addrs: []string{"127.0.0.1:9092"},
+		Topic: "app-logs",
+		Producer: map[string]any{
+			"required_acks": "all",
+			"compression":   "lz4",
+			"retry":         map[string]any{"max": "5", "backoff": "200ms"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := w.(*logSink).pub.Config()
+	if p.RequiredAcks != "all" || p.Compression != CompessionLz4 || p.Retry.Max != 5 || p.Retry.Backoff != "200ms" {
+		t.Errorf("unexpected producer config %+v", p)
+	}
+}
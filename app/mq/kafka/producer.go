@@ -2,9 +2,12 @@ package kafka
 
 import (
 	"context"
+	"maps"
 	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/bobacgo/kit/app/logger"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 type ProducerOpt func(o *ProducerOpts)
@@ -74,7 +77,8 @@ type ProducerServer struct {
 	pub sarama.SyncProducer
 }
 
-func newProducer(adds []string, cfg *ProducerConfig) (*ProducerServer, error) {
+// NewProducer 新建同步生产者
+func NewProducer(adds []string, cfg *ProducerConfig) (*ProducerServer, error) {
 	config := sarama.NewConfig()
 
 	config.Producer.RequiredAcks = cfg.RequiredAcks.ack()
@@ -104,14 +108,13 @@ func newProducer(adds []string, cfg *ProducerConfig) (*ProducerServer, error) {
 }
 
 // SendMessage 发送消息到指定主题
+// ctx 中的上下文日志字段写入 header (见 logger.WithFields), 消费者通过 MessageContext 读取
 func (p *ProducerServer) SendMessage(ctx context.Context, topic string, value []byte, opts ...ProducerOpt) error {
-
-	// TODO ctx to headers
-
 	o := ProducerOpts{}
 	for _, opt := range opts {
 		opt(&o)
 	}
+	o.headers = withLogFields(ctx, o.headers)
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.ByteEncoder(value),
@@ -134,3 +137,33 @@ func (p *ProducerServer) SendMessage(ctx context.Context, topic string, value []
 	_, _, err := p.pub.SendMessage(msg)
 	return err
 }
+
+// SendMessages 批量发送消息到指定主题
+func (p *ProducerServer) SendMessages(ctx context.Context, topic string, values [][]byte) error {
+	headers := ProducerOpts{headers: withLogFields(ctx, nil)}.h()
+	msgs := make([]*sarama.ProducerMessage, 0, len(values))
+	for _, v := range values {
+		msgs = append(msgs, &sarama.ProducerMessage{
+			Topic:   topic,
+			Value:   sarama.ByteEncoder(v),
+			Headers: headers,
+		})
+	}
+	return p.pub.SendMessages(msgs)
+}
+
+// withLogFields 复制 headers 并写入上下文日志字段
+func withLogFields(ctx context.Context, headers map[string]string) map[string]string {
+	if len(logger.Fields(ctx)) == 0 {
+		return headers
+	}
+	out := make(propagation.MapCarrier, len(headers))
+	maps.Copy(out, headers)
+	logger.InjectFields(ctx, out)
+	return out
+}
+
+// Close 关闭生产者
+func (p *ProducerServer) Close() error {
+	return p.pub.Close()
+}
@@ -37,7 +37,7 @@ func New(appName string, config Config, subs ...Subscriber) *Server {
 func (s *Server) Start(ctx context.Context) error {
 	// 初始化生产者
 	var err error
-	if s.producer, err = newProducer(s.config.Addrs, &s.config.Producer); err != nil {
+	if s.producer, err = NewProducer(s.config.Addrs, &s.config.Producer); err != nil {
 		return fmt.Errorf("init producer : %w", err)
 	}
 
@@ -49,6 +49,7 @@ type AppOptions struct {
 	localCache cache.Cache
 	redis      cache.RedisManager
 	db         db.DBManager
+	dbDrivers  []db.DriverOpenFunc // 重新连接时使用
 
 	// hook func
 	beforeStart                       []func(ctx context.Context) error
@@ -157,6 +158,7 @@ func WithMustDB(drivers ...db.DriverOpenFunc) AppOption {
 	return func(o *AppOptions) {
 		o.wg.Go(func() error {
 			var err error
+			o.dbDrivers = drivers
 			dmap := db.DialectorMap(drivers, o.conf.DB)
 			if o.db, err = db.NewDBManager(dmap); err != nil {
 				return fmt.Errorf("init db manager failed: %w", err)
@@ -164,6 +166,12 @@ func WithMustDB(drivers ...db.DriverOpenFunc) AppOption {
 			slog.Info(fmt.Sprintf(initDoneFmt, db.ComponentName))
 			return nil
 		})
+		o.afterStop = append(o.afterStop, func(context.Context, *AppOptions) error {
+			if err := o.db.Close(); err != nil { // 停止副本健康检查并关闭连接
+				slog.Error("[database] close error", "err", err)
+			}
+			return nil
+		})
 	}
 }
 
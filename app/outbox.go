@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bobacgo/kit/app/outbox"
+	"github.com/bobacgo/kit/app/server"
+)
+
+const compOutbox = "outbox"
+
+// WithOutbox 使用发件箱 relay, 将默认数据库 outbox 表中的消息发送到 Kafka (kafka.addrs)
+// 依赖 WithMustDB, 配置见 outbox 节点
+func WithOutbox() AppOption {
+	return WithServer(compOutbox, func(a *AppOptions) server.Server {
+		return &outboxServer{opts: a}
+	})
+}
+
+// outboxServer 数据库在 options 执行完成后才初始化, 启动时再创建 relay
+type outboxServer struct {
+	opts  *AppOptions
+	relay *outbox.Relay
+}
+
+func (s *outboxServer) Start(ctx context.Context) error {
+	gdb := s.opts.DB().Default()
+	if gdb == nil {
+		return errors.New("outbox requires default db, use WithMustDB")
+	}
+	cfg := s.opts.Conf()
+	pub, err := outbox.NewKafkaPublisher(cfg.Kafka.Addrs, cfg.Kafka.Producer)
+	if err != nil {
+		return err
+	}
+	s.relay = outbox.NewRelay(gdb, pub, cfg.Outbox)
+	return s.relay.Start(ctx)
+}
+
+func (s *outboxServer) Stop(ctx context.Context) error {
+	if s.relay == nil {
+		return nil
+	}
+	return s.relay.Stop(ctx)
+}
+
+func (s *outboxServer) Get() any {
+	return s.relay
+}
@@ -0,0 +1,53 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/bobacgo/kit/app/types"
+)
+
+// Config relay 配置
+type Config struct {
+	Interval        types.Duration `mapstructure:"interval" validate:"duration" default:"1s"`                               // 轮询间隔 (本进程提交的消息会立即发送)
+	BatchSize       int            `mapstructure:"batchSize" yaml:"batchSize" validate:"gte=0" default:"100"`               // 每次读取的消息数
+	MaxAttempts     int            `mapstructure:"maxAttempts" yaml:"maxAttempts" validate:"gte=0" default:"10"`            // 发送失败超过次数标记为 failed, 0 不限制
+	Backoff         types.Duration `mapstructure:"backoff" validate:"duration" default:"1s"`                                // 首次重试间隔, 之后指数增长
+	MaxBackoff      types.Duration `mapstructure:"maxBackoff" yaml:"maxBackoff" validate:"duration" default:"5m"`           // 最大重试间隔
+	Retention       types.Duration `mapstructure:"retention" validate:"duration" default:"168h"`                            // 已发送消息的保留时长
+	CleanupInterval types.Duration `mapstructure:"cleanupInterval" yaml:"cleanupInterval" validate:"duration" default:"1h"` // 清理间隔
+}
+
+func (c Config) interval() time.Duration {
+	return durationOr(c.Interval, time.Second)
+}
+
+func (c Config) batchSize() int {
+	if c.BatchSize > 0 {
+		return c.BatchSize
+	}
+	return 100
+}
+
+// backoff 第 attempts 次失败后的重试间隔
+func (c Config) backoff(attempts int) time.Duration {
+	d, limit := durationOr(c.Backoff, time.Second), durationOr(c.MaxBackoff, 5*time.Minute)
+	for i := 1; i < attempts && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+func (c Config) retention() time.Duration {
+	return durationOr(c.Retention, 7*24*time.Hour)
+}
+
+func (c Config) cleanupInterval() time.Duration {
+	return durationOr(c.CleanupInterval, time.Hour)
+}
+
+func durationOr(d types.Duration, def time.Duration) time.Duration {
+	if v := d.TimeDuration(); v > 0 {
+		return v
+	}
+	return def
+}
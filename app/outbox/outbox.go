@@ -0,0 +1,129 @@
+// Package outbox 事务发件箱, 保证数据库写入与 Kafka 消息的一致性
+/*
+	业务数据和消息在同一个事务中写入数据库, 由 Relay 读取并发送到 Kafka, 进程在两步之间崩溃也不会丢失消息
+
+	err := dbs.Transaction(ctx, func(ctx context.Context) error {
+		if err := db.FromContext(ctx, dbs).Create(&order).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(ctx, dbs.Default(), &outbox.Message{Topic: "order.created", Key: order.ID, Payload: data})
+	})
+
+	1.至少发送一次: 每条消息带有幂等键 (header x-idempotency-key), 消费者据此去重
+	2.相同 Key (聚合 ID) 的消息按写入顺序发送, 前一条发送失败时后面的等待重试; 超过最大重试次数标记为 failed 后不再阻塞
+	  退避中的消息只阻塞相同 Key 的消息, 不影响其他 Key
+	3.本进程提交的消息会立即通知同一数据库的 Relay 发送, 其他情况按 interval 轮询
+	4.已发送的消息保留 retention 后删除, failed 的消息保留, 需要人工处理
+	5.多个实例同时运行 Relay 会重复发送 (依赖幂等键去重), 建议只在一个实例中启用
+	6.outbox 表是系统表, 不按租户隔离 (见 db 包的多租户说明), 租户 ID 随日志字段写入 header
+*/
+package outbox
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/bobacgo/kit/app/db"
+	"github.com/bobacgo/kit/app/logger"
+	"github.com/bobacgo/kit/app/tenant"
+	"github.com/bobacgo/kit/pkg/uid"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/gorm"
+)
+
+// HeaderIdempotencyKey 消息幂等键的 header
+const HeaderIdempotencyKey = "x-idempotency-key"
+
+// Status 消息状态
+type Status string
+
+const (
+	StatusPending Status = "pending" // 待发送
+	StatusSent    Status = "sent"    // 已发送
+	StatusFailed  Status = "failed"  // 超过最大重试次数
+)
+
+// Message 发件箱消息
+type Message struct {
+	ID             uint64            `gorm:"primaryKey;autoIncrement"`
+	Topic          string            `gorm:"size:255;not null"`
+	Key            string            `gorm:"size:255;index"` // 聚合 ID, 作为 Kafka 消息 key, 相同 key 的消息保证顺序
+	Payload        []byte            `gorm:"not null"`
+	Headers        map[string]string `gorm:"serializer:json;type:text"`
+	IdempotencyKey string            `gorm:"size:64;uniqueIndex"` // 为空时自动生成
+	Status         Status            `gorm:"size:16;index"`
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string `gorm:"type:text"`
+	CreatedAt      time.Time
+	SentAt         *time.Time `gorm:"index"`
+}
+
+func (Message) TableName() string {
+	return "outbox"
+}
+
+// Migrate 创建发件箱表
+func Migrate(db *gorm.DB) error {
+	return db.WithContext(tenant.Skip(db.Statement.Context)).AutoMigrate(&Message{})
+}
+
+var (
+	notifyMu  sync.Mutex
+	notifiers = make(map[*gorm.Config]chan struct{})
+)
+
+// notifier 通知本进程中同一数据库的 Relay 有新消息提交
+// key 为 gorm.Open 创建的 *gorm.Config, 同一个连接的会话 (WithContext、事务) 共用
+func notifier(gdb *gorm.DB) chan struct{} {
+	notifyMu.Lock()
+	defer notifyMu.Unlock()
+	ch, ok := notifiers[gdb.Config]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		notifiers[gdb.Config] = ch
+	}
+	return ch
+}
+
+// Publish 写入消息, ctx 中有 db 的事务时 (见 db.Transaction) 在事务中写入, 提交后通知 Relay 发送
+// ctx 中的上下文日志字段写入 header (见 logger.WithFields)
+func Publish(ctx context.Context, gdb *gorm.DB, msgs ...*Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for _, m := range msgs {
+		if m.IdempotencyKey == "" {
+			m.IdempotencyKey = uid.UUID()
+		}
+		if len(logger.Fields(ctx)) > 0 {
+			headers := make(propagation.MapCarrier, len(m.Headers))
+			maps.Copy(headers, m.Headers)
+			logger.InjectFields(ctx, headers)
+			m.Headers = headers
+		}
+		m.ID, m.Status, m.Attempts, m.NextAttemptAt = 0, StatusPending, 0, now
+	}
+	if err := db.Tx(tenant.Skip(ctx), gdb).Create(msgs).Error; err != nil {
+		return err
+	}
+	notify := notifier(gdb)
+	db.AfterCommit(ctx, func(context.Context) {
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	})
+	return nil
+}
+
+// headers 发送到 Kafka 的 header
+func (m *Message) headers() map[string]string {
+	headers := make(map[string]string, len(m.Headers)+1)
+	maps.Copy(headers, m.Headers)
+	headers[HeaderIdempotencyKey] = m.IdempotencyKey
+	return headers
+}
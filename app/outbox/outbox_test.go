@@ -0,0 +1,211 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bobacgo/kit/app/db"
+	"github.com/bobacgo/kit/app/logger"
+	"github.com/bobacgo/kit/app/tenant"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRelay(t *testing.T) {
+	gdb := newTestDB(t)
+	ctx := context.Background()
+	pub := &MemoryPublisher{}
+	r := NewRelay(gdb, pub, Config{MaxAttempts: 2, Backoff: "1m"})
+
+	// 回滚的事务不写入消息
+	errRollback := errors.New("rollback")
+	err := db.Transaction(ctx, gdb, func(ctx context.Context) error {
+		if err := Publish(ctx, gdb, &Message{Topic: "order", Key: "a", Payload: []byte("x")}); err != nil {
+			t.Fatal(err)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+	err = db.Transaction(ctx, gdb, func(ctx context.Context) error {
+		return Publish(ctx, gdb,
+			&Message{Topic: "order", Key: "a", Payload: []byte("a1")},
+			&Message{Topic: "order", Key: "a", Payload: []byte("a2")},
+			&Message{Topic: "order", Key: "b", Payload: []byte("b1")},
+		)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a1 发送失败, a2 等待, b1 发送
+	pub.Fail = func(m *Message) error {
+		if string(m.Payload) == "a1" {
+			return errors.New("broker down")
+		}
+		return nil
+	}
+	if fetched, sent, err := r.RelayOnce(ctx); err != nil || fetched != 3 || sent != 1 {
+		t.Fatalf("relay once: %d %d %v", fetched, sent, err)
+	}
+	if got := payloads(pub); len(got) != 1 || got[0] != "b1" {
+		t.Fatalf("unexpected sent %v", got)
+	}
+	// 未到重试时间
+	pub.Fail = nil
+	if _, sent, _ := r.RelayOnce(ctx); sent != 0 {
+		t.Fatalf("expected backoff, sent %d", sent)
+	}
+	r.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, sent, err := r.RelayOnce(ctx); err != nil || sent != 2 {
+		t.Fatalf("retry: %d %v", sent, err)
+	}
+	if got := payloads(pub); len(got) != 3 || got[1] != "a1" || got[2] != "a2" {
+		t.Fatalf("unexpected order %v", got)
+	}
+	if msgs := pub.Messages(); msgs[0].Headers[HeaderIdempotencyKey] == "" || msgs[0].Headers[HeaderIdempotencyKey] == msgs[1].Headers[HeaderIdempotencyKey] {
+		t.Errorf("unexpected idempotency keys %v %v", msgs[0].Headers, msgs[1].Headers)
+	}
+
+	// 超过最大重试次数
+	_ = Publish(ctx, gdb, &Message{Topic: "order", Key: "c", Payload: []byte("c1")})
+	pub.Fail = func(*Message) error { return errors.New("rejected") }
+	for i := range 2 {
+		r.now = func() time.Time { return time.Now().Add(time.Duration(i+2) * time.Hour) }
+		_, _, _ = r.RelayOnce(ctx)
+	}
+	var failed Message
+	gdb.Where("key = ?", "c").First(&failed)
+	if failed.Status != StatusFailed || failed.Attempts != 2 || failed.LastError != "rejected" {
+		t.Errorf("unexpected failed message %+v", failed)
+	}
+
+	// 清理已发送的消息
+	r.now = func() time.Time { return time.Now().Add(8 * 24 * time.Hour) }
+	if n, err := r.Cleanup(ctx); err != nil || n != 3 {
+		t.Errorf("cleanup: %d %v", n, err)
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	gdb := newTestDB(t)
+	ctx := context.Background()
+	pub := &MemoryPublisher{Fail: func(m *Message) error {
+		if m.Topic == "down" {
+			return errors.New("topic down")
+		}
+		return nil
+	}}
+	r := NewRelay(gdb, pub, Config{BatchSize: 2, Backoff: "1m"})
+	err := Publish(ctx, gdb,
+		&Message{Topic: "down", Key: "a", Payload: []byte("a1")},
+		&Message{Topic: "down", Key: "b", Payload: []byte("b1")},
+		&Message{Topic: "down", Key: "a", Payload: []byte("a2")},
+		&Message{Topic: "up", Key: "c", Payload: []byte("c1")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一批都进入退避, 之后的批次跳过退避中的消息和排在其后的相同 key 的消息
+	if fetched, sent, err := r.RelayOnce(ctx); err != nil || fetched != 2 || sent != 0 {
+		t.Fatalf("relay once: %d %d %v", fetched, sent, err)
+	}
+	if fetched, sent, err := r.RelayOnce(ctx); err != nil || fetched != 1 || sent != 1 {
+		t.Fatalf("backoff batch should not block other keys: %d %d %v", fetched, sent, err)
+	}
+	if got := payloads(pub); len(got) != 1 || got[0] != "c1" {
+		t.Fatalf("unexpected sent %v", got)
+	}
+}
+
+func TestRelayServer(t *testing.T) {
+	// 每个数据库一个 Relay, 提交后只通知同一数据库的 Relay
+	dbs := []*gorm.DB{newTestDB(t), newTestDB(t)}
+	pubs := []*MemoryPublisher{{}, {}}
+	for i, gdb := range dbs {
+		r := NewRelay(gdb, pubs[i], Config{Interval: "1h"})
+		if err := r.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		defer r.Stop(context.Background())
+	}
+
+	// 提交后立即发送, 不等待轮询 (先提交到后启动的 Relay 的数据库)
+	for _, i := range []int{1, 0} {
+		err := db.Transaction(context.Background(), dbs[i], func(ctx context.Context) error {
+			return Publish(ctx, dbs[i], &Message{Topic: "order", Payload: []byte("x")})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for len(pubs[i].Messages()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if len(pubs[i].Messages()) != 1 {
+			t.Fatalf("message not relayed by relay %d", i)
+		}
+	}
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	gdb, err := db.NewDB(sqlite.Open(filepath.Join(t.TempDir(), "outbox.db")), db.Config{Driver: "sqlite"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(gdb); err != nil {
+		t.Fatal(err)
+	}
+	return gdb
+}
+
+// 开启 schema 租户模式时发件箱表不按租户隔离
+func TestTenantSchema(t *testing.T) {
+	m, err := db.NewDBManager(map[string]db.DialectorConfig{
+		"default": {Dialector: sqlite.Open(filepath.Join(t.TempDir(), "outbox.db")), Config: db.Config{Driver: "sqlite", Tenant: db.TenantConfig{Mode: db.TenantSchema}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	gdb := m.Default()
+	if err := Migrate(gdb); err != nil {
+		t.Fatal(err)
+	}
+	t1, err := tenant.WithID(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Transaction(t1, gdb, func(ctx context.Context) error {
+		return Publish(ctx, gdb, &Message{Topic: "order", Key: "a", Payload: []byte("a1")})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub := &MemoryPublisher{}
+	r := NewRelay(gdb, pub, Config{Retention: "1ns"})
+	if fetched, sent, err := r.RelayOnce(context.Background()); err != nil || fetched != 1 || sent != 1 {
+		t.Fatalf("relay once: %d %d %v", fetched, sent, err)
+	}
+	if got := pub.Messages(); len(got) != 1 || got[0].Headers[logger.FieldsPrefix+logger.FieldTenant] != "t1" {
+		t.Fatalf("unexpected sent %v", got)
+	}
+	r.now = func() time.Time { return time.Now().Add(time.Second) }
+	if n, err := r.Cleanup(context.Background()); err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+}
+
+func payloads(p *MemoryPublisher) []string {
+	var out []string
+	for _, m := range p.Messages() {
+		out = append(out, string(m.Payload))
+	}
+	return out
+}
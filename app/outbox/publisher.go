@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/bobacgo/kit/app/mq/kafka"
+)
+
+// Publisher 发送消息
+type Publisher interface {
+	Publish(ctx context.Context, m *Message) error
+}
+
+// KafkaPublisher 发送到 Kafka
+type KafkaPublisher struct {
+	pub *kafka.LazyProducer
+}
+
+// NewKafkaPublisher 未指定 RequiredAcks 时需要所有副本确认
+func NewKafkaPublisher(addrs []string, conf kafka.ProducerConfig) (*KafkaPublisher, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("outbox kafka addrs is empty")
+	}
+	if conf.RequiredAcks == "" {
+		conf.RequiredAcks = kafka.RequiredAcksAll
+	}
+	return &KafkaPublisher{pub: kafka.NewLazyProducer(addrs, conf)}, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, m *Message) error {
+	pub, err := p.pub.Get()
+	if err != nil {
+		return err
+	}
+	opts := []kafka.ProducerOpt{kafka.WithHeaders(m.headers())}
+	if m.Key != "" {
+		opts = append(opts, kafka.WithKey(m.Key))
+	}
+	return pub.SendMessage(ctx, m.Topic, m.Payload, opts...)
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.pub.Close()
+}
+
+// MemoryPublisher 内存中保存发送的消息, 用于测试
+type MemoryPublisher struct {
+	// Fail 返回错误时模拟发送失败
+	Fail func(m *Message) error
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, m *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Fail != nil {
+		if err := p.Fail(m); err != nil {
+			return err
+		}
+	}
+	msg := *m
+	msg.Headers = m.headers()
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+// Messages 已发送的消息
+func (p *MemoryPublisher) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.messages...)
+}
@@ -0,0 +1,204 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bobacgo/kit/app/db"
+	"github.com/bobacgo/kit/app/tenant"
+	"gorm.io/gorm"
+)
+
+const cleanupBatch = 1000
+
+// Relay 读取发件箱并发送消息, 实现 server.Server
+type Relay struct {
+	db   *gorm.DB
+	pub  Publisher
+	conf Config
+	now  func() time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewRelay(gdb *gorm.DB, pub Publisher, conf Config) *Relay {
+	return &Relay{
+		db:   gdb,
+		pub:  pub,
+		conf: conf,
+		now:  time.Now,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start 创建发件箱表并开始发送
+func (r *Relay) Start(ctx context.Context) error {
+	if err := Migrate(r.db.WithContext(ctx)); err != nil {
+		return fmt.Errorf("outbox migrate: %w", err)
+	}
+	go r.run()
+	return nil
+}
+
+// Stop 等待正在发送的批次结束
+func (r *Relay) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if c, ok := r.pub.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (r *Relay) Get() any {
+	return r
+}
+
+func (r *Relay) run() {
+	defer close(r.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	notify := notifier(r.db)
+	poll := time.NewTicker(r.conf.interval())
+	defer poll.Stop()
+	cleanup := time.NewTicker(r.conf.cleanupInterval())
+	defer cleanup.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-cleanup.C:
+			if n, err := r.Cleanup(ctx); err != nil {
+				slog.Error("[outbox] cleanup error", "err", err)
+			} else if n > 0 {
+				slog.Info("[outbox] cleanup sent messages", "count", n)
+			}
+		case <-poll.C:
+			r.drain(ctx)
+		case <-notify:
+			r.drain(ctx)
+		}
+	}
+}
+
+// drain 发送到没有可发送的消息为止
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		fetched, sent, err := r.RelayOnce(ctx)
+		if err != nil {
+			slog.Error("[outbox] relay error", "err", err)
+			return
+		}
+		if fetched < r.conf.batchSize() || sent == 0 {
+			return
+		}
+	}
+}
+
+// RelayOnce 读取一批待发送的消息并发送, 返回读取和发送成功的数量
+func (r *Relay) RelayOnce(ctx context.Context) (fetched, sent int, err error) {
+	ctx = tenant.Skip(db.ForcePrimary(ctx)) // 只读副本有复制延迟, 会读到已发送的消息
+	now := r.now()
+	var msgs []Message
+	err = r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+		Where(r.waitingEarlier(), StatusPending, now).
+		Order("id").Limit(r.conf.batchSize()).Find(&msgs).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	blocked := make(map[string]struct{}) // 同一批次中前面的消息发送失败, 保证相同 key 的顺序
+	for i := range msgs {
+		m := &msgs[i]
+		if _, ok := blocked[m.Key]; ok && m.Key != "" {
+			continue
+		}
+		if err := r.pub.Publish(ctx, m); err != nil {
+			blocked[m.Key] = struct{}{}
+			if err := r.fail(ctx, m, err); err != nil {
+				return len(msgs), sent, err
+			}
+			continue
+		}
+		err = r.db.WithContext(ctx).Model(m).Updates(map[string]any{
+			"status":   StatusSent,
+			"attempts": m.Attempts + 1,
+			"sent_at":  now,
+		}).Error
+		if err != nil { // 下次会重复发送, 由幂等键去重
+			return len(msgs), sent, err
+		}
+		sent++
+	}
+	return len(msgs), sent, nil
+}
+
+// waitingEarlier 过滤相同 key 有更早的消息在等待重试的消息
+// 退避中的消息不读取, 避免一整批都在退避时阻塞后面其他 key 的消息
+func (r *Relay) waitingEarlier() string {
+	q := r.db.Statement.Quote
+	table, key := q(Message{}.TableName()), q("key")
+	return fmt.Sprintf("(%[2]s = '' OR NOT EXISTS (SELECT 1 FROM %[1]s %[3]s WHERE %[3]s.%[2]s = %[1]s.%[2]s "+
+		"AND %[3]s.status = ? AND %[3]s.next_attempt_at > ? AND %[3]s.id < %[1]s.id))", table, key, q("earlier"))
+}
+
+// fail 记录发送失败, 超过最大重试次数时标记为 failed
+func (r *Relay) fail(ctx context.Context, m *Message, cause error) error {
+	attempts := m.Attempts + 1
+	updates := map[string]any{
+		"attempts":        attempts,
+		"last_error":      cause.Error(),
+		"next_attempt_at": r.now().Add(r.conf.backoff(attempts)),
+	}
+	if r.conf.MaxAttempts > 0 && attempts >= r.conf.MaxAttempts {
+		updates["status"] = StatusFailed
+		slog.Error("[outbox] message failed", "id", m.ID, "topic", m.Topic, "key", m.Key, "attempts", attempts, "err", cause)
+	} else {
+		slog.Warn("[outbox] publish error, will retry", "id", m.ID, "topic", m.Topic, "key", m.Key, "attempts", attempts, "err", cause)
+	}
+	return r.db.WithContext(ctx).Model(m).Updates(updates).Error
+}
+
+// Cleanup 删除超过保留时长的已发送消息, 返回删除的数量
+func (r *Relay) Cleanup(ctx context.Context) (int64, error) {
+	ctx = tenant.Skip(ctx)
+	before := r.now().Add(-r.conf.retention())
+	var total int64
+	for {
+		var ids []uint64
+		err := r.db.WithContext(ctx).Model(&Message{}).
+			Where("status = ? AND sent_at < ?", StatusSent, before).
+			Limit(cleanupBatch).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return total, err
+		}
+		res := r.db.WithContext(ctx).Delete(&Message{}, ids)
+		if res.Error != nil {
+			return total, res.Error
+		}
+		total += res.RowsAffected
+		if len(ids) < cleanupBatch {
+			return total, nil
+		}
+	}
+}
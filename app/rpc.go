@@ -8,8 +8,8 @@ import (
 
 	otelgrpc "go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 
+	"github.com/bobacgo/kit/app/server/admin"
 	"github.com/bobacgo/kit/app/server/rpc/interceptor"
-	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -44,7 +44,10 @@ func (srv *RpcServer) Start(ctx context.Context) error {
 	srv.server = grpc.NewServer(srv.grpcServerOpts...)
 
 	healthgrpc.RegisterHealthServer(srv.server, health.NewServer()) // 注册健康检查服务
-	if srv.RegistryFn != nil {                                      // 注册业务接口
+	if opts, ok := adminOptions(cfg); ok {                          // 注册运维管理服务
+		admin.RegisterGrpc(srv.server, opts...)
+	}
+	if srv.RegistryFn != nil { // 注册业务接口
 		srv.RegistryFn(srv.server, srv.Opts)
 	}
 
@@ -75,13 +78,16 @@ func (srv *RpcServer) Stop(ctx context.Context) error {
 }
 
 func (srv *RpcServer) defaultInterceptor() {
+	access := srv.Opts.Conf().Logger.Access
 	defaultOpts := append(srv.grpcServerOpts, grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor( // 单向拦截器
-			logging.UnaryServerInterceptor(interceptor.Logger(), logging.WithFieldsFromContext(interceptor.LogTraceID)),
+			interceptor.LogFields(srv.Opts.AppID()), // 上下文日志字段
+			interceptor.AccessLog(access),           // 访问日志
 			recovery.UnaryServerInterceptor(recovery.WithRecoveryHandler(interceptor.Recovery)),
 			interceptor.ValidateParam(), // 参数校验
 		), grpc.ChainStreamInterceptor( // 流式拦截器
-			logging.StreamServerInterceptor(interceptor.Logger(), logging.WithFieldsFromContext(interceptor.LogTraceID)),
+			interceptor.StreamLogFields(srv.Opts.AppID()), // 上下文日志字段
+			interceptor.StreamAccessLog(access),           // 访问日志
 			recovery.StreamServerInterceptor(recovery.WithRecoveryHandler(interceptor.Recovery)),
 			// interceptor.ValidateStreamParam(), // 对接收到的消息进行校验
 		))
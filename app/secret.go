@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bobacgo/kit/app/conf"
+	"github.com/bobacgo/kit/app/db"
+	"github.com/bobacgo/kit/app/secret"
+)
+
+const compSecret = "secret"
+
+// useSecrets 配置中使用了密钥引用时, 启动定时刷新
+// 密钥轮换后重新加载配置, 重新连接受影响的数据库和 Redis, 并更新字段加密的密钥
+func (o *AppOptions) useSecrets(reload func() error) {
+	if len(secret.Default().Refs()) == 0 {
+		return
+	}
+	components[compSecret] = struct{}{}
+	o.servers[compSecret] = secret.Default()
+
+	secret.OnChange(func(refs []string) {
+		old := conf.GetBasicConf()
+		err := reload()
+		conf.RecordReload(context.Background(), strings.Join(refs, ","), compSecret, err)
+		if err != nil {
+			slog.Error("[secret] reload config error", "refs", refs, "err", err)
+			return
+		}
+		o.reconnect(old, conf.GetBasicConf())
+		reloadKeyring()
+	})
+}
+
+// reconnect 连接信息 (主库或只读副本) 有变化的实例重新连接
+func (o *AppOptions) reconnect(old, cur conf.Basic) {
+	for k, c := range cur.DB {
+		oc := old.DB[k]
+		if _, ok := o.db[k]; !ok || (oc.Source == c.Source && slices.Equal(oc.Replicas, c.Replicas)) {
+			continue
+		}
+		dmap := db.DialectorMap(o.dbDrivers, map[string]db.Config{k: c})
+		d, ok := dmap[k]
+		if !ok {
+			continue
+		}
+		if err := o.db.Reconnect(k, d); err != nil {
+			slog.Error("[secret] db reconnect error", "key", k, "err", err)
+		}
+	}
+	for k, c := range cur.Redis {
+		oc := old.Redis[k]
+		if _, ok := o.redis[k]; !ok || (oc.Username == c.Username && oc.Password == c.Password) {
+			continue
+		}
+		if err := o.redis.UpdateCredentials(k, c); err != nil {
+			slog.Error("[secret] redis update credentials error", "key", k, "err", err)
+		}
+	}
+}
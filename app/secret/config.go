@@ -0,0 +1,27 @@
+package secret
+
+import "github.com/bobacgo/kit/app/types"
+
+type Config struct {
+	RefreshInterval types.Duration `mapstructure:"refreshInterval" yaml:"refreshInterval" validate:"duration" default:"5m"` // 定时刷新间隔
+	Dir             string         `mapstructure:"dir" default:"/run/secrets"`                                              // file provider 的根目录
+	Vault           *VaultConfig   `mapstructure:"vault"`                                                                   // 配置后注册 vault provider
+}
+
+type VaultConfig struct {
+	Addr      string         `mapstructure:"addr" validate:"omitempty,url"` // http://127.0.0.1:8200
+	Token     string         `mapstructure:"token" mask:""`                 // 推荐使用 ${VAULT_TOKEN}
+	Namespace string         `mapstructure:"namespace"`                     // 企业版命名空间
+	Timeout   types.Duration `mapstructure:"timeout" validate:"duration" default:"5s"`
+}
+
+// Configure 按配置设置默认管理器
+func Configure(cfg Config) {
+	std.SetInterval(cfg.RefreshInterval.TimeDuration())
+	if cfg.Dir != "" {
+		std.Register(ProviderFile, NewFileProvider(cfg.Dir))
+	}
+	if cfg.Vault != nil && cfg.Vault.Addr != "" {
+		std.Register(ProviderVault, NewVaultProvider(*cfg.Vault))
+	}
+}
@@ -0,0 +1,24 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+const ProviderEnv = "env"
+
+// EnvProvider 从环境变量读取密钥
+type EnvProvider struct{}
+
+func NewEnvProvider() *EnvProvider {
+	return &EnvProvider{}
+}
+
+func (p *EnvProvider) Get(_ context.Context, name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("secret: env %s not found", name)
+	}
+	return v, nil
+}
@@ -0,0 +1,39 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ProviderFile = "file"
+	defaultDir   = "/run/secrets"
+)
+
+// FileProvider 从文件读取密钥 (Docker/K8s secret 挂载)
+// 文件内容末尾的换行会被去除
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+// Get 相对路径基于 dir, 且不允许跳出 dir
+func (p *FileProvider) Get(_ context.Context, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		if !filepath.IsLocal(path) {
+			return "", fmt.Errorf("secret: file %s is outside %s", path, p.dir)
+		}
+		path = filepath.Join(p.dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("secret: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
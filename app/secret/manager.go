@@ -0,0 +1,266 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultInterval = 5 * time.Minute
+
+// Manager 管理 SecretProvider, 缓存已解析的密钥并定时刷新
+// 实现了 server.Server, 由 app 在使用了密钥引用时启动
+type Manager struct {
+	mu        sync.RWMutex
+	providers map[string]SecretProvider
+	values    map[string]string // ref -> 当前值
+	listeners []func(refs []string)
+	interval  time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		providers: make(map[string]SecretProvider),
+		values:    make(map[string]string),
+		interval:  defaultInterval,
+	}
+}
+
+// Register 注册 SecretProvider, 同名覆盖
+func (m *Manager) Register(name string, p SecretProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.providers[name] = p
+}
+
+// SetInterval 设置刷新间隔, 需要在 Start 之前调用
+func (m *Manager) SetInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.interval = d
+}
+
+// OnChange 密钥刷新后值有变化时回调 (参数为变化的引用)
+func (m *Manager) OnChange(fn func(refs []string)) {
+	if fn == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// Refs 已解析的密钥引用
+func (m *Manager) Refs() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	refs := make([]string, 0, len(m.values))
+	for ref := range m.values {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// Resolve 解析密钥引用, 非引用原样返回
+// 已解析过的引用直接返回缓存的值, 由 Refresh 负责更新
+func (m *Manager) Resolve(ctx context.Context, ref string) (string, error) {
+	if !IsRef(ref) {
+		return ref, nil
+	}
+	m.mu.RLock()
+	v, ok := m.values[ref]
+	m.mu.RUnlock()
+	if ok {
+		return v, nil
+	}
+	v, err := m.fetch(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	m.values[ref] = v
+	m.mu.Unlock()
+	return v, nil
+}
+
+func (m *Manager) fetch(ctx context.Context, ref string) (string, error) {
+	name, path, err := ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	m.mu.RLock()
+	p, ok := m.providers[name]
+	m.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("secret: provider %s not registered", name)
+	}
+	v, err := p.Get(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", ref, err)
+	}
+	return v, nil
+}
+
+// Refresh 重新获取所有已解析的密钥, 返回值有变化的引用并通知回调
+// 获取失败的引用保留旧值
+func (m *Manager) Refresh(ctx context.Context) ([]string, error) {
+	var (
+		changed []string
+		errs    []error
+	)
+	for _, ref := range m.Refs() {
+		v, err := m.fetch(ctx, ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.mu.Lock()
+		if m.values[ref] != v {
+			m.values[ref] = v
+			changed = append(changed, ref)
+		}
+		m.mu.Unlock()
+	}
+	if len(changed) > 0 {
+		m.mu.RLock()
+		listeners := m.listeners
+		m.mu.RUnlock()
+		for _, fn := range listeners {
+			fn(changed)
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// Start 启动定时刷新
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		return nil
+	}
+	ctx, m.cancel = context.WithCancel(context.Background())
+	m.done = make(chan struct{})
+	go m.loop(ctx, m.interval, m.done)
+	return nil
+}
+
+func (m *Manager) loop(ctx context.Context, interval time.Duration, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := m.Refresh(ctx)
+			if err != nil {
+				slog.Error("[secret] refresh error", "err", err)
+			}
+			if len(changed) > 0 {
+				slog.Warn("[secret] secret rotated", "refs", changed)
+			}
+		}
+	}
+}
+
+// Stop 停止定时刷新
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+	m.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (m *Manager) Get() any {
+	return m
+}
+
+// ResolveStruct 原地解析 ptr 中所有值为密钥引用的字符串 (包括 map、slice 中的元素)
+func (m *Manager) ResolveStruct(ctx context.Context, ptr any) error {
+	val := reflect.ValueOf(ptr)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return errors.New("secret: ResolveStruct requires a non-nil pointer")
+	}
+	var errs []error
+	m.walk(ctx, val.Elem(), "", &errs)
+	return errors.Join(errs...)
+}
+
+// walk 递归处理 v (必须可寻址)
+func (m *Manager) walk(ctx context.Context, v reflect.Value, path string, errs *[]error) {
+	switch v.Kind() {
+	case reflect.String:
+		if !IsRef(v.String()) {
+			return
+		}
+		s, err := m.Resolve(ctx, v.String())
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+			return
+		}
+		v.SetString(s)
+	case reflect.Struct:
+		typ := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.CanSet() {
+				m.walk(ctx, field, joinPath(path, typ.Field(i).Name), errs)
+			}
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			m.walk(ctx, v.Elem(), path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			m.walk(ctx, v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// map 的值不可寻址, 复制出来处理后再写回
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			m.walk(ctx, elem, fmt.Sprintf("%s[%v]", path, iter.Key()), errs)
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		m.walk(ctx, elem, path, errs)
+		v.Set(elem)
+	}
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,84 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// 密钥引用
+/*
+	配置值为 secret://{provider}/{path} 时, 加载配置时会通过对应的 SecretProvider 解析为真实的值
+	只支持整个配置值为引用 (部分替换请使用环境变量插值 ${VAR})
+
+		secret://env/DB_PASSWORD                       环境变量 DB_PASSWORD
+		secret://file/db_dsn                           文件 {dir}/db_dsn (默认 /run/secrets, 即 Docker/K8s secret 挂载目录)
+		secret://file//etc/app/db_dsn                  绝对路径
+		secret://vault/secret/data/app/db#password     Vault KV 的 password 字段 (不指定字段默认为 value)
+
+	已解析的值会定时刷新, 变化时通知 OnChange 注册的回调 (e.g. 数据库、Redis 重新连接)
+*/
+
+const Scheme = "secret://"
+
+// SecretProvider 密钥提供者
+type SecretProvider interface {
+	// Get 获取 path 对应的密钥
+	Get(ctx context.Context, path string) (string, error)
+}
+
+// ProviderFunc 函数形式的 SecretProvider
+type ProviderFunc func(ctx context.Context, path string) (string, error)
+
+func (f ProviderFunc) Get(ctx context.Context, path string) (string, error) {
+	return f(ctx, path)
+}
+
+// IsRef 是否为密钥引用
+func IsRef(s string) bool {
+	return strings.HasPrefix(s, Scheme)
+}
+
+// ParseRef 解析密钥引用 secret://{provider}/{path}
+func ParseRef(ref string) (provider, path string, err error) {
+	if !IsRef(ref) {
+		return "", "", fmt.Errorf("secret: invalid ref %q, want %s{provider}/{path}", ref, Scheme)
+	}
+	provider, path, _ = strings.Cut(strings.TrimPrefix(ref, Scheme), "/")
+	if provider == "" || path == "" {
+		return "", "", fmt.Errorf("secret: invalid ref %q, want %s{provider}/{path}", ref, Scheme)
+	}
+	return provider, path, nil
+}
+
+var std = NewManager()
+
+func init() {
+	std.Register(ProviderEnv, NewEnvProvider())
+	std.Register(ProviderFile, NewFileProvider(defaultDir))
+}
+
+// Default 默认的密钥管理器 (已注册 env、file)
+func Default() *Manager {
+	return std
+}
+
+// Register 向默认管理器注册 SecretProvider, 同名覆盖
+func Register(name string, p SecretProvider) {
+	std.Register(name, p)
+}
+
+// Resolve 使用默认管理器解析密钥引用, 非引用原样返回
+func Resolve(ctx context.Context, ref string) (string, error) {
+	return std.Resolve(ctx, ref)
+}
+
+// ResolveStruct 使用默认管理器解析 ptr 中所有的密钥引用
+func ResolveStruct(ctx context.Context, ptr any) error {
+	return std.ResolveStruct(ctx, ptr)
+}
+
+// OnChange 密钥刷新后值有变化时回调 (参数为变化的引用)
+func OnChange(fn func(refs []string)) {
+	std.OnChange(fn)
+}
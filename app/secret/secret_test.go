@@ -0,0 +1,141 @@
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func vaultStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/app/db": // KV v2
+			w.Write([]byte(`{"data":{"data":{"password":"v2-pwd","port":3306},"metadata":{"version":3}}}`))
+		case "/v1/kv/app/redis": // KV v1
+			w.Write([]byte(`{"data":{"value":"v1-pwd"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVaultProvider(t *testing.T) {
+	srv := vaultStub(t)
+	p := NewVaultProvider(VaultConfig{Addr: srv.URL, Token: "root", Timeout: "1s"})
+	ctx := context.Background()
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "secret/data/app/db#password", want: "v2-pwd"},
+		{path: "secret/data/app/db#port", want: "3306"},
+		{path: "kv/app/redis", want: "v1-pwd"},
+		{path: "secret/data/app/db#missing", wantErr: true},
+		{path: "secret/data/not-found", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := p.Get(ctx, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Get(%s) err = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Get(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	bad := NewVaultProvider(VaultConfig{Addr: srv.URL, Token: "bad"})
+	if _, err := bad.Get(ctx, "kv/app/redis"); err == nil {
+		t.Error("expected permission denied")
+	}
+}
+
+type conf struct {
+	DSN   string
+	Plain string
+	Redis map[string]struct{ Password string }
+	Keys  []string
+	Ptr   *string
+}
+
+func TestResolveStruct(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db_dsn"), []byte("root:pwd@tcp(db)/demo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_REDIS_PWD", "redis-pwd")
+
+	m := NewManager()
+	m.Register(ProviderEnv, NewEnvProvider())
+	m.Register(ProviderFile, NewFileProvider(dir))
+
+	ptr := "secret://env/TEST_REDIS_PWD"
+	c := &conf{
+		DSN:   "secret://file/db_dsn",
+		Plain: "plain",
+		Redis: map[string]struct{ Password string }{"default": {Password: "secret://env/TEST_REDIS_PWD"}},
+		Keys:  []string{"a", "secret://env/TEST_REDIS_PWD"},
+		Ptr:   &ptr,
+	}
+	if err := m.ResolveStruct(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+	if c.DSN != "root:pwd@tcp(db)/demo" || c.Plain != "plain" || c.Redis["default"].Password != "redis-pwd" ||
+		c.Keys[1] != "redis-pwd" || *c.Ptr != "redis-pwd" {
+		t.Errorf("unexpected resolved config: %+v", c)
+	}
+	if len(m.Refs()) != 2 {
+		t.Errorf("refs = %v", m.Refs())
+	}
+
+	err := m.ResolveStruct(context.Background(), &conf{DSN: "secret://file/../etc/passwd", Plain: "secret://unknown/x"})
+	if err == nil {
+		t.Error("expected error for path traversal and unknown provider")
+	}
+}
+
+func TestManagerRefresh(t *testing.T) {
+	value := "v1"
+	m := NewManager()
+	m.Register("test", ProviderFunc(func(_ context.Context, path string) (string, error) {
+		return path + "=" + value, nil
+	}))
+	var notified []string
+	m.OnChange(func(refs []string) { notified = refs })
+
+	ctx := context.Background()
+	if v, _ := m.Resolve(ctx, "secret://test/key"); v != "key=v1" {
+		t.Fatalf("Resolve() = %s", v)
+	}
+	if changed, _ := m.Refresh(ctx); len(changed) != 0 || notified != nil {
+		t.Errorf("unexpected change %v", changed)
+	}
+
+	value = "v2"
+	if v, _ := m.Resolve(ctx, "secret://test/key"); v != "key=v1" {
+		t.Errorf("Resolve() should use cached value before refresh, got %s", v)
+	}
+	if _, err := m.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(notified) != 1 || notified[0] != "secret://test/key" {
+		t.Errorf("notified = %v", notified)
+	}
+	if v, _ := m.Resolve(ctx, "secret://test/key"); v != "key=v2" {
+		t.Errorf("Resolve() after refresh = %s", v)
+	}
+}
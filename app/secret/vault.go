@@ -0,0 +1,86 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	ProviderVault = "vault"
+	defaultField  = "value"
+)
+
+// VaultProvider 通过 HTTP API 读取 Vault (或兼容实现) 中的密钥
+//
+//	path: {mount}/data/{name}#{field}  KV v2
+//	path: {mount}/{name}#{field}       KV v1
+type VaultProvider struct {
+	conf   VaultConfig
+	client *http.Client
+}
+
+func NewVaultProvider(conf VaultConfig) *VaultProvider {
+	return &VaultProvider{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.Timeout.TimeDuration()},
+	}
+}
+
+type vaultResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []string                   `json:"errors"`
+}
+
+func (p *VaultProvider) Get(ctx context.Context, path string) (string, error) {
+	path, field, _ := strings.Cut(path, "#")
+	if field == "" {
+		field = defaultField
+	}
+	url := strings.TrimRight(p.conf.Addr, "/") + "/v1/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("secret: vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.conf.Token)
+	if p.conf.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.conf.Namespace)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("secret: vault request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("secret: vault read body: %w", err)
+	}
+	var res vaultResponse
+	if err := json.Unmarshal(body, &res); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("secret: vault decode %s: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secret: vault %s status %d %v", path, resp.StatusCode, res.Errors)
+	}
+
+	data := res.Data
+	if raw, ok := data["data"]; ok { // KV v2 的数据在 data.data 中
+		var inner map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &inner); err == nil {
+			data = inner
+		}
+	}
+	raw, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("secret: vault %s field %s not found", path, field)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return string(raw), nil // 非字符串值使用原始 JSON
+	}
+	return s, nil
+}
@@ -3,6 +3,7 @@ package security
 import (
 	"context"
 
+	"github.com/bobacgo/kit/app/tenant"
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -20,7 +21,8 @@ type Claims struct {
 		}
 	*/
 	jwt.RegisteredClaims
-	Data any `json:"data,omitempty"` // 自定义数据
+	Tenant string `json:"tenant,omitempty"` // 租户 ID, 见 ClaimsTenant
+	Data   any    `json:"data,omitempty"`   // 自定义数据
 }
 
 const (
@@ -32,3 +34,24 @@ func GetUserInfo[T any](ctx context.Context) T {
 	info, _ = ctx.Value(ClaimsKey).(*T)
 	return *info
 }
+
+// Subject 获取 jwt subject, ctx 中没有 *Claims 时为空
+func Subject(ctx context.Context) string {
+	if claims, ok := ctx.Value(ClaimsKey).(*Claims); ok && claims != nil {
+		return claims.Subject
+	}
+	return ""
+}
+
+// ClaimsTenant 从 ctx 中的 *Claims 解析租户 (Claims.Tenant), 需要在认证之后使用
+func ClaimsTenant() tenant.Resolver {
+	return tenant.ResolverFunc(func(r tenant.Request) string {
+		if r.Ctx == nil {
+			return ""
+		}
+		if claims, ok := r.Ctx.Value(ClaimsKey).(*Claims); ok && claims != nil {
+			return claims.Tenant
+		}
+		return ""
+	})
+}
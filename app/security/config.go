@@ -12,6 +12,11 @@ type Config struct {
 type CiphertextConfig struct {
 	IsCiphertext bool       `mapstructure:"isCiphertext" yaml:"isCiphertext"`   // 密码字段是否启用密文传输
 	CipherKey    Ciphertext `mapstructure:"cipherKey" yaml:"cipherKey" mask:""` // 支持 8 16 24 bit
+
+	// 数据库字段加密 (见 orm.Encrypted、orm.Hashed)
+	DataKeys       map[string]Ciphertext `mapstructure:"dataKeys" yaml:"dataKeys" mask:""`     // 版本 -> 密钥 (16|24|32 字节), 版本名不区分大小写
+	DataKeyVersion string                `mapstructure:"dataKeyVersion" yaml:"dataKeyVersion"` // 加密使用的版本, 其他版本只用于解密 (密钥轮换)
+	IndexKey       Ciphertext            `mapstructure:"indexKey" yaml:"indexKey" mask:""`     // 盲索引 (HMAC-SHA256) 的密钥, 修改后需要重新计算索引列
 }
 
 type JwtConfig struct {
@@ -27,7 +27,7 @@ type PhoneNo string
 func (pNo PhoneNo) LogValue() slog.Value {
 	no := string(pNo)
 	if len(no) == 11 { //  15345678901 -> 153****8901
-		no = no[:3] + strings.Repeat("*", 4) + no[len(no)-5:]
+		no = no[:3] + strings.Repeat("*", 4) + no[len(no)-4:]
 	} else if len(no) > 2 { // 10000 -> 1***0
 		no = no[:1] + strings.Repeat("*", len(no)-2) + no[len(no)-1:]
 	}
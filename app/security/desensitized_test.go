@@ -1,12 +1,14 @@
 package security
 
 import (
-	"fmt"
 	"log/slog"
 	"testing"
+
+	"github.com/bobacgo/kit/app/logger/logtest"
 )
 
 func TestPhoneNo_LogValue(t *testing.T) {
+	logtest.New(t)
 	slog.Info("phoneNo", "phoneNo", PhoneNo("13800000000"))
-	fmt.Println(PhoneNo("13800000000").LogValue())
-}
\ No newline at end of file
+	logtest.RequireLogged(t, slog.LevelInfo, "phoneNo", "phoneNo", "138****0000")
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bobacgo/kit/app/audit"
 	"github.com/bobacgo/kit/app/cache"
 	"github.com/bobacgo/kit/app/types"
 	"github.com/bobacgo/kit/pkg/uid"
@@ -52,7 +53,10 @@ func (t *JWToken) init() {
 
 // Generate 颁发token access token 和 refresh token
 // refresh token 不需要保存任何用户信息
+// 结果记录审计事件 audit.TypeLogin
 func (t *JWToken) Generate(ctx context.Context, claims *Claims) (atoken, rtoken string, err error) {
+	defer func() { recordLogin(ctx, claims, err) }()
+
 	claims.ID = uid.UUID()
 	claims.Issuer = t.cfg.Issuer
 	claims.Audience = t.cfg.Audience
@@ -82,6 +86,19 @@ func (t *JWToken) Generate(ctx context.Context, claims *Claims) (atoken, rtoken
 	return
 }
 
+func recordLogin(ctx context.Context, claims *Claims, err error) {
+	e := audit.Event{
+		Type:     audit.TypeLogin,
+		Outcome:  audit.OutcomeOf(err),
+		Actor:    claims.Subject,
+		Metadata: map[string]string{"token_id": claims.ID},
+	}
+	if err != nil {
+		e.Reason = err.Error()
+	}
+	_ = audit.Record(ctx, e)
+}
+
 func (t *JWToken) keyfunc(_ *jwt.Token) (any, error) {
 	return t.cfg.Secret, nil
 }
@@ -130,7 +147,7 @@ func (t *JWToken) RemoveToken(ctx context.Context, subject string) error {
 	case t.rdb != nil:
 		return t.rdb.Del(ctx, t.key(subject)).Err()
 	case t.cache != nil:
-		t.cache.Del(t.key(subject))
+		cache.WithTenant(ctx, t.cache).Del(t.key(subject))
 		return nil
 	default:
 		return errors.New("cache not init")
@@ -148,7 +165,7 @@ func (t *JWToken) getToken(ctx context.Context, subject string) ([]string, error
 			return nil, err
 		}
 	case t.cache != nil:
-		if err = t.cache.Get(t.key(subject), &tokenStr); err != nil {
+		if err = cache.WithTenant(ctx, t.cache).Get(t.key(subject), &tokenStr); err != nil {
 			return nil, err
 		}
 	default:
@@ -165,7 +182,7 @@ func (t *JWToken) cacheToken(ctx context.Context, subject, tokenID, token string
 	case t.rdb != nil:
 		return t.rdb.Set(ctx, t.key(subject), value, t.cfg.AccessTokenExpired.TimeDuration()).Err()
 	case t.cache != nil:
-		return t.cache.Set(t.key(subject), value, t.cfg.AccessTokenExpired.TimeDuration())
+		return cache.WithTenant(ctx, t.cache).Set(t.key(subject), value, t.cfg.AccessTokenExpired.TimeDuration())
 	default:
 		return errors.New("cache not init")
 	}
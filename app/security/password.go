@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/bobacgo/kit/app/audit"
 	"github.com/bobacgo/kit/app/cache"
 	"github.com/bobacgo/kit/pkg/ucrypto"
 	"github.com/bobacgo/kit/pkg/utime"
@@ -121,8 +123,11 @@ func (h *PwdVerifier) expire() time.Duration {
 	return time.Duration(utime.ZeroHour(1).Unix() - time.Now().Unix())
 }
 
+// fail 密码错误次数+1, 并记录审计事件 audit.TypePasswordFailed
 func (h *PwdVerifier) fail(ctx context.Context) {
-	if err := h.incr(ctx); err != nil && h.OnErr != nil {
+	err := h.incr(ctx)
+	h.record(ctx, err)
+	if err != nil && h.OnErr != nil {
 		h.OnErr(err)
 	}
 	if err := h.reExpire(ctx); err != nil && h.OnErr != nil {
@@ -130,6 +135,23 @@ func (h *PwdVerifier) fail(ctx context.Context) {
 	}
 }
 
+func (h *PwdVerifier) record(ctx context.Context, err error) {
+	e := audit.Event{
+		Type:     audit.TypePasswordFailed,
+		Outcome:  audit.OutcomeFailure,
+		Resource: h.key,
+		Metadata: map[string]string{
+			"count":  strconv.Itoa(int(h.errCount)),
+			"limit":  strconv.Itoa(int(h.pv.limit)),
+			"locked": strconv.FormatBool(errors.Is(err, ErrPasswdLimit)),
+		},
+	}
+	if err != nil && !errors.Is(err, ErrPasswdLimit) {
+		e.Reason = err.Error()
+	}
+	_ = audit.Record(ctx, e)
+}
+
 func (h *PwdVerifier) incr(ctx context.Context) error {
 	var err error
 	if h.pv.rdb != nil {
@@ -0,0 +1,109 @@
+// Package admin 运维管理接口
+// 同时提供 HTTP (gin) 和 gRPC 两种访问方式
+//
+//	GET    /debug/config?format=json|yaml   查看当前生效的配置 (已脱敏)
+//	GET    /debug/loggers                   查看全局及模块的日志级别
+//	PUT    /debug/loggers/:name             设置模块的日志级别 {"level": "debug", "ttl": "10m"} (name 为 root 时修改全局级别)
+//	DELETE /debug/loggers/:name             模块恢复为跟随全局级别
+//
+// 修改运行状态的接口 (PUT、DELETE、SetLogger) 没有配置 WithAuth 时拒绝访问
+//
+//	/kit.admin.v1.Admin/GetConfig
+//	/kit.admin.v1.Admin/ListLoggers
+//	/kit.admin.v1.Admin/SetLogger
+//
+// 每次调用都会记录审计事件 audit.TypeAdminCall
+//
+// 管理接口可以修改服务的运行状态, 只应该在配置 server.admin.enabled 后注册, 并使用 WithAuth 校验调用方
+package admin
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const serviceName = "kit.admin.v1.Admin"
+
+// RegisterGin 注册 HTTP 管理接口
+// r 上注册的其他路由也会记录审计事件, 需要传入单独的路由组 (e.g. e.Group("/debug"))
+func RegisterGin(r gin.IRouter, opts ...Option) {
+	o := newOptions(opts)
+	r.Use(auditGin, o.authGin)
+	r.GET("/config", getConfig)
+	r.GET("/loggers", listLoggers)
+	r.PUT("/loggers/:name", o.requireAuth, setLogger)
+	r.DELETE("/loggers/:name", o.requireAuth, resetLogger)
+}
+
+// RegisterGrpc 注册 gRPC 管理服务
+func RegisterGrpc(s grpc.ServiceRegistrar, opts ...Option) {
+	s.RegisterService(&serviceDesc, adminServer{opts: newOptions(opts)})
+}
+
+// FullMethod gRPC 方法的完整名称 (e.g. /kit.admin.v1.Admin/GetConfig)
+// 客户端调用: conn.Invoke(ctx, admin.FullMethod("GetConfig"), req, resp)
+func FullMethod(method string) string {
+	return "/" + serviceName + "/" + method
+}
+
+type adminServer struct {
+	opts options
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("GetConfig", adminServer.GetConfig),
+		unaryMethod("ListLoggers", adminServer.ListLoggers),
+		unaryMethod("SetLogger", adminServer.SetLogger),
+	},
+	Metadata: "", // 手写的 ServiceDesc, 没有对应的 proto 文件
+}
+
+// mutatingMethods 修改运行状态的方法, 必须配置 Authenticator
+var mutatingMethods = map[string]bool{"SetLogger": true}
+
+// unaryMethod 构建 gRPC 单向方法描述 (与 protoc 生成的 handler 逻辑一致)
+func unaryMethod[Req any, PReq interface {
+	*Req
+	proto.Message
+}, Resp proto.Message](name string, fn func(adminServer, context.Context, PReq) (Resp, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			in := PReq(new(Req))
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req any) (any, error) {
+				s := srv.(adminServer)
+				ctx, err := s.opts.authGrpc(ctx, mutatingMethods[name])
+				if err != nil {
+					auditGrpc(ctx, name, resourceOf(req), err)
+					return nil, err
+				}
+				resp, err := fn(s, ctx, req.(PReq))
+				auditGrpc(ctx, name, resourceOf(req), err)
+				return resp, err
+			}
+			if interceptor == nil {
+				return handler(ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: FullMethod(name)}
+			return interceptor(ctx, in, info, handler)
+		},
+	}
+}
+
+// resourceOf 操作对象 (e.g. SetLogger 的模块名称)
+func resourceOf(req any) string {
+	if s, ok := req.(*structpb.Struct); ok {
+		return s.GetFields()["name"].GetStringValue()
+	}
+	return ""
+}
@@ -0,0 +1,257 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bobacgo/kit/app/audit"
+	"github.com/bobacgo/kit/app/logger"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestGetConfigHttp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	RegisterGin(e.Group("/debug"))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/config?format=yaml", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "loadedAt") {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetConfigGrpc(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	RegisterGrpc(s)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	resp := new(wrapperspb.StringValue)
+	if err := conn.Invoke(context.Background(), FullMethod("GetConfig"), wrapperspb.String("json"), resp); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.GetValue(), `"loadedAt"`) {
+		t.Errorf("unexpected response %s", resp.GetValue())
+	}
+	if err := conn.Invoke(context.Background(), FullMethod("GetConfig"), wrapperspb.String("xml"), resp); err == nil {
+		t.Error("expected invalid argument error")
+	}
+}
+
+// authed 带有令牌的请求
+func authed(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	return req
+}
+
+func TestLoggersHttp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	RegisterGin(e.Group("/debug"), WithAuth(TokenAuth("ops", "secret")))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, authed(http.MethodPut, "/debug/loggers/admin-test", `{"level":"debug","ttl":"1m"}`))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"level":"debug"`) || !strings.Contains(w.Body.String(), "expiresAt") {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, authed(http.MethodGet, "/debug/loggers", ""))
+	if !strings.Contains(w.Body.String(), `"name":"root"`) || !strings.Contains(w.Body.String(), `"name":"admin-test"`) {
+		t.Errorf("unexpected response %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, authed(http.MethodPut, "/debug/loggers/admin-test", `{"level":"verbose"}`))
+	if strings.Contains(w.Body.String(), `"code":0`) {
+		t.Errorf("expected bad request, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, authed(http.MethodDelete, "/debug/loggers/admin-test", ""))
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, authed(http.MethodGet, "/debug/loggers", ""))
+	if !strings.Contains(w.Body.String(), `"name":"admin-test","level":"info","inherited":true`) {
+		t.Errorf("module should inherit the global level after reset, got %s", w.Body.String())
+	}
+}
+
+func TestLoggersGrpc(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	RegisterGrpc(s, WithAuth(TokenAuth("ops", "secret")))
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	req, _ := structpb.NewStruct(map[string]any{"name": "admin-grpc", "level": "warn"})
+	resp := new(wrapperspb.StringValue)
+	if err := conn.Invoke(ctx, FullMethod("SetLogger"), req, resp); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Invoke(ctx, FullMethod("ListLoggers"), &emptypb.Empty{}, resp); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.GetValue(), `"name":"admin-grpc","level":"warn"`) {
+		t.Errorf("unexpected response %s", resp.GetValue())
+	}
+	req, _ = structpb.NewStruct(map[string]any{"level": "warn"})
+	if err := conn.Invoke(ctx, FullMethod("SetLogger"), req, resp); err == nil {
+		t.Error("expected invalid argument error")
+	}
+}
+
+func TestAuthHttp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	RegisterGin(e.Group("/debug"), WithAuth(TokenAuth("ops", "secret")))
+
+	for token, ok := range map[string]bool{"": false, "Bearer wrong": false, "Bearer secret": true} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/debug/config", nil)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		e.ServeHTTP(w, req)
+		if got := strings.Contains(w.Body.String(), "loadedAt"); got != ok {
+			t.Errorf("token %q: unexpected response %s", token, w.Body.String())
+		}
+	}
+}
+
+func TestAuthGrpc(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	RegisterGrpc(s, WithAuth(TokenAuth("ops", "secret")))
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	resp := new(wrapperspb.StringValue)
+	err = conn.Invoke(context.Background(), FullMethod("GetConfig"), wrapperspb.String("json"), resp)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected unauthenticated, got %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	if err := conn.Invoke(ctx, FullMethod("GetConfig"), wrapperspb.String("json"), resp); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutatingRequiresAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	RegisterGin(e.Group("/debug"))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/debug/loggers/admin-noauth", strings.NewReader(`{"level":"debug"}`)))
+	if !strings.Contains(w.Body.String(), `"code":403`) {
+		t.Errorf("expected forbidden, got %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/loggers", nil))
+	if strings.Contains(w.Body.String(), "admin-noauth") {
+		t.Errorf("logger level should not be changed, got %s", w.Body.String())
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	RegisterGrpc(s)
+	go s.Serve(lis)
+	defer s.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	req, _ := structpb.NewStruct(map[string]any{"name": "admin-noauth", "level": "debug"})
+	err = conn.Invoke(context.Background(), FullMethod("SetLogger"), req, new(wrapperspb.StringValue))
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected permission denied, got %v", err)
+	}
+}
+
+type memStore struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (s *memStore) Append(_ context.Context, e *audit.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, *e)
+	return nil
+}
+
+func (s *memStore) Close() error {
+	return nil
+}
+
+func TestAuditActor(t *testing.T) {
+	store := new(memStore)
+	audit.Register(store)
+	t.Cleanup(func() { audit.Close() })
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.ContextWithFallback = true
+	e.Use(func(c *gin.Context) { // 上游伪造的日志字段
+		c.Request = c.Request.WithContext(logger.WithFields(c.Request.Context(), slog.String(logger.FieldUser, "attacker")))
+	})
+	RegisterGin(e.Group("/debug"), WithAuth(TokenAuth("ops", "secret")))
+
+	e.ServeHTTP(httptest.NewRecorder(), authed(http.MethodGet, "/debug/config", ""))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+	if len(store.events) != 2 {
+		t.Fatalf("events = %d, want 2", len(store.events))
+	}
+	if a := store.events[0]; a.Actor != "ops" || a.Outcome != audit.OutcomeSuccess {
+		t.Errorf("unexpected event %+v", a)
+	}
+	if a := store.events[1]; a.Actor != "" || a.Outcome != audit.OutcomeFailure {
+		t.Errorf("actor should not be taken from log fields, got %+v", a)
+	}
+}
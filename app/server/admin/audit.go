@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/bobacgo/kit/app/audit"
+	"github.com/bobacgo/kit/app/security"
+	"github.com/bobacgo/kit/web/r"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+)
+
+// auditGin 管理接口调用记录审计事件 audit.TypeAdminCall
+func auditGin(c *gin.Context) {
+	c.Next()
+
+	e := audit.Event{
+		Type:     audit.TypeAdminCall,
+		Outcome:  audit.OutcomeSuccess,
+		Actor:    actor(c),
+		Action:   c.Request.Method + " " + c.FullPath(),
+		Resource: c.Param("name"),
+		ClientIP: c.ClientIP(),
+	}
+	if err := c.Errors.Last(); err != nil {
+		e.Outcome, e.Reason = audit.OutcomeFailure, err.Error()
+	}
+	if code, ok := c.Value(r.CodeKey).(codes.Code); ok && code != codes.OK {
+		e.Outcome = audit.OutcomeFailure
+	}
+	if c.Writer.Status() >= http.StatusBadRequest {
+		e.Outcome = audit.OutcomeFailure
+	}
+	_ = audit.Record(c, e)
+}
+
+// auditGrpc 同 auditGin
+func auditGrpc(ctx context.Context, method, resource string, err error) {
+	e := audit.Event{
+		Type:     audit.TypeAdminCall,
+		Outcome:  audit.OutcomeOf(err),
+		Actor:    actor(ctx),
+		Action:   FullMethod(method),
+		Resource: resource,
+	}
+	if err != nil {
+		e.Reason = err.Error()
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.ClientIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(e.ClientIP); err == nil {
+			e.ClientIP = host
+		}
+	}
+	_ = audit.Record(ctx, e)
+}
+
+// actor 通过 Authenticator 认证的调用方, 没有时为 jwt subject
+func actor(ctx context.Context) string {
+	if a := actorOf(ctx); a != "" {
+		return a
+	}
+	return security.Subject(ctx)
+}
@@ -0,0 +1,129 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/bobacgo/kit/web/r"
+	rcodes "github.com/bobacgo/kit/web/r/codes"
+	rstatus "github.com/bobacgo/kit/web/r/status"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrUnauthenticated 令牌缺失或错误
+	ErrUnauthenticated = errors.New("admin: unauthenticated")
+	// ErrNoAuthenticator 修改运行状态的接口必须配置 Authenticator
+	ErrNoAuthenticator = errors.New("admin: authenticator is required for this operation")
+)
+
+// Authenticator 校验调用方的令牌 (HTTP Authorization: Bearer {token}, gRPC metadata authorization)
+// 返回调用方标识, 作为审计事件的 actor
+type Authenticator func(ctx context.Context, token string) (actor string, err error)
+
+// TokenAuth 使用固定令牌校验, actor 为 name
+func TokenAuth(name, token string) Authenticator {
+	return func(_ context.Context, t string) (string, error) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(t), []byte(token)) != 1 {
+			return "", ErrUnauthenticated
+		}
+		return name, nil
+	}
+}
+
+type options struct {
+	auth Authenticator
+}
+
+type Option func(*options)
+
+// WithAuth 所有管理接口都需要通过认证
+func WithAuth(auth Authenticator) Option {
+	return func(o *options) {
+		o.auth = auth
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+type actorKey struct{}
+
+// ginActorKey gin.Context 的 key 只能是 string
+const ginActorKey = "kit:admin_actor"
+
+// actorOf 认证通过的调用方
+func actorOf(ctx context.Context) string {
+	if c, ok := ctx.(*gin.Context); ok {
+		return c.GetString(ginActorKey)
+	}
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+func bearer(v string) string {
+	token, ok := strings.CutPrefix(v, "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// authGin 没有配置 Authenticator 时不校验
+func (o options) authGin(c *gin.Context) {
+	if o.auth == nil {
+		c.Next()
+		return
+	}
+	actor, err := o.auth(c, bearer(c.GetHeader("Authorization")))
+	if err != nil {
+		_ = c.Error(err)
+		r.Reply(c, rstatus.New(rcodes.TokenInvalid, err.Error()))
+		c.Abort()
+		return
+	}
+	c.Set(ginActorKey, actor)
+	c.Next()
+}
+
+// requireAuth 修改运行状态的接口 (e.g. 设置日志级别), 没有配置 Authenticator 时拒绝
+func (o options) requireAuth(c *gin.Context) {
+	if o.auth == nil {
+		_ = c.Error(ErrNoAuthenticator)
+		r.Reply(c, rstatus.New(rcodes.Forbidden, ErrNoAuthenticator.Error()))
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// authGrpc 返回带有调用方的 ctx, mutating 为 true 时必须配置 Authenticator
+func (o options) authGrpc(ctx context.Context, mutating bool) (context.Context, error) {
+	if o.auth == nil {
+		if mutating {
+			return ctx, status.Error(codes.PermissionDenied, ErrNoAuthenticator.Error())
+		}
+		return ctx, nil
+	}
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			token = bearer(v[0])
+		}
+	}
+	actor, err := o.auth(ctx, token)
+	if err != nil {
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return context.WithValue(ctx, actorKey{}, actor), nil
+}
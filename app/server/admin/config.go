@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/bobacgo/kit/app/conf"
+	"github.com/bobacgo/kit/web/r"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+// getConfig 查看当前生效的配置
+// 包含每个配置项的来源文件、最近一次加载时间以及与上一次加载的差异
+func getConfig(c *gin.Context) {
+	snapshot := conf.GetSnapshot()
+	if strings.EqualFold(c.Query("format"), formatYAML) {
+		data, err := yaml.Marshal(snapshot)
+		if err != nil {
+			r.Reply(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "application/yaml; charset=utf-8", data)
+		return
+	}
+	r.Reply(c, snapshot)
+}
+
+// GetConfig 查看当前生效的配置
+// req 为输出格式 json (默认) | yaml
+func (adminServer) GetConfig(_ context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	var (
+		data []byte
+		err  error
+	)
+	snapshot := conf.GetSnapshot()
+	switch strings.ToLower(req.GetValue()) {
+	case "", formatJSON:
+		data, err = json.Marshal(snapshot)
+	case formatYAML:
+		data, err = yaml.Marshal(snapshot)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported format %q", req.GetValue())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return wrapperspb.String(string(data)), nil
+}
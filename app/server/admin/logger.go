@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bobacgo/kit/app/logger"
+	"github.com/bobacgo/kit/web/r"
+	"github.com/bobacgo/kit/web/r/errs"
+	rstatus "github.com/bobacgo/kit/web/r/status"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// SetLoggerReq 设置模块日志级别
+type SetLoggerReq struct {
+	Level logger.LogLevel `json:"level"`         // debug | info | warn | error
+	TTL   string          `json:"ttl,omitempty"` // 到期自动恢复 (e.g. 10m), 为空则不恢复
+}
+
+// listLoggers 查看全局及所有模块的日志级别
+func listLoggers(c *gin.Context) {
+	r.Reply(c, logger.ModuleLevels())
+}
+
+// setLogger 设置模块的日志级别
+func setLogger(c *gin.Context) {
+	var req SetLoggerReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		r.Reply(c, rstatus.New(errs.BadRequest.Code, err.Error()))
+		return
+	}
+	info, err := setModuleLevel(c.Param("name"), req)
+	if err != nil {
+		r.Reply(c, rstatus.New(errs.BadRequest.Code, err.Error()))
+		return
+	}
+	r.Reply(c, info)
+}
+
+// resetLogger 模块恢复为跟随全局级别
+func resetLogger(c *gin.Context) {
+	if err := logger.ResetModuleLevel(c.Param("name")); err != nil {
+		r.Reply(c, rstatus.New(errs.BadRequest.Code, err.Error()))
+		return
+	}
+	r.Reply(c, nil)
+}
+
+func setModuleLevel(name string, req SetLoggerReq) (logger.ModuleLevel, error) {
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			return logger.ModuleLevel{}, err
+		}
+	}
+	if err := logger.SetModuleLevel(name, req.Level, ttl); err != nil {
+		return logger.ModuleLevel{}, err
+	}
+	for _, l := range logger.ModuleLevels() {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+	return logger.ModuleLevel{}, nil
+}
+
+// ListLoggers 查看全局及所有模块的日志级别 (JSON)
+func (adminServer) ListLoggers(_ context.Context, _ *emptypb.Empty) (*wrapperspb.StringValue, error) {
+	data, err := json.Marshal(logger.ModuleLevels())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return wrapperspb.String(string(data)), nil
+}
+
+// SetLogger 设置模块的日志级别
+// req: {"name": "db", "level": "debug", "ttl": "10m"}, level 为空时恢复为跟随全局级别
+func (adminServer) SetLogger(_ context.Context, req *structpb.Struct) (*wrapperspb.StringValue, error) {
+	fields := req.GetFields()
+	name := fields["name"].GetStringValue()
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	level := fields["level"].GetStringValue()
+	if level == "" {
+		if err := logger.ResetModuleLevel(name); err != nil {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return wrapperspb.String(""), nil
+	}
+	info, err := setModuleLevel(name, SetLoggerReq{Level: logger.LogLevel(level), TTL: fields["ttl"].GetStringValue()})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	data, _ := json.Marshal(info)
+	return wrapperspb.String(string(data)), nil
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bobacgo/kit/app/logger"
+	"github.com/bobacgo/kit/app/security"
+	"github.com/bobacgo/kit/web/r"
+	"github.com/bobacgo/kit/web/r/codes"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// AccessLog 访问日志中间件 (替代 gin.Logger)
+// 每个请求输出一条结构化日志, 见 logger.LogAccess
+// 1.包体只记录 application/json
+// 2.请求包体在 handler 读取时捕获, 未读取的部分不记录; 包体最多保存 MaxSize 字节
+func AccessLog(cfg logger.AccessConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" { // 未匹配到路由
+			route = c.Request.URL.Path
+		}
+		if cfg.Skip(route) {
+			c.Next()
+			return
+		}
+		start := time.Now()
+
+		var reqBody *logger.BodyCapture
+		if cfg.Body.Request && c.ContentType() == binding.MIMEJSON {
+			reqBody = cfg.NewCapture() // 处理请求时读取包体的同时捕获
+			c.Request.Body = &teeBody{Reader: io.TeeReader(c.Request.Body, reqBody), Closer: c.Request.Body}
+		}
+		var respBody *logger.BodyCapture
+		if cfg.Body.Response {
+			respBody = cfg.NewCapture()
+			c.Writer = &captureWriter{ResponseWriter: c.Writer, body: respBody}
+		}
+
+		c.Next()
+
+		a := &logger.Access{
+			Protocol:  "http",
+			Method:    c.Request.Method,
+			Route:     route,
+			Status:    c.Writer.Status(),
+			Latency:   time.Since(start),
+			Bytes:     max(c.Writer.Size(), 0),
+			ClientIP:  c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Subject:   security.Subject(c),
+			Request:   cfg.RedactCapture(reqBody),
+			Level:     slog.LevelInfo,
+		}
+		if err := c.Errors.Last(); err != nil { // *gin.Error 为 nil 时不能直接赋值给 error
+			a.Err = err
+		}
+		if respBody != nil && strings.HasPrefix(c.Writer.Header().Get("Content-Type"), binding.MIMEJSON) {
+			a.Response = cfg.RedactCapture(respBody)
+		}
+		if v, ok := c.Get(r.CodeKey); ok {
+			if code, ok := v.(codes.Code); ok {
+				a.Code = new(int)
+				*a.Code = int(code)
+			}
+		}
+		switch {
+		case a.Status >= http.StatusInternalServerError || a.Err != nil:
+			a.Level = slog.LevelError
+		case a.Status >= http.StatusBadRequest || (a.Code != nil && *a.Code != int(codes.OK)):
+			a.Level = slog.LevelWarn
+		}
+		logger.LogAccess(c, a)
+	}
+}
+
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
+
+// captureWriter 写入响应的同时捕获包体
+type captureWriter struct {
+	gin.ResponseWriter
+	body io.Writer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	_, _ = w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	_, _ = io.WriteString(w.body, s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/bobacgo/kit/app/logger"
+	"github.com/bobacgo/kit/app/security"
+	"github.com/bobacgo/kit/pkg/uid"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const HeaderRequestID = "X-Request-Id"
+
+// LogFields 添加请求范围的日志字段, 见 logger.WithFields
+// 1.读取上游传递的 x-log-* header (只接受 logger 配置 propagateFields 中的字段, 见 logger.ExtractFields)
+// 2.request_id 取 X-Request-Id header (校验同 x-log-* 字段, 见 logger.ValidRequestID), 没有或不可用时生成, 并写回响应头
+// 3.app_id 为当前服务实例的 ID; 全局中间件在认证之前执行, user 由认证之后的 LogUser 添加
+func LogFields(appID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := logger.ExtractFields(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		requestID := c.GetHeader(HeaderRequestID)
+		if !logger.ValidRequestID(requestID) {
+			requestID = logger.FieldValue(ctx, logger.FieldRequestID)
+		}
+		if requestID == "" {
+			requestID = uid.UUID()
+		}
+		c.Header(HeaderRequestID, requestID)
+
+		attrs := []slog.Attr{
+			slog.String(logger.FieldRequestID, requestID),
+			slog.String(logger.FieldAppID, appID),
+		}
+		c.Request = c.Request.WithContext(logger.WithFields(ctx, attrs...))
+		c.Next()
+	}
+}
+
+// LogUser 添加日志字段 user (jwt subject), 放在认证中间件之后
+//
+//	g := e.Group("/api", auth, middleware.LogUser())
+func LogUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if sub := security.Subject(c); sub != "" {
+			c.Request = c.Request.WithContext(logger.WithFields(c.Request.Context(), slog.String(logger.FieldUser, sub)))
+		}
+		c.Next()
+	}
+}
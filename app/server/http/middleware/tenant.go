@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/bobacgo/kit/app/tenant"
+	"github.com/bobacgo/kit/web/r"
+	"github.com/bobacgo/kit/web/r/errs"
+	"github.com/bobacgo/kit/web/r/status"
+	"github.com/gin-gonic/gin"
+)
+
+// Tenant 解析租户并保存到请求的 ctx 中, 见 tenant.WithID
+// 使用 jwt claims 解析时需要放在认证之后, 见 tenant 包的说明
+// required 为 true 时没有租户或格式错误返回 400
+func Tenant(resolver tenant.Resolver, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := resolver.Resolve(tenant.Request{Ctx: c, Header: c.GetHeader, Host: c.Request.Host})
+		if id == "" {
+			if required {
+				r.Reply(c, status.New(errs.BadRequest.Code, tenant.ErrMissing.Error()))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		ctx, err := tenant.WithID(c.Request.Context(), id)
+		if err != nil {
+			r.Reply(c, status.New(errs.BadRequest.Code, err.Error()))
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,102 @@
+package interceptor
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/bobacgo/kit/app/logger"
+	"github.com/bobacgo/kit/app/security"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// AccessLog 访问日志拦截器, 与 http 中间件输出相同结构的日志, 见 logger.LogAccess
+func AccessLog(cfg logger.AccessConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if cfg.Skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		resp, err = handler(ctx, req)
+
+		a := newAccess(ctx, "unary", info.FullMethod, start, err)
+		if msg, ok := resp.(proto.Message); ok && err == nil {
+			a.Bytes = proto.Size(msg)
+		}
+		if cfg.Body.Request {
+			a.Request = cfg.Redact(marshalBody(req))
+		}
+		if cfg.Body.Response && err == nil {
+			a.Response = cfg.Redact(marshalBody(resp))
+		}
+		logger.LogAccess(ctx, a)
+		return resp, err
+	}
+}
+
+// StreamAccessLog 流式访问日志拦截器 (不记录包体)
+func StreamAccessLog(cfg logger.AccessConfig) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if cfg.Skip(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		start := time.Now()
+		err := handler(srv, ss)
+		logger.LogAccess(ss.Context(), newAccess(ss.Context(), "stream", info.FullMethod, start, err))
+		return err
+	}
+}
+
+func newAccess(ctx context.Context, method, fullMethod string, start time.Time, err error) *logger.Access {
+	code := status.Code(err)
+	a := &logger.Access{
+		Protocol: "grpc",
+		Method:   method,
+		Route:    fullMethod,
+		Status:   int(code),
+		Latency:  time.Since(start),
+		Subject:  security.Subject(ctx),
+		Err:      err,
+		Level:    slog.LevelInfo,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		a.ClientIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(a.ClientIP); err == nil {
+			a.ClientIP = host
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("user-agent"); len(values) > 0 {
+			a.UserAgent = values[0]
+		}
+	}
+	switch code {
+	case codes.OK:
+	case codes.Unknown, codes.DeadlineExceeded, codes.Internal, codes.Unavailable, codes.DataLoss:
+		a.Level = slog.LevelError
+	default:
+		a.Level = slog.LevelWarn
+	}
+	return a
+}
+
+// marshalBody proto 消息字段名与生成代码的 json 标签一致, 便于按 mask 规则脱敏
+func marshalBody(v any) []byte {
+	if v == nil {
+		return nil
+	}
+	if msg, ok := v.(proto.Message); ok {
+		b, _ := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+		return b
+	}
+	b, _ := json.Marshal(v)
+	return b
+}
@@ -0,0 +1,133 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/bobacgo/kit/app/logger"
+	"github.com/bobacgo/kit/app/security"
+	"github.com/bobacgo/kit/pkg/uid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const MetadataRequestID = "x-request-id"
+
+// metadataCarrier 适配 propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// LogFields 添加请求范围的日志字段, 见 logger.WithFields
+// 1.读取上游传递的 x-log-* metadata (只接受 logger 配置 propagateFields 中的字段, 见 logger.ExtractFields)
+// 2.request_id 取 x-request-id metadata (校验同 x-log-* 字段, 见 logger.ValidRequestID), 没有或不可用时生成
+// 3.app_id 为当前服务实例的 ID; 默认拦截器在认证之前执行, user 由认证之后的 LogUser 添加
+func LogFields(appID string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		return handler(logFieldsContext(ctx, appID), req)
+	}
+}
+
+// StreamLogFields 流式请求的日志字段, 同 LogFields
+func StreamLogFields(appID string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &fieldsServerStream{ServerStream: ss, ctx: logFieldsContext(ss.Context(), appID)})
+	}
+}
+
+func logFieldsContext(ctx context.Context, appID string) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = logger.ExtractFields(ctx, metadataCarrier(md))
+
+	requestID := metadataCarrier(md).Get(MetadataRequestID)
+	if !logger.ValidRequestID(requestID) {
+		requestID = logger.FieldValue(ctx, logger.FieldRequestID)
+	}
+	if requestID == "" {
+		requestID = uid.UUID()
+	}
+	return logger.WithFields(ctx,
+		slog.String(logger.FieldRequestID, requestID),
+		slog.String(logger.FieldAppID, appID),
+	)
+}
+
+// LogUser 添加日志字段 user (jwt subject), 放在认证拦截器之后
+//
+//	grpc.ChainUnaryInterceptor(auth, interceptor.LogUser())
+func LogUser() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		return handler(logUserContext(ctx), req)
+	}
+}
+
+// StreamLogUser 流式请求的日志字段 user, 同 LogUser
+func StreamLogUser() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &fieldsServerStream{ServerStream: ss, ctx: logUserContext(ss.Context())})
+	}
+}
+
+func logUserContext(ctx context.Context) context.Context {
+	if sub := security.Subject(ctx); sub != "" {
+		return logger.WithFields(ctx, slog.String(logger.FieldUser, sub))
+	}
+	return ctx
+}
+
+type fieldsServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fieldsServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// ClientLogFields 向下游传递上下文日志字段
+func ClientLogFields() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingFields(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientLogFields 流式请求向下游传递上下文日志字段
+func StreamClientLogFields() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingFields(ctx), desc, cc, method, opts...)
+	}
+}
+
+func outgoingFields(ctx context.Context) context.Context {
+	if len(logger.Fields(ctx)) == 0 {
+		return ctx
+	}
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	logger.InjectFields(ctx, metadataCarrier(md))
+	if id := logger.FieldValue(ctx, logger.FieldRequestID); id != "" {
+		md.Set(MetadataRequestID, id)
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
@@ -4,14 +4,16 @@ import (
 	"context"
 	"log/slog"
 
+	"github.com/bobacgo/kit/app/logger"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// Logger 使用 slog
+// Logger 使用 slog (模块 rpc, 级别可单独调整)
 func Logger() logging.Logger {
+	log := logger.Named("rpc")
 	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
-		slog.Log(ctx, slog.Level(lvl), msg, fields...)
+		log.Log(ctx, slog.Level(lvl), msg, fields...)
 	})
 }
 
@@ -0,0 +1,55 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bobacgo/kit/app/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Tenant 解析租户并保存到 ctx 中, 见 tenant.WithID
+// 使用 jwt claims 解析时需要放在认证之后
+// required 为 true 时没有租户或格式错误返回 InvalidArgument
+func Tenant(resolver tenant.Resolver, required bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if ctx, err = tenantContext(ctx, resolver, required); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamTenant 流式请求的租户, 同 Tenant
+func StreamTenant(resolver tenant.Resolver, required bool) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := tenantContext(ss.Context(), resolver, required)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &fieldsServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func tenantContext(ctx context.Context, resolver tenant.Resolver, required bool) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := resolver.Resolve(tenant.Request{
+		Ctx:    ctx,
+		Header: func(key string) string { return metadataCarrier(md).Get(strings.ToLower(key)) },
+		Host:   metadataCarrier(md).Get(":authority"),
+	})
+	if id == "" {
+		if required {
+			return ctx, status.Error(codes.InvalidArgument, tenant.ErrMissing.Error())
+		}
+		return ctx, nil
+	}
+	ctx, err := tenant.WithID(ctx, id)
+	if err != nil {
+		return ctx, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return ctx, nil
+}
@@ -43,6 +43,7 @@ func New[T any](configPath string, opts ...AppOption) *App {
 	// 1. 加载配置
 	cfg, err := conf.LoadApp[T](configPath, func(e fsnotify.Event) {
 		logger.SetLevel(conf.GetBasicConf().Logger.Level)
+		reloadKeyring()
 		slog.Warn("[config] config onchange", "name", e.Name, "op", e.Op)
 	})
 	if err != nil {
@@ -85,6 +86,12 @@ func New[T any](configPath string, opts ...AppOption) *App {
 	if err := wg.Wait(); err != nil { // 等待 options 实例化结束
 		log.Panic(err)
 	}
+	o.useKeyring()
+	o.useSecrets(func() error {
+		_, err := conf.LoadApp[T](configPath, nil)
+		return err
+	})
+	o.useAudit()
 
 	return &App{
 		AppOptions: o,
@@ -96,6 +103,8 @@ func New[T any](configPath string, opts ...AppOption) *App {
 // 1.注册服务
 // 2.退出相关组件或服务
 func (a *App) Run() error {
+	defer flushLogger() // 最后执行, 启动失败时也写入队列中的日志
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
@@ -153,11 +162,11 @@ func (a *App) Run() error {
 	signal.Notify(a.signal, a.sigs...)
 	<-a.signal
 
-	if err = a.shutdown(ctx); err != nil {
-		return err
+	err = a.shutdown(ctx)
+	if err == nil {
+		slog.Info("[server] service has exited")
 	}
-	slog.Info("[server] service has exited")
-	return nil
+	return err
 }
 
 // Stop 手动停止服务
@@ -217,6 +226,13 @@ func (a *App) shutdown(ctx context.Context) error {
 	return nil
 }
 
+// flushLogger 写入异步队列中剩余的日志并关闭输出, 之后的日志同步写入
+func flushLogger() {
+	if err := logger.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "[logger] close error: %v\n", err)
+	}
+}
+
 func (a *App) buildInstance() (*registry.ServiceInstance, error) {
 	endpoints := make([]string, 0)
 	httpScheme, grpcScheme := false, false
@@ -0,0 +1,88 @@
+package tenant
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// HeaderTenantID 默认的租户 header (grpc metadata 为小写)
+const HeaderTenantID = "X-Tenant-Id"
+
+// Request 解析租户需要的请求信息, 由 http 中间件、grpc 拦截器提供
+type Request struct {
+	Ctx    context.Context         // 认证在此之前完成时包含 jwt claims
+	Header func(key string) string // http header 或 grpc metadata
+	Host   string                  // http Host 或 grpc :authority
+}
+
+// Resolver 从请求中解析租户 ID, 没有时返回空
+type Resolver interface {
+	Resolve(r Request) string
+}
+
+type ResolverFunc func(r Request) string
+
+func (f ResolverFunc) Resolve(r Request) string {
+	return f(r)
+}
+
+// FromHeader 从 header 读取, name 为空时为 X-Tenant-Id
+// header 可以被客户端伪造, 只在网关已校验或内部调用时使用
+func FromHeader(name string) Resolver {
+	if name == "" {
+		name = HeaderTenantID
+	}
+	return ResolverFunc(func(r Request) string {
+		if r.Header == nil {
+			return ""
+		}
+		return strings.TrimSpace(r.Header(name))
+	})
+}
+
+// FromSubdomain 从子域名读取, e.g. baseDomain 为 example.com 时 t1.example.com -> t1
+func FromSubdomain(baseDomain string) Resolver {
+	suffix := "." + strings.TrimPrefix(strings.ToLower(baseDomain), ".")
+	return ResolverFunc(func(r Request) string {
+		host := strings.ToLower(r.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		sub, ok := strings.CutSuffix(host, suffix)
+		if !ok || sub == "" || strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	})
+}
+
+// Verify 租户由 trusted (jwt claims 等) 决定, claimed (header 等客户端提供的) 不为空且与之不同时返回空
+// trusted 没有结果时返回空, 不会退回到 claimed
+//
+//	tenant.Verify(security.ClaimsTenant(), tenant.FromHeader(""))
+func Verify(trusted, claimed Resolver) Resolver {
+	return ResolverFunc(func(r Request) string {
+		id := trusted.Resolve(r)
+		if id == "" {
+			return ""
+		}
+		if other := claimed.Resolve(r); other != "" && other != id {
+			return ""
+		}
+		return id
+	})
+}
+
+// Chain 依次解析, 返回第一个不为空的结果
+// 不要把 FromHeader 放在 jwt claims 之后作为兜底: 没有 claims 时租户由客户端决定, 使用 Verify
+func Chain(resolvers ...Resolver) Resolver {
+	return ResolverFunc(func(r Request) string {
+		for _, res := range resolvers {
+			if id := res.Resolve(r); id != "" {
+				return id
+			}
+		}
+		return ""
+	})
+}
@@ -0,0 +1,89 @@
+// Package tenant 多租户
+/*
+	租户 ID 由中间件解析 (见 Resolver) 后保存在 ctx 中, 并作为日志字段 tenant 输出
+	从 jwt claims 解析时中间件放在认证之后 (全局中间件在认证之前执行, 读不到 claims), header 只用于校验, 见 Verify
+
+		g := e.Group("/api", auth, middleware.Tenant(tenant.Verify(security.ClaimsTenant(), tenant.FromHeader("")), true))
+
+	使用 ctx 的组件自动按租户隔离:
+		1.db: 按列 (tenant_id 条件)、按 schema (schema.table)、按数据库 (DBManager.ForTenant), 见 db.TenantConfig
+		2.redis: key 添加租户前缀 (cache.RedisConf.Tenant)
+		3.本地缓存: cache.WithTenant(ctx, c)
+*/
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/bobacgo/kit/app/logger"
+)
+
+var (
+	// ErrMissing ctx 中没有租户
+	ErrMissing = errors.New("tenant is missing in context")
+	// ErrInvalid 租户 ID 格式错误
+	ErrInvalid = errors.New("invalid tenant id")
+)
+
+// 租户 ID 会用于 schema 名、数据源 key、缓存 key, 只允许字母、数字、_、-
+var idRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Valid 租户 ID 格式是否正确
+func Valid(id string) bool {
+	return idRe.MatchString(id)
+}
+
+type (
+	idKey   struct{}
+	skipKey struct{}
+)
+
+// WithID 保存租户 ID, 并添加日志字段 tenant
+func WithID(ctx context.Context, id string) (context.Context, error) {
+	if !Valid(id) {
+		return ctx, fmt.Errorf("%w: %q", ErrInvalid, id)
+	}
+	ctx = context.WithValue(ctx, idKey{}, id)
+	return logger.WithFields(ctx, slog.String(logger.FieldTenant, id)), nil
+}
+
+// FromContext ctx 中的租户 ID
+func FromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(idKey{}).(string)
+	return id, ok && id != ""
+}
+
+// ID ctx 中的租户 ID, 没有时为空
+func ID(ctx context.Context) string {
+	id, _ := FromContext(ctx)
+	return id
+}
+
+// Skip 跳过租户隔离 (e.g. 跨租户的统计、定时任务), 慎用
+func Skip(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+// Skipped 是否跳过租户隔离
+func Skipped(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	skip, _ := ctx.Value(skipKey{}).(bool)
+	return skip
+}
+
+// KeyPrefix 缓存 key 的租户前缀, 没有租户时为空
+func KeyPrefix(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok && !Skipped(ctx) {
+		return id + ":"
+	}
+	return ""
+}
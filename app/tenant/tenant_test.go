@@ -0,0 +1,70 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bobacgo/kit/app/logger"
+)
+
+func TestWithID(t *testing.T) {
+	ctx, err := WithID(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ID(ctx) != "t1" || logger.FieldValue(ctx, logger.FieldTenant) != "t1" || KeyPrefix(ctx) != "t1:" {
+		t.Fatal(ID(ctx), logger.FieldValue(ctx, logger.FieldTenant), KeyPrefix(ctx))
+	}
+	if KeyPrefix(Skip(ctx)) != "" || KeyPrefix(context.Background()) != "" {
+		t.Fatal("prefix without tenant")
+	}
+	for _, id := range []string{"", "a.b", "t1;drop", "../x"} {
+		if _, err := WithID(context.Background(), id); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%q: %v", id, err)
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	h := http.Header{}
+	h.Set(HeaderTenantID, "h1")
+	req := Request{Ctx: context.Background(), Header: h.Get, Host: "s1.example.com:8080"}
+
+	if id := FromHeader("").Resolve(req); id != "h1" {
+		t.Fatal(id)
+	}
+	if id := FromSubdomain("example.com").Resolve(req); id != "s1" {
+		t.Fatal(id)
+	}
+	for _, host := range []string{"example.com", "a.b.example.com", "s1.example.org"} {
+		if id := FromSubdomain("example.com").Resolve(Request{Host: host}); id != "" {
+			t.Errorf("%s: %s", host, id)
+		}
+	}
+	if id := Chain(FromHeader("X-Other"), FromSubdomain("example.com"), FromHeader("")).Resolve(req); id != "s1" {
+		t.Fatal(id)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	claims := func(id string) Resolver {
+		return ResolverFunc(func(Request) string { return id })
+	}
+	tests := []struct {
+		claims, header, want string
+	}{
+		{"t1", "", "t1"},
+		{"t1", "t1", "t1"},
+		{"t1", "t2", ""}, // header 与 claims 不同
+		{"", "t2", ""},   // 没有 claims 时不使用 header
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set(HeaderTenantID, tt.header)
+		if id := Verify(claims(tt.claims), FromHeader("")).Resolve(Request{Header: h.Get}); id != tt.want {
+			t.Errorf("claims %q header %q: got %q, want %q", tt.claims, tt.header, id, tt.want)
+		}
+	}
+}
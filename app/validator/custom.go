@@ -1,6 +1,9 @@
 package validator
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -11,6 +14,9 @@ func registerValidation(valid *validator.Validate) {
 	// “validate:duration”
 	// 验证时间格式 "300ms", "-1.5h" or "2h45m"
 	valid.RegisterValidation("duration", durationValid)
+	// “validate:single=Type file”
+	// 结构体切片中字段 Type 为 file 的元素最多一个
+	valid.RegisterValidation("single", singleValid)
 }
 
 func durationValid(fl validator.FieldLevel) bool {
@@ -21,3 +27,22 @@ func durationValid(fl validator.FieldLevel) bool {
 	_, err := time.ParseDuration(str)
 	return err == nil
 }
+
+func singleValid(fl validator.FieldLevel) bool {
+	name, value, _ := strings.Cut(fl.Param(), " ")
+	v := fl.Field()
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return true
+	}
+	n := 0
+	for i := 0; i < v.Len(); i++ {
+		e := reflect.Indirect(v.Index(i))
+		if e.Kind() != reflect.Struct {
+			continue
+		}
+		if f := e.FieldByName(name); f.IsValid() && fmt.Sprint(f.Interface()) == value {
+			n++
+		}
+	}
+	return n <= 1
+}
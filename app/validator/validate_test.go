@@ -2,10 +2,15 @@ package validator_test
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"testing"
 
+	"github.com/bobacgo/kit/app/logger/logtest"
 	"github.com/bobacgo/kit/app/validator"
 	"github.com/go-playground/locales/ja"
+	ut "github.com/go-playground/universal-translator"
+	v10 "github.com/go-playground/validator/v10"
 	jaTranslations "github.com/go-playground/validator/v10/translations/ja"
 )
 
@@ -91,3 +96,33 @@ func TestNestedStruct(t *testing.T) {
 		t.Error("Expected error for invalid slice data, got nil")
 	}
 }
+
+func TestSingle(t *testing.T) {
+	type sink struct{ Type string }
+	type config struct {
+		Sinks []sink `validate:"single=Type file"`
+	}
+	if err := validator.Struct(config{Sinks: []sink{{"file"}, {"http"}, {"http"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validator.Struct(config{Sinks: []sink{{"file"}, {"file"}}}); err == nil {
+		t.Error("expected error for two file sinks")
+	}
+}
+
+func TestAddTransError(t *testing.T) {
+	logtest.New(t)
+	validator.AddTrans(validator.TranslationLanguage{
+		Lt: ja.New(),
+		RegisterFunc: func(*v10.Validate, ut.Translator) error {
+			return errors.New("register failed")
+		},
+	})
+	logtest.RequireLogged(t, slog.LevelError, "[validator] registerTrans", "locale", "ja", "error", "register failed")
+
+	// 恢复默认翻译器, 避免影响其他测试
+	validator.AddTrans(validator.TranslationLanguage{
+		Lt:           ja.New(),
+		RegisterFunc: jaTranslations.RegisterDefaultTranslations,
+	})
+}
@@ -0,0 +1,54 @@
+// audit 审计日志工具
+//
+//	AUDIT_KEY=... go run ./cmd/audit verify -f ./logs/audit.log              // 校验哈希链文件, 检测篡改
+//	AUDIT_KEY=... go run ./cmd/audit verify -f ./logs/audit.log -head <hash> // 同时校验链头 (发现末尾记录被截断)
+//
+// 哈希链的密钥 (audit.file.key) 从环境变量 AUDIT_KEY 读取
+//
+// 校验失败时退出码为 1, 并输出第一处断开的行号
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bobacgo/kit/app/audit"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(cmd string, args []string) error {
+	switch cmd {
+	case "verify":
+		fs := flag.NewFlagSet("verify", flag.ExitOnError)
+		path := fs.String("f", "./logs/audit.log", "audit file path")
+		head := fs.String("head", "", "expected hash of the last record")
+		_ = fs.Parse(args)
+		res, err := audit.Verify(*path, []byte(os.Getenv("AUDIT_KEY")), *head)
+		if err != nil {
+			return fmt.Errorf("%s: %w (%d valid records)", *path, err, res.Count)
+		}
+		fmt.Fprintf(os.Stdout, "%s: ok, %d records, head %s\n", *path, res.Count, res.Head)
+		return nil
+	default:
+		usage()
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: audit <command> [flags]
+
+commands:
+  verify -f file [-head hash]   verify the hash chain of an audit file (key from $AUDIT_KEY)`)
+}
@@ -0,0 +1,65 @@
+// config 配置文件工具
+//
+//	go run ./cmd/config schema > config.schema.json  // 导出 JSON Schema (IDE 自动补全)
+//	go run ./cmd/config sample > config.yaml         // 导出带注释的示例配置
+//	go run ./cmd/config check -f ./config.yaml       // 离线校验配置文件 (CI 中使用)
+//
+// service 节点为应用自定义配置, 这里不做约束
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bobacgo/kit/app/conf"
+)
+
+type service = map[string]any
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(cmd string, args []string) error {
+	switch cmd {
+	case "schema":
+		data, err := conf.SchemaJSON[service]()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(os.Stdout, string(data))
+		return err
+	case "sample":
+		_, err := os.Stdout.Write(conf.SampleYAML[service]())
+		return err
+	case "check":
+		fs := flag.NewFlagSet("check", flag.ExitOnError)
+		path := fs.String("f", "./config.yaml", "config file path")
+		_ = fs.Parse(args)
+		if err := conf.Check[service](*path); err != nil {
+			return fmt.Errorf("%s: %w", *path, err)
+		}
+		fmt.Fprintf(os.Stdout, "%s: ok\n", *path)
+		return nil
+	default:
+		usage()
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: config <command> [flags]
+
+commands:
+  schema          print JSON Schema of the app config
+  sample          print a commented sample config (yaml)
+  check -f file   validate a config file offline`)
+}
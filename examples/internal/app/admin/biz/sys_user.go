@@ -9,21 +9,17 @@ import (
 type sysUserServiceImpl struct{}
 
 func (svc *sysUserServiceImpl) PageList(query model.PageQuery) (*page.Data[model.SysUser], error) {
-	list, err := dao.SysUser.PageList(query)
-	return list, err
+	return dao.SysUser.PageList(query)
 }
 
 func (svc *sysUserServiceImpl) Create(user model.SysUser) error {
-	// TODO implement me
-	panic("implement me")
+	return dao.SysUser.Create(user)
 }
 
 func (svc *sysUserServiceImpl) Update(user model.SysUser) error {
-	// TODO implement me
-	panic("implement me")
+	return dao.SysUser.Update(user)
 }
 
 func (svc *sysUserServiceImpl) Delete(id int) error {
-	// TODO implement me
-	panic("implement me")
+	return dao.SysUser.Delete(id)
 }
@@ -3,6 +3,17 @@ package dao
 import (
 	"github.com/bobacgo/kit/examples/internal/app/admin/model"
 	"github.com/bobacgo/kit/g"
+	"github.com/bobacgo/kit/web/orm"
+	"gorm.io/gorm"
 )
 
-var SysUser g.IBase[model.SysUser, model.PageQuery] = new(sysUserDaoImpl)
\ No newline at end of file
+var SysUser g.IBase[model.SysUser, model.PageQuery]
+
+// Init 创建数据表并初始化 dao, 注册路由时调用
+func Init(gdb *gorm.DB) error {
+	if err := gdb.AutoMigrate(model.Tables...); err != nil {
+		return err
+	}
+	SysUser = &sysUserDaoImpl{repo: orm.NewRepository[model.SysUser](gdb)}
+	return nil
+}
@@ -1,34 +1,42 @@
 package dao
 
 import (
+	"context"
+
 	"github.com/bobacgo/kit/examples/internal/app/admin/model"
+	"github.com/bobacgo/kit/web/orm"
 	"github.com/bobacgo/kit/web/r/page"
 )
 
-type sysUserDaoImpl struct{}
+var (
+	sysUserName     = orm.Field[string]("username")
+	sysUserNickname = orm.Field[string]("nickname")
+)
+
+// sysUserDaoImpl 使用 orm.Repository 实现 g.IBase
+type sysUserDaoImpl struct {
+	repo *orm.Repository[model.SysUser]
+}
 
 func (dao *sysUserDaoImpl) PageList(query model.PageQuery) (*page.Data[model.SysUser], error) {
-	_ = query
-	users := []model.SysUser{
-		{"weilanjin", "abc123", "lanjin.wei"},
-		{"gogo", "abc123", "gogo"},
+	var specs []orm.Spec
+	if query.Username != "" {
+		specs = append(specs, sysUserName.Like(query.Username+"%"))
 	}
-
-	data := page.New(int64(len(users)), users...)
-	return data, nil
+	if query.Nickname != "" {
+		specs = append(specs, sysUserNickname.Like(query.Nickname+"%"))
+	}
+	return dao.repo.Page(context.Background(), query.Query, specs...)
 }
 
 func (dao *sysUserDaoImpl) Create(user model.SysUser) error {
-	// TODO implement me
-	panic("implement me")
+	return dao.repo.Create(context.Background(), &user)
 }
 
 func (dao *sysUserDaoImpl) Update(user model.SysUser) error {
-	// TODO implement me
-	panic("implement me")
+	return dao.repo.Update(context.Background(), &user)
 }
 
 func (dao *sysUserDaoImpl) Delete(id int) error {
-	// TODO implement me
-	panic("implement me")
+	return dao.repo.Delete(context.Background(), id)
 }
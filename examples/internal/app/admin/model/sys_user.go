@@ -6,7 +6,8 @@ import (
 )
 
 type SysUser struct {
-	Username string `json:"username"`
+	ID       int    `json:"id" gorm:"primaryKey"`
+	Username string `json:"username" gorm:"size:64;uniqueIndex"`
 	Passcode string `json:"-"`
 	Nickname string `json:"nickname"`
 }
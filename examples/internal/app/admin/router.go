@@ -1,12 +1,18 @@
 package admin
 
 import (
+	"log"
+
 	"github.com/bobacgo/kit/app"
+	"github.com/bobacgo/kit/examples/internal/app/admin/dao"
 	"github.com/bobacgo/kit/examples/internal/app/admin/handler"
 	"github.com/gin-gonic/gin"
 )
 
 func Register(r *gin.RouterGroup, app *app.AppOptions) {
+	if err := dao.Init(app.DB().Default()); err != nil {
+		log.Panicf("init admin dao failed: %v", err)
+	}
 
 	userHandler := handler.NewUserHandler()
 	// sys user
@@ -1,6 +1,7 @@
 package g
 
 import (
+	"github.com/bobacgo/kit/web/orm"
 	"github.com/bobacgo/kit/web/r/errs"
 	"github.com/pkg/errors"
 	"golang.org/x/exp/maps"
@@ -9,14 +10,13 @@ import (
 
 type FindByIDService[T any] struct{}
 
+// FindByID 记录不存在时返回 errs.NotFound
+//
+// Deprecated: 使用 orm.Repository
 func (*FindByIDService[T]) FindByID(tx *gorm.DB, id string) (T, error) {
 	var m T
 	err := tx.Where("id = ?", id).First(&m).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		// return m, WrapError(err, r.FailRecordNotFound)
-	}
-	// return m, WrapError(err, r.FailRead)
-	return m, nil
+	return m, orm.Error(err)
 }
 
 // UniqueService 校验传入值是否已经在数据库中存在
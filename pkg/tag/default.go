@@ -1,142 +1,300 @@
 package tag
 
 import (
+	"encoding"
+	"errors"
 	"fmt"
-	"golang.org/x/exp/slices"
 	"log/slog"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 )
 
-// Default 处理默认赋值的标签
+// Defaulter 自定义默认值钩子
+// 字段的 default 标签处理完成后调用, 用于设置依赖其他字段或无法用标签表达的默认值
+type Defaulter interface {
+	SetDefaults()
+}
+
+// checker 自带格式校验的类型 (e.g. types.Duration、types.ByteSize)
+type checker interface {
+	Check() error
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	defaulterType       = reflect.TypeOf((*Defaulter)(nil)).Elem()
+	checkerType         = reflect.TypeOf((*checker)(nil)).Elem()
+)
+
+// Default 处理默认赋值的标签, 返回填充后的新对象 (不会修改入参)
+// 解析出错的字段会输出警告日志并保持原值
+//
+// 支持的类型:
+//
+//	bool、int*、uint*、float*、string
+//	time.Duration `default:"5s"`
+//	types.Duration `default:"5s"`、types.ByteSize `default:"512MB"` (通过 Check() 校验格式)
+//	slice/array   `default:"a,b,c"` (逗号分隔)
+//	map           `default:"k1:v1,k2:v2"`
+//	pointer       有 default 标签时分配内存并填充 (结构体指针可用 `default:"{}"`)
+//	encoding.TextUnmarshaler 以及 Defaulter 钩子
+//
+// 嵌套的 struct、pointer、slice、array、map 中的元素会递归处理
 func Default[T any](data T) T {
-	val := reflect.ValueOf(data)
-	if val.IsZero() {
-		return data
-	}
-	isPtr := val.Kind() == reflect.Ptr
-	if isPtr {
-		val = val.Elem()
-	}
-	if val.Kind() != reflect.Struct { // 如果不是结构体，直接返回
-		return data
+	if err := SetDefault(&data); err != nil {
+		slog.Warn("default tag value parse error", "err", err)
 	}
-	// 创建一个新的同类型对象
-	newVal := reflect.New(val.Type()).Elem()
-
-	// 递归处理结构体字段
-	new(defaultTag).parseStruct(val, newVal)
+	return data
+}
 
-	// 返回新的对象
-	if isPtr {
-		return newVal.Addr().Interface().(T)
+// SetDefault 原地填充 ptr 指向对象中的默认值
+// 为了不影响原对象, 遍历时 pointer、slice、map 都会被复制一份
+func SetDefault(ptr any) error {
+	val := reflect.ValueOf(ptr)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return errors.New("tag: SetDefault requires a non-nil pointer")
 	}
-	return newVal.Interface().(T)
+	d := new(defaultTag)
+	d.walk(val.Elem(), "")
+	return errors.Join(d.errs...)
+}
+
+type defaultTag struct {
+	errs []error
 }
 
-type defaultTag struct{}
-
-// parseStruct 只处理 struct、ptr、map、slice、array
-func (t *defaultTag) parseStruct(src, dst reflect.Value) {
-	switch src.Kind() {
-	case reflect.Struct: // 处理结构体
-		for i := 0; i < src.NumField(); i++ {
-			field := src.Field(i)
-			structField := src.Type().Field(i)
-			newField := reflect.New(field.Type()).Elem()
-
-			if !slices.Contains(basicKind, src.Kind()) {
-				t.parseStruct(field, newField)
-			} else {
-				t.tagValue(field, newField, structField)
+// walk 递归处理 v (必须可寻址)
+// path 为字段路径, 用于错误提示
+func (t *defaultTag) walk(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		typ := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() { // 未导出字段
+				continue
 			}
-			dst.Field(i).Set(newField)
+			sf := typ.Field(i)
+			t.field(field, sf, joinPath(path, sf.Name))
 		}
-	case reflect.Ptr: // 处理接口类型和指针类型
-		if src.IsNil() {
+	case reflect.Ptr:
+		if v.IsNil() {
 			return
 		}
-		elem := src.Elem()
-		newElement := reflect.New(elem.Type()).Elem()
-		t.parseStruct(elem, newElement)
-		dst.Set(newElement.Addr())
-	case reflect.Slice, reflect.Array: // 处理 Slice 和 Array
-		if src.Kind() == reflect.Slice && src.IsNil() {
+		elem := reflect.New(v.Type().Elem()) // 复制一份, 避免修改原对象
+		elem.Elem().Set(v.Elem())
+		t.walk(elem.Elem(), path)
+		v.Set(elem)
+	case reflect.Slice:
+		if v.IsNil() {
 			return
 		}
-		// 创建一个新的 Slice 或 Array
-		var newContainer reflect.Value
-		if src.Kind() == reflect.Slice {
-			newContainer = reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
-		} else {
-			newContainer = reflect.New(src.Type()).Elem()
-		}
-		for i := 0; i < src.Len(); i++ {
-			// 递归处理 Slice 或 Array 的每个元素
-			element := src.Index(i)
-			newElement := reflect.New(element.Type()).Elem()
-			t.parseStruct(element, newElement)
-			newContainer.Index(i).Set(newElement)
-		}
-		dst.Set(newContainer)
-	// 创建一个新的 Slice 或 Array
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(cp, v)
+		for i := 0; i < cp.Len(); i++ {
+			t.walk(cp.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+		v.Set(cp)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			t.walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
 	case reflect.Map:
-		if src.IsNil() {
+		if v.IsNil() {
 			return
 		}
-		// 创建一个新的 Map
-		newMap := reflect.MakeMap(src.Type())
-		for _, key := range src.MapKeys() {
-			// 递归处理 Map 的每个值
-			value := src.MapIndex(key)
-			newValue := reflect.New(value.Type()).Elem()
-			t.parseStruct(value, newValue)
-			newMap.SetMapIndex(key, newValue)
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			// map 的值不可寻址, 复制出来处理后再写回
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			t.walk(elem, fmt.Sprintf("%s[%v]", path, iter.Key()))
+			cp.SetMapIndex(iter.Key(), elem)
+		}
+		v.Set(cp)
+	case reflect.Interface:
+		if v.IsNil() {
+			return
 		}
-		dst.Set(newMap)
+		// 接口中的值不可寻址, 复制出来处理后再写回
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		t.walk(elem, path)
+		v.Set(elem)
+		return
 	default:
-		dst.Set(src)
+		return
 	}
+	t.callDefaulter(v)
 }
 
-func (t *defaultTag) tagValue(src, dst reflect.Value, structField reflect.StructField) {
-	// 检查是否有 default 标签
-	tagValue, ok := structField.Tag.Lookup("default")
-	if !ok || tagValue == "" {
-		dst.Set(src)
+// field 处理结构体字段
+// 1.零值且有 default 标签 -> 按标签赋值
+// 2.其他情况 -> 递归处理嵌套的元素
+func (t *defaultTag) field(field reflect.Value, sf reflect.StructField, path string) {
+	tagValue, ok := sf.Tag.Lookup("default")
+	if !ok || tagValue == "" || !field.IsZero() {
+		t.walk(field, path)
+		return
+	}
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := t.fillPtrElem(elem.Elem(), tagValue, path); err != nil {
+			t.errs = append(t.errs, err)
+			return
+		}
+		field.Set(elem)
 		return
 	}
-	if !src.IsZero() { // 如果源值不为零，则不设置默认值
+	if field.Kind() == reflect.Struct && !isTextUnmarshaler(field) {
+		t.walk(field, path) // 结构体的 default 标签没有意义, 按字段处理
 		return
 	}
-	if err := t.set(dst, src.Kind(), tagValue); err != nil {
-		slog.Warn("default tag value parse error", "err", err)
+	if err := t.set(field, tagValue); err != nil {
+		t.errs = append(t.errs, fmt.Errorf("%s: %w", path, err))
+		return
+	}
+	t.walk(field, path)
+}
+
+// fillPtrElem 指针字段: 结构体按字段填充, 其他类型按标签赋值
+func (t *defaultTag) fillPtrElem(elem reflect.Value, tagValue, path string) error {
+	if elem.Kind() == reflect.Struct && !isTextUnmarshaler(elem) {
+		t.walk(elem, path)
+		return nil
 	}
+	if err := t.set(elem, tagValue); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
 }
 
-func (t *defaultTag) set(dst reflect.Value, kind reflect.Kind, val string) error {
-	switch kind {
+// set 将字符串 val 解析为 dst 的类型并赋值
+func (t *defaultTag) set(dst reflect.Value, val string) error {
+	if isTextUnmarshaler(dst) {
+		return dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+	if dst.Type() == durationType {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("to Duration: %w", err)
+		}
+		dst.SetInt(int64(d))
+		return nil
+	}
+
+	switch dst.Kind() {
 	case reflect.Bool:
-		dstVal, err := strconv.ParseBool(val)
+		b, err := strconv.ParseBool(val)
 		if err != nil {
-			return fmt.Errorf("to Bool: %v", err)
+			return fmt.Errorf("to Bool: %w", err)
 		}
-		dst.SetBool(dstVal)
+		dst.SetBool(b)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		dstVal, err := strconv.ParseInt(val, 10, dst.Type().Bits())
+		n, err := strconv.ParseInt(val, 10, dst.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("to Int: %w", err)
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(val, 10, dst.Type().Bits())
 		if err != nil {
-			return fmt.Errorf("to Int: %v", err)
+			return fmt.Errorf("to Uint: %w", err)
 		}
-		dst.SetInt(dstVal)
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		dstVal, err := strconv.ParseUint(val, 10, dst.Type().Bits())
+		dst.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, dst.Type().Bits())
 		if err != nil {
-			return fmt.Errorf("to Uint: %v", err)
+			return fmt.Errorf("to Float: %w", err)
 		}
-		dst.SetUint(dstVal)
+		dst.SetFloat(n)
 	case reflect.String:
+		if dst.Type().Implements(checkerType) { // types.Duration、types.ByteSize 格式校验
+			if err := reflect.ValueOf(val).Convert(dst.Type()).Interface().(checker).Check(); err != nil {
+				return fmt.Errorf("to %s: %w", dst.Type().Name(), err)
+			}
+		}
 		dst.SetString(val)
+	case reflect.Slice:
+		items := splitList(val)
+		s := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := t.set(s.Index(i), item); err != nil {
+				return fmt.Errorf("to Slice[%d]: %w", i, err)
+			}
+		}
+		dst.Set(s)
+	case reflect.Array:
+		items := splitList(val)
+		if len(items) > dst.Len() {
+			return fmt.Errorf("to Array: %d items overflow length %d", len(items), dst.Len())
+		}
+		for i, item := range items {
+			if err := t.set(dst.Index(i), item); err != nil {
+				return fmt.Errorf("to Array[%d]: %w", i, err)
+			}
+		}
+	case reflect.Map:
+		items := splitList(val)
+		m := reflect.MakeMapWithSize(dst.Type(), len(items))
+		for _, item := range items {
+			k, v, ok := strings.Cut(item, ":")
+			if !ok {
+				return fmt.Errorf("to Map: invalid item %q, want key:value", item)
+			}
+			key := reflect.New(dst.Type().Key()).Elem()
+			if err := t.set(key, strings.TrimSpace(k)); err != nil {
+				return fmt.Errorf("to Map key: %w", err)
+			}
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := t.set(elem, strings.TrimSpace(v)); err != nil {
+				return fmt.Errorf("to Map[%s]: %w", k, err)
+			}
+			m.SetMapIndex(key, elem)
+		}
+		dst.Set(m)
+	case reflect.Ptr:
+		elem := reflect.New(dst.Type().Elem())
+		if err := t.set(elem.Elem(), val); err != nil {
+			return err
+		}
+		dst.Set(elem)
 	default:
+		return fmt.Errorf("unsupported kind %s", dst.Kind())
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// callDefaulter 调用 Defaulter 钩子
+func (t *defaultTag) callDefaulter(v reflect.Value) {
+	if v.CanAddr() && v.Addr().Type().Implements(defaulterType) {
+		v.Addr().Interface().(Defaulter).SetDefaults()
+	}
+}
+
+func isTextUnmarshaler(v reflect.Value) bool {
+	return v.CanAddr() && reflect.PointerTo(v.Type()).Implements(textUnmarshalerType)
+}
+
+// splitList 按逗号拆分并去除空白, 空字符串返回空列表
+func splitList(val string) []string {
+	if strings.TrimSpace(val) == "" {
+		return nil
+	}
+	items := strings.Split(val, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,220 @@
+package tag
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobacgo/kit/app/types"
+)
+
+type scalars struct {
+	Bool    bool          `default:"true"`
+	Int     int           `default:"-1"`
+	Int8    int8          `default:"8"`
+	Uint16  uint16        `default:"16"`
+	Float32 float32       `default:"1.5"`
+	Float64 float64       `default:"3.14"`
+	String  string        `default:"hello"`
+	TimeDur time.Duration `default:"1m30s"`
+	NoTag   int
+}
+
+type special struct {
+	Duration types.Duration `default:"5s"`
+	ByteSize types.ByteSize `default:"512MB"`
+	IP       net.IP         `default:"127.0.0.1"` // encoding.TextUnmarshaler
+}
+
+type containers struct {
+	Strings []string          `default:"a, b ,c"`
+	Ints    []int             `default:"1,2,3"`
+	Array   [3]uint8          `default:"1,2"`
+	Map     map[string]int    `default:"a:1,b:2"`
+	Durs    map[string]string `default:"read:1s,write:2s"`
+}
+
+type pointers struct {
+	Int    *int            `default:"10"`
+	String *string         `default:"ptr"`
+	Nested *nested         `default:"{}"` // 结构体指针分配后按字段填充
+	NilPtr *nested         // 没有标签的 nil 指针保持 nil
+	Dur    *types.Duration `default:"3s"`
+}
+
+type nested struct {
+	Addr    string         `default:"0.0.0.0:80"`
+	Timeout types.Duration `default:"1s"`
+}
+
+type outer struct {
+	Inner   nested
+	Ptr     *nested
+	List    []nested
+	Map     map[string]nested
+	MapPtr  map[string]*nested
+	private string `default:"skip"`
+}
+
+type hooked struct {
+	Host string `default:"localhost"`
+	Port int
+	Addr string
+}
+
+func (h *hooked) SetDefaults() {
+	if h.Port == 0 {
+		h.Port = 8080
+	}
+	if h.Addr == "" {
+		h.Addr = h.Host + ":" + "8080"
+	}
+}
+
+type hookedParent struct {
+	Items map[string]hooked
+}
+
+type invalid struct {
+	Int      int            `default:"abc"`
+	Duration types.Duration `default:"5x"`
+	ByteSize types.ByteSize `default:"12XB"`
+	Map      map[string]int `default:"a=1"`
+	Ok       string         `default:"ok"`
+}
+
+func intPtr(i int) *int       { return &i }
+func strPtr(s string) *string { return &s }
+func durPtr(d types.Duration) *types.Duration {
+	return &d
+}
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want any
+	}{
+		{
+			name: "scalars",
+			in:   &scalars{},
+			want: &scalars{Bool: true, Int: -1, Int8: 8, Uint16: 16, Float32: 1.5, Float64: 3.14, String: "hello", TimeDur: 90 * time.Second},
+		},
+		{
+			name: "scalars keep non-zero",
+			in:   &scalars{Int: 5, String: "set", TimeDur: time.Second},
+			want: &scalars{Bool: true, Int: 5, Int8: 8, Uint16: 16, Float32: 1.5, Float64: 3.14, String: "set", TimeDur: time.Second},
+		},
+		{
+			name: "special types",
+			in:   &special{},
+			want: &special{Duration: "5s", ByteSize: "512MB", IP: net.ParseIP("127.0.0.1")},
+		},
+		{
+			name: "containers",
+			in:   &containers{},
+			want: &containers{
+				Strings: []string{"a", "b", "c"},
+				Ints:    []int{1, 2, 3},
+				Array:   [3]uint8{1, 2, 0},
+				Map:     map[string]int{"a": 1, "b": 2},
+				Durs:    map[string]string{"read": "1s", "write": "2s"},
+			},
+		},
+		{
+			name: "containers keep non-zero",
+			in:   &containers{Strings: []string{"x"}, Map: map[string]int{"z": 0}},
+			want: &containers{
+				Strings: []string{"x"},
+				Ints:    []int{1, 2, 3},
+				Array:   [3]uint8{1, 2, 0},
+				Map:     map[string]int{"z": 0},
+				Durs:    map[string]string{"read": "1s", "write": "2s"},
+			},
+		},
+		{
+			name: "pointers",
+			in:   &pointers{},
+			want: &pointers{
+				Int:    intPtr(10),
+				String: strPtr("ptr"),
+				Nested: &nested{Addr: "0.0.0.0:80", Timeout: "1s"},
+				Dur:    durPtr("3s"),
+			},
+		},
+		{
+			name: "nested struct, pointer, slice and map",
+			in: &outer{
+				Ptr:    &nested{Addr: "127.0.0.1:80"},
+				List:   []nested{{}, {Timeout: "2s"}},
+				Map:    map[string]nested{"default": {}},
+				MapPtr: map[string]*nested{"default": {Addr: ":9090"}, "nil": nil},
+			},
+			want: &outer{
+				Inner:  nested{Addr: "0.0.0.0:80", Timeout: "1s"},
+				Ptr:    &nested{Addr: "127.0.0.1:80", Timeout: "1s"},
+				List:   []nested{{Addr: "0.0.0.0:80", Timeout: "1s"}, {Addr: "0.0.0.0:80", Timeout: "2s"}},
+				Map:    map[string]nested{"default": {Addr: "0.0.0.0:80", Timeout: "1s"}},
+				MapPtr: map[string]*nested{"default": {Addr: ":9090", Timeout: "1s"}, "nil": nil},
+			},
+		},
+		{
+			name: "non-pointer struct",
+			in:   nested{},
+			want: nested{Addr: "0.0.0.0:80", Timeout: "1s"},
+		},
+		{
+			name: "defaulter hook",
+			in:   &hookedParent{Items: map[string]hooked{"a": {}, "b": {Port: 1}}},
+			want: &hookedParent{Items: map[string]hooked{
+				"a": {Host: "localhost", Port: 8080, Addr: "localhost:8080"},
+				"b": {Host: "localhost", Port: 1, Addr: "localhost:8080"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Default(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Default() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultNotModifyInput(t *testing.T) {
+	in := &outer{
+		Ptr:  &nested{},
+		List: []nested{{}},
+		Map:  map[string]nested{"default": {}},
+	}
+	_ = Default(in)
+	if in.Inner.Addr != "" || in.Ptr.Addr != "" || in.List[0].Addr != "" || in.Map["default"].Addr != "" {
+		t.Errorf("input modified: %+v", in)
+	}
+}
+
+func TestSetDefaultErrors(t *testing.T) {
+	v := &invalid{}
+	err := SetDefault(v)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, field := range []string{"Int", "Duration", "ByteSize", "Map"} {
+		if !strings.Contains(err.Error(), field+":") {
+			t.Errorf("error should mention field %s: %v", field, err)
+		}
+	}
+	if v.Ok != "ok" {
+		t.Errorf("valid fields should still be filled, got %q", v.Ok)
+	}
+	if v.Int != 0 || v.Duration != "" {
+		t.Errorf("invalid fields should keep zero value: %+v", v)
+	}
+
+	if err := SetDefault(invalid{}); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+}
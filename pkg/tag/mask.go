@@ -44,9 +44,13 @@ type maskTag struct{}
 func (t *maskTag) parseStruct(src, dst reflect.Value, structField reflect.StructField) {
 	switch src.Kind() {
 	case reflect.Struct: // 处理结构体
+		dst.Set(src) // 先整体复制, 保留未导出字段 (e.g. time.Time)
 		for i := 0; i < src.NumField(); i++ {
-			field := src.Field(i)
 			structField = src.Type().Field(i)
+			if !structField.IsExported() { // 未导出字段无法赋值
+				continue
+			}
+			field := src.Field(i)
 			newField := reflect.New(field.Type()).Elem()
 			t.set(field, newField, structField)
 			dst.Field(i).Set(newField)
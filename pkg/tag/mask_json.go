@@ -0,0 +1,103 @@
+package tag
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// MaskRules 收集结构体中带 mask 标签的字段, 返回 json 字段名 -> 脱敏规则
+// 用于对原始 json 数据 (e.g. 请求包体) 按结构体的 mask 标签脱敏
+func MaskRules(v any) map[string]string {
+	rules := make(map[string]string)
+	typ := reflect.TypeOf(v)
+	if typ == nil {
+		return rules
+	}
+	collectMaskRules(typ, rules, make(map[reflect.Type]bool))
+	return rules
+}
+
+func collectMaskRules(typ reflect.Type, rules map[string]string, visited map[reflect.Type]bool) {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || visited[typ] {
+		return
+	}
+	visited[typ] = true
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if rule, ok := field.Tag.Lookup("mask"); ok {
+			rules[name] = rule
+			continue
+		}
+		collectMaskRules(field.Type, rules, visited)
+	}
+}
+
+// MaskJSON 按字段名对 json 数据脱敏 (任意层级)
+// 字符串值按规则脱敏 (同 mask 标签), 其他类型的值替换为 "***"
+// data 不是合法的 json 时原样返回
+func MaskJSON(data []byte, rules map[string]string) []byte {
+	if len(rules) == 0 || len(data) == 0 {
+		return data
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return data
+	}
+	out, err := json.Marshal(maskJSONValue(v, rules))
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+func maskJSONValue(v any, rules map[string]string) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			rule, ok := rules[k]
+			if !ok {
+				val[k] = maskJSONValue(item, rules)
+				continue
+			}
+			val[k] = maskJSONField(item, rule)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = maskJSONValue(item, rules)
+		}
+	}
+	return v
+}
+
+func maskJSONField(v any, rule string) any {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return new(maskTag).maskString(val, rule)
+	case []any:
+		for i, item := range val {
+			val[i] = maskJSONField(item, rule)
+		}
+		return val
+	default:
+		return "***"
+	}
+}
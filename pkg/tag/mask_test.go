@@ -3,6 +3,7 @@ package tag
 import (
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestMaskTag(t *testing.T) {
@@ -68,4 +69,24 @@ func TestMaskTag(t *testing.T) {
 	// 原始对象未被修改
 	fmt.Println("Original object (unchanged):")
 	fmt.Printf("%+v\n", user)
-}
\ No newline at end of file
+}
+func TestDesensitizeUnexported(t *testing.T) {
+	type Event struct {
+		Email string    `json:"email" mask:""`
+		At    time.Time `json:"at"` // time.Time 只有未导出字段
+		note  string
+	}
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	e := Event{Email: "john.doe@example.com", At: at, note: "n"}
+
+	masked := Desensitize(e)
+	if masked.Email == e.Email {
+		t.Errorf("email not masked: %s", masked.Email)
+	}
+	if !masked.At.Equal(at) || masked.note != "n" {
+		t.Errorf("unexported fields lost: %+v", masked)
+	}
+	if p := Desensitize(&e); p.Email == e.Email || !p.At.Equal(at) {
+		t.Errorf("pointer: %+v", p)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 )
 
@@ -91,3 +93,39 @@ func pkcs7UnPadding(plaintext []byte) []byte {
 	unp := int(plaintext[l-1])
 	return plaintext[:(l - unp)]
 }
+
+// --- AES-GCM 加密解密 (带认证, 用于数据存储) ---
+
+// AESGCMEncrypt 返回 nonce + 密文, key 长度必须 16|24|32, aad 为附加认证数据 (不加密, 解密时必须相同)
+func AESGCMEncrypt(plaintext, key, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// AESGCMDecrypt 解密 AESGCMEncrypt 的结果, 密文或 aad 被修改时返回错误
+func AESGCMDecrypt(ciphertext, key, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ct := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ct, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	bl, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("key 长度必须 16|24|32长度: %s", err)
+	}
+	return cipher.NewGCM(bl)
+}
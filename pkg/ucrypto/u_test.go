@@ -2,6 +2,8 @@ package ucrypto_test
 
 import (
 	"encoding/base64"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/bobacgo/kit/pkg/ucrypto"
@@ -38,6 +40,21 @@ func TestAES(t *testing.T) {
 	t.Log(plaintext)
 }
 
+func TestAESGCM(t *testing.T) {
+	key := []byte("12345678901234567890123456789012")
+	ciphertext, err := ucrypto.AESGCMEncrypt([]byte("hello world"), key, []byte("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := ucrypto.AESGCMDecrypt(ciphertext, key, []byte("v1"))
+	if err != nil || string(plaintext) != "hello world" {
+		t.Fatal(string(plaintext), err)
+	}
+	if _, err := ucrypto.AESGCMDecrypt(ciphertext, key, []byte("v2")); err == nil {
+		t.Fatal("aad mismatch should fail")
+	}
+}
+
 func TestDES(t *testing.T) {
 	key := "12345678" // 8 位
 	raw := "hello world"
@@ -57,7 +74,13 @@ func TestDES(t *testing.T) {
 }
 
 func TestKeyPairs(t *testing.T) {
-	ucrypto.KeyPairs("rsa")
+	name := filepath.Join(t.TempDir(), "rsa")
+	ucrypto.KeyPairs(name)
+	for _, f := range []string{name + ".private.pem", name + ".public.pem"} {
+		if _, err := os.Stat(f); err != nil {
+			t.Fatal(err)
+		}
+	}
 }
 
 // 私钥生成
@@ -0,0 +1,386 @@
+package orm
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bobacgo/kit/app/security"
+	"github.com/bobacgo/kit/app/tenant"
+	"github.com/bobacgo/kit/pkg/ucrypto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 字段加密 (敏感数据落库加密)
+/*
+	// app.New 按 security.ciphertext 设置密钥, 配置文件变化和密钥轮换后自动更新; 不使用 app 时自行设置
+	keyring, err := orm.NewKeyring(conf.Security.Ciphertext)
+	orm.SetKeyring(keyring)
+
+	type User struct {
+		orm.Model
+		Phone    orm.Encrypted[string] `gorm:"type:text"`      // AES-GCM 加密, 读取时自动解密
+		PhoneIdx orm.Hashed            `gorm:"size:64;index"` // Phone 的盲索引, 用于等值查询
+	}
+
+	u.Phone = orm.Encrypt("13800000000")
+	u.PhoneIdx, err = u.Phone.Index()
+	idx, err := orm.Hash("13800000000")
+	db.Where("phone_idx = ?", idx).First(&u)
+
+	1.密文格式为 {版本}:{base64(nonce + 密文)}, 版本作为附加认证数据, 每次加密的结果不同, 不能用于查询
+	2.密钥轮换: 添加新版本的密钥并修改 dataKeyVersion, 旧版本保留用于解密, 使用 Rotate 重新加密后删除
+	3.盲索引为明文的 HMAC-SHA256, 相同明文的索引相同, 只支持等值查询; 需要忽略大小写等时在计算前自行归一化
+	4.没有设置密钥时读写都返回 ErrNoKeyring, 不会写入明文
+	5.NULL 读取为零值, 保存时仍为 NULL; 需要保存零值时使用 Encrypt (e.g. orm.Encrypt(""))
+*/
+
+var (
+	// ErrNoKeyring 没有设置字段加密的密钥
+	ErrNoKeyring = errors.New("data keys are not configured, see orm.SetKeyring")
+	// ErrNoIndexKey 没有配置盲索引的密钥
+	ErrNoIndexKey = errors.New("blind index key is not configured")
+)
+
+var keyring atomic.Pointer[Keyring]
+
+// Keyring 字段加密的密钥
+type Keyring struct {
+	keys   map[string][]byte // 版本 -> 密钥
+	active string            // 加密使用的版本
+	index  []byte            // 盲索引的密钥
+}
+
+// NewKeyring 使用 security.CiphertextConfig 的 dataKeys、dataKeyVersion、indexKey
+// 版本名不区分大小写 (viper 读取的 map key 为小写), 统一转换为小写
+func NewKeyring(conf security.CiphertextConfig) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string][]byte, len(conf.DataKeys)), active: strings.ToLower(conf.DataKeyVersion), index: []byte(conf.IndexKey)}
+	for version, key := range conf.DataKeys {
+		if version == "" || strings.Contains(version, ":") {
+			return nil, fmt.Errorf("invalid data key version %q", version)
+		}
+		version = strings.ToLower(version)
+		if _, ok := k.keys[version]; ok {
+			return nil, fmt.Errorf("duplicate data key version %q", version)
+		}
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("data key %s: length must be 16, 24 or 32 bytes", version)
+		}
+		k.keys[version] = []byte(key)
+	}
+	if _, ok := k.keys[k.active]; !ok {
+		return nil, fmt.Errorf("data key version %q not found", k.active)
+	}
+	return k, nil
+}
+
+// SetKeyring 设置全局的密钥, 可以在密钥轮换后重新设置
+func SetKeyring(k *Keyring) {
+	keyring.Store(k)
+}
+
+func currentKeyring() (*Keyring, error) {
+	k := keyring.Load()
+	if k == nil {
+		return nil, ErrNoKeyring
+	}
+	return k, nil
+}
+
+// Encrypt 使用当前版本的密钥加密
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	ct, err := ucrypto.AESGCMEncrypt(plaintext, k.keys[k.active], []byte(k.active))
+	if err != nil {
+		return "", err
+	}
+	return k.active + ":" + base64.StdEncoding.EncodeToString(ct), nil
+}
+
+// Decrypt 按密文中的版本选择密钥解密
+func (k *Keyring) Decrypt(ciphertext string) (plaintext []byte, version string, err error) {
+	version, data, ok := strings.Cut(ciphertext, ":")
+	if !ok {
+		return nil, "", errors.New("invalid ciphertext: missing key version")
+	}
+	key, ok := k.keys[strings.ToLower(version)]
+	if !ok {
+		return nil, version, fmt.Errorf("data key version %q not found", version)
+	}
+	ct, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, version, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	if plaintext, err = ucrypto.AESGCMDecrypt(ct, key, []byte(version)); err != nil {
+		return nil, version, fmt.Errorf("decrypt with key %s: %w", version, err)
+	}
+	return plaintext, version, nil
+}
+
+// Hash 盲索引
+func (k *Keyring) Hash(plaintext string) (Hashed, error) {
+	if len(k.index) == 0 {
+		return "", ErrNoIndexKey
+	}
+	// 不使用 ucrypto.SHA256: 空字符串也需要索引 (Encrypt("") 可以查询)
+	h := hmac.New(sha256.New, k.index)
+	h.Write([]byte(plaintext))
+	return Hashed(hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// Encrypted 加密存储的字段, 写入时使用当前版本的密钥加密, 读取时解密
+// T 为 string、[]byte 时直接加密, 其他类型加密 JSON; 序列化 JSON 时为明文, 日志中为 ******
+// 读取时为 NULL 或没有设置值 (零值且不是 Encrypt 创建的) 时写入 NULL, 见 Null
+type Encrypted[T any] struct {
+	V          T
+	valid      bool   // 由 Encrypt、Scan 或 JSON 设置了值 (包括零值)
+	version    string // 读取时的密钥版本
+	ciphertext string // 读取时的密文, Rotate 用于判断并发修改
+}
+
+// Encrypt 包装明文
+func Encrypt[T any](v T) Encrypted[T] {
+	return Encrypted[T]{V: v, valid: true}
+}
+
+// Null 没有值, 写入 NULL
+func (e Encrypted[T]) Null() bool {
+	return !e.valid && reflect.ValueOf(&e.V).Elem().IsZero()
+}
+
+func (e Encrypted[T]) Value() (driver.Value, error) {
+	if e.Null() {
+		return nil, nil
+	}
+	k, err := currentKeyring()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := e.bytes()
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt(plaintext)
+}
+
+func (e *Encrypted[T]) Scan(v any) error {
+	var ciphertext string
+	switch val := v.(type) {
+	case nil:
+		*e = Encrypted[T]{}
+		return nil
+	case string:
+		ciphertext = val
+	case []byte:
+		ciphertext = string(val)
+	default:
+		return fmt.Errorf("can not convert %T to encrypted value", v)
+	}
+	k, err := currentKeyring()
+	if err != nil {
+		return err
+	}
+	plaintext, version, err := k.Decrypt(ciphertext)
+	if err != nil {
+		return err
+	}
+	var val T
+	switch p := any(&val).(type) {
+	case *string:
+		*p = string(plaintext)
+	case *[]byte:
+		*p = plaintext
+	default:
+		if err := json.Unmarshal(plaintext, &val); err != nil {
+			return fmt.Errorf("decode encrypted value: %w", err)
+		}
+	}
+	*e = Encrypted[T]{V: val, valid: true, version: version, ciphertext: ciphertext}
+	return nil
+}
+
+func (e Encrypted[T]) bytes() ([]byte, error) {
+	switch v := any(e.V).(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	}
+	return json.Marshal(e.V)
+}
+
+// GormDataType 密文比明文长, 注意列的长度
+func (Encrypted[T]) GormDataType() string {
+	return "string"
+}
+
+// Stale 读取时使用的不是当前版本的密钥, 保存后使用当前版本重新加密
+func (e Encrypted[T]) Stale() bool {
+	k := keyring.Load()
+	return e.version != "" && k != nil && e.version != k.active
+}
+
+func (e Encrypted[T]) stored() string {
+	return e.ciphertext
+}
+
+// Index 明文的盲索引, 没有值时为空 (写入 NULL)
+func (e Encrypted[T]) Index() (Hashed, error) {
+	if e.Null() {
+		return "", nil
+	}
+	k, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := e.bytes()
+	if err != nil {
+		return "", err
+	}
+	return k.Hash(string(plaintext))
+}
+
+func (e Encrypted[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.V)
+}
+
+func (e *Encrypted[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*e = Encrypted[T]{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &e.V); err != nil {
+		return err
+	}
+	e.valid = true
+	return nil
+}
+
+// LogValue 日志脱敏
+func (e Encrypted[T]) LogValue() slog.Value {
+	return slog.StringValue("******")
+}
+
+// Hashed 盲索引 (HMAC-SHA256 的 hex), 由 Hash 或 Encrypted.Index 计算, 为空时写入 NULL
+type Hashed string
+
+// Hash 明文的盲索引, 用于写入索引列和等值查询
+func Hash(plaintext string) (Hashed, error) {
+	k, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+	return k.Hash(plaintext)
+}
+
+func (h Hashed) Value() (driver.Value, error) {
+	if h == "" {
+		return nil, nil
+	}
+	return string(h), nil
+}
+
+func (h *Hashed) Scan(v any) error {
+	switch val := v.(type) {
+	case nil:
+		*h = ""
+	case string:
+		*h = Hashed(val)
+	case []byte:
+		*h = Hashed(val)
+	default:
+		return fmt.Errorf("can not convert %T to hashed value", v)
+	}
+	return nil
+}
+
+func (Hashed) GormDataType() string {
+	return "string"
+}
+
+// Rotate 使用当前版本的密钥重新加密 T 中 Stale 的 Encrypted 字段, 返回更新的行数
+// 所有行处理完成后才能删除旧版本的密钥
+// ctx 中有租户时只处理该租户的行 (schema 模式需要逐个租户调用), 没有租户时处理所有行 (tenant.Skip)
+// 更新条件包含读取时的密文, 读取之后被并发修改的行不更新 (不覆盖新值), 也不计入返回值
+func Rotate[T any](ctx context.Context, db *gorm.DB, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if _, ok := tenant.FromContext(ctx); !ok {
+		ctx = tenant.Skip(ctx)
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return 0, err
+	}
+	var (
+		rows    []*T
+		updated int64
+	)
+	err := db.WithContext(ctx).Model(new(T)).FindInBatches(&rows, batchSize, func(*gorm.DB, int) error {
+		for _, row := range rows {
+			fields := staleFields(reflect.ValueOf(row).Elem(), nil)
+			if len(fields) == 0 {
+				continue
+			}
+			tx := db.WithContext(ctx).Model(row)
+			names := make([]string, 0, len(fields))
+			for _, f := range fields {
+				field := stmt.Schema.LookUpField(f.name)
+				if field == nil {
+					return fmt.Errorf("rotate: field %s not found", f.name)
+				}
+				tx = tx.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: f.ciphertext})
+				names = append(names, f.name)
+			}
+			res := tx.Select(names).Updates(row)
+			if res.Error != nil {
+				return res.Error
+			}
+			updated += res.RowsAffected
+		}
+		return nil
+	}).Error
+	return updated, err
+}
+
+// staleField 需要重新加密的字段和读取时的密文
+type staleField struct {
+	name       string
+	ciphertext string
+}
+
+// staleFields 需要重新加密的字段 (包括嵌入结构体的字段)
+func staleFields(rv reflect.Value, fields []staleField) []staleField {
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Type().Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if s, ok := rv.Field(i).Interface().(interface {
+			Stale() bool
+			stored() string
+		}); ok {
+			if s.Stale() {
+				fields = append(fields, staleField{name: f.Name, ciphertext: s.stored()})
+			}
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			fields = staleFields(rv.Field(i), fields)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,159 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bobacgo/kit/app/security"
+	"gorm.io/gorm"
+)
+
+type address struct {
+	City string `json:"city"`
+}
+
+type customer struct {
+	Model
+	Phone    Encrypted[string] `gorm:"type:text"`
+	PhoneIdx Hashed            `gorm:"size:64;index"`
+	Address  Encrypted[address]
+}
+
+func newKeyring(t *testing.T, version string) *Keyring {
+	k, err := NewKeyring(security.CiphertextConfig{
+		DataKeys: map[string]security.Ciphertext{
+			"v1": "0123456789abcdef0123456789abcdef",
+			"v2": "fedcba9876543210fedcba9876543210",
+		},
+		DataKeyVersion: version,
+		IndexKey:       "index-key",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestEncrypted(t *testing.T) {
+	gdb := newTestDB(t, &customer{})
+	ctx := context.Background()
+
+	SetKeyring(nil)
+	if err := gdb.Create(&customer{Phone: Encrypt("13800000000")}).Error; !errors.Is(err, ErrNoKeyring) {
+		t.Fatal(err)
+	}
+
+	SetKeyring(newKeyring(t, "v1"))
+	t.Cleanup(func() { SetKeyring(nil) })
+	c := &customer{Phone: Encrypt("13800000000"), Address: Encrypt(address{City: "shanghai"})}
+	var err error
+	if c.PhoneIdx, err = c.Phone.Index(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Create(c).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var raw string // 落库为密文
+	if err := gdb.Raw("SELECT phone FROM customer WHERE id = ?", c.ID).Scan(&raw).Error; err != nil || !strings.HasPrefix(raw, "v1:") || strings.Contains(raw, "138") {
+		t.Fatal(raw, err)
+	}
+
+	idx, err := Hash("13800000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got customer
+	if err := gdb.Where("phone_idx = ?", idx).First(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.Phone.V != "13800000000" || got.Address.V.City != "shanghai" || got.Phone.Stale() {
+		t.Fatal(got)
+	}
+
+	// 轮换到 v2, v1 的数据仍然可以读取 (版本名不区分大小写)
+	SetKeyring(newKeyring(t, "V2"))
+	if err := gdb.First(&got, "id = ?", c.ID).Error; err != nil || !got.Phone.Stale() {
+		t.Fatal(got, err)
+	}
+	n, err := Rotate[customer](ctx, gdb, 10)
+	if err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+	if err := gdb.Raw("SELECT phone FROM customer WHERE id = ?", c.ID).Scan(&raw).Error; err != nil || !strings.HasPrefix(raw, "v2:") {
+		t.Fatal(raw, err)
+	}
+	if n, err := Rotate[customer](ctx, gdb, 10); err != nil || n != 0 {
+		t.Fatal(n, err)
+	}
+
+	// 读取之后被并发修改的行不覆盖
+	SetKeyring(newKeyring(t, "v1"))
+	concurrent, err := keyring.Load().Encrypt([]byte("13900000000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gdb.Callback().Update().Before("gorm:update").Register("test:concurrent_write", func(tx *gorm.DB) {
+		tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE customer SET phone = ? WHERE id = ?", concurrent, c.ID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err = Rotate[customer](ctx, gdb, 10)
+	if err := gdb.Callback().Update().Remove("test:concurrent_write"); err != nil {
+		t.Fatal(err)
+	}
+	if err != nil || n != 0 {
+		t.Fatal(n, err)
+	}
+	if err := gdb.First(&got, "id = ?", c.ID).Error; err != nil || got.Phone.V != "13900000000" {
+		t.Fatal(got, err)
+	}
+
+	// NULL 读取后保存仍为 NULL, 显式设置的零值加密保存
+	var null customer
+	if err := gdb.Create(&null).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.First(&null, "id = ?", null.ID).Error; err != nil || !null.Phone.Null() {
+		t.Fatal(null, err)
+	}
+	if err := gdb.Save(&null).Error; err != nil {
+		t.Fatal(err)
+	}
+	var phone *string
+	if err := gdb.Raw("SELECT phone FROM customer WHERE id = ?", null.ID).Scan(&phone).Error; err != nil || phone != nil {
+		t.Fatal(phone, err)
+	}
+	null.Phone = Encrypt("")
+	if err := gdb.Save(&null).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Raw("SELECT phone FROM customer WHERE id = ?", null.ID).Scan(&phone).Error; err != nil || phone == nil || !strings.HasPrefix(*phone, "v1:") {
+		t.Fatal(phone, err)
+	}
+	// 空字符串也有盲索引, 可以查询
+	if null.PhoneIdx, err = null.Phone.Index(); err != nil || null.PhoneIdx == "" {
+		t.Fatal(null.PhoneIdx, err)
+	}
+	if err := gdb.Save(&null).Error; err != nil {
+		t.Fatal(err)
+	}
+	if idx, err = Hash(""); err != nil || idx != null.PhoneIdx {
+		t.Fatal(idx, err)
+	}
+	var empty customer
+	if err := gdb.Where("phone_idx = ?", idx).First(&empty).Error; err != nil || empty.ID != null.ID || empty.Phone.Null() {
+		t.Fatal(empty, err)
+	}
+
+	// 篡改密文
+	if err := gdb.Exec("UPDATE customer SET phone = ? WHERE id = ?", raw[:len(raw)-4]+"AAAA", c.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.First(&got, "id = ?", c.ID).Error; err == nil {
+		t.Fatal("tampered ciphertext should fail")
+	}
+}
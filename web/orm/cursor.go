@@ -0,0 +1,176 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/bobacgo/kit/web/r/page"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Order 游标分页的排序列
+type Order struct {
+	Column string // 列名或字段名
+	Desc   bool
+}
+
+func Asc(column string) Order {
+	return Order{Column: column}
+}
+
+func Desc(column string) Order {
+	return Order{Column: column, Desc: true}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// CursorFind 游标 (keyset) 分页查找
+// orders 的最后一列需要唯一 (一般为主键), 排序列不能为 NULL, 建议在排序列上建联合索引
+//
+//	data, err := orm.CursorFind[model.User](db.Where("status = ?", 1), q.Cursor, orm.Desc("created_at"), orm.Desc("id"))
+func CursorFind[T any](db *gorm.DB, c page.Cursor, orders ...Order) (*page.CursorData[T], error) {
+	if len(orders) == 0 {
+		return nil, errors.New("cursor pagination requires order columns")
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	fields := make([]*schema.Field, len(orders))
+	for i, o := range orders {
+		if fields[i] = stmt.Schema.LookUpField(o.Column); fields[i] == nil {
+			return nil, fmt.Errorf("cursor order column %q not found in %s", o.Column, stmt.Schema.Name)
+		}
+	}
+
+	db = db.Session(&gorm.Session{})
+	data := &page.CursorData[T]{List: make([]T, 0)}
+	if c.WithTotal {
+		var total int64
+		if err := db.Model(new(T)).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		data.Total = &total
+	}
+
+	q := db
+	var backward bool
+	if c.Cursor != "" {
+		var (
+			raws []json.RawMessage
+			err  error
+		)
+		if backward, raws, err = page.DecodeCursor(c.Cursor); err != nil {
+			return nil, err
+		}
+		if len(raws) != len(orders) {
+			return nil, page.ErrInvalidCursor
+		}
+		values := make([]any, len(raws))
+		for i, raw := range raws {
+			if values[i], err = decodeCursorValue(raw, fields[i].FieldType); err != nil {
+				return nil, page.ErrInvalidCursor
+			}
+		}
+		q = q.Where(keyset(fields, orders, values, backward))
+	}
+	for i, o := range orders {
+		q = q.Order(clause.OrderByColumn{Column: clause.Column{Name: fields[i].DBName}, Desc: o.Desc != backward})
+	}
+
+	limit := c.Limit()
+	if err := q.Limit(limit + 1).Find(&data.List).Error; err != nil {
+		return nil, err
+	}
+	more := len(data.List) > limit
+	if more {
+		data.List = data.List[:limit]
+	}
+	if backward {
+		slices.Reverse(data.List)
+	}
+	if len(data.List) == 0 {
+		return data, nil
+	}
+
+	// 向后翻页时有上一页 (从上一页来), 向前翻页时有下一页
+	hasNext, hasPrev := more || backward, c.Cursor != "" && (more || !backward)
+	var err error
+	if hasNext {
+		if data.Next, err = encodeCursor(db.Statement.Context, fields, &data.List[len(data.List)-1], false); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		if data.Prev, err = encodeCursor(db.Statement.Context, fields, &data.List[0], true); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// keyset 排序在边界记录之后的条件
+// (a, b) > (1, 2) => a > 1 OR (a = 1 AND b > 2), 每列的方向可以不同
+func keyset(fields []*schema.Field, orders []Order, values []any, backward bool) clause.Expression {
+	or := make([]clause.Expression, 0, len(fields))
+	for i := range fields {
+		and := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: clause.Column{Name: fields[j].DBName}, Value: values[j]})
+		}
+		col := clause.Column{Name: fields[i].DBName}
+		if orders[i].Desc != backward {
+			and = append(and, clause.Lt{Column: col, Value: values[i]})
+		} else {
+			and = append(and, clause.Gt{Column: col, Value: values[i]})
+		}
+		or = append(or, clause.And(and...))
+	}
+	return clause.Or(or...)
+}
+
+func encodeCursor[T any](ctx context.Context, fields []*schema.Field, m *T, backward bool) (string, error) {
+	rv := reflect.ValueOf(m).Elem()
+	values := make([]json.RawMessage, len(fields))
+	for i, f := range fields {
+		v, _ := f.ValueOf(ctx, rv)
+		raw, err := encodeCursorValue(v)
+		if err != nil {
+			return "", fmt.Errorf("encode cursor %s: %w", f.Name, err)
+		}
+		values[i] = raw
+	}
+	return page.EncodeCursor(backward, values...), nil
+}
+
+// encodeCursorValue 时间类型 (包括 LocalTime) 保留纳秒, 避免精度丢失导致重复或遗漏
+func encodeCursorValue(v any) (json.RawMessage, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.IsValid() && rv.Kind() == reflect.Struct && rv.Type().ConvertibleTo(timeType) {
+		v = rv.Convert(timeType).Interface()
+	}
+	return json.Marshal(v)
+}
+
+func decodeCursorValue(raw json.RawMessage, typ reflect.Type) (any, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Struct && typ.ConvertibleTo(timeType) {
+		var t time.Time
+		err := json.Unmarshal(raw, &t)
+		return t, err
+	}
+	v := reflect.New(typ)
+	if err := json.Unmarshal(raw, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
@@ -0,0 +1,79 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bobacgo/kit/web/r/page"
+	"gorm.io/gorm"
+)
+
+func names(list []user) (s []string) {
+	for _, u := range list {
+		s = append(s, u.Name)
+	}
+	return s
+}
+
+func TestCursorFind(t *testing.T) {
+	gdb := newTestDB(t, &user{})
+	ctx := context.Background()
+	repo := NewRepository[user](gdb)
+	fixedID := gdb.Session(&gorm.Session{SkipHooks: true}) // 固定 ID 用于排序, 跳过 BeforeCreate 生成的 UUID
+	for i := range 7 {
+		u := &user{Model: Model{ID: fmt.Sprintf("id%d", i)}, Name: fmt.Sprintf("u%d", i), Status: i % 3}
+		if err := fixedID.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// status desc, id asc: u2 u5 u1 u4 u0 u3 u6
+	orders := []Order{Desc("status"), Asc("id")}
+	q := page.Cursor{PageSize: 3, WithTotal: true}
+
+	p1, err := repo.CursorPage(ctx, q, orders)
+	if err != nil || fmt.Sprint(names(p1.List)) != "[u2 u5 u1]" || p1.Prev != "" || p1.Next == "" || *p1.Total != 7 {
+		t.Fatal(p1, err)
+	}
+	q.Cursor = p1.Next
+	p2, err := repo.CursorPage(ctx, q, orders)
+	if err != nil || fmt.Sprint(names(p2.List)) != "[u4 u0 u3]" || p2.Prev == "" || p2.Next == "" {
+		t.Fatal(p2, err)
+	}
+	q.Cursor = p2.Next
+	p3, err := repo.CursorPage(ctx, q, orders)
+	if err != nil || fmt.Sprint(names(p3.List)) != "[u6]" || p3.Next != "" {
+		t.Fatal(p3, err)
+	}
+
+	// 向前翻页
+	q.Cursor = p3.Prev
+	back, err := repo.CursorPage(ctx, q, orders)
+	if err != nil || fmt.Sprint(names(back.List)) != "[u4 u0 u3]" || back.Next == "" || back.Prev == "" {
+		t.Fatal(back, err)
+	}
+	q.Cursor = back.Prev
+	back, err = repo.CursorPage(ctx, q, orders)
+	if err != nil || fmt.Sprint(names(back.List)) != "[u2 u5 u1]" || back.Prev != "" {
+		t.Fatal(back, err)
+	}
+
+	// 时间列
+	byTime := []Order{Asc("created_at"), Asc("id")}
+	list, err := repo.CursorPage(ctx, page.Cursor{PageSize: 3}, byTime, userStatus.Ne(0))
+	if err != nil || fmt.Sprint(names(list.List)) != "[u1 u2 u4]" {
+		t.Fatal(list, err)
+	}
+	list, err = repo.CursorPage(ctx, page.Cursor{Cursor: list.Next, PageSize: 3}, byTime, userStatus.Ne(0))
+	if err != nil || fmt.Sprint(names(list.List)) != "[u5]" || list.Next != "" {
+		t.Fatal(list, err)
+	}
+
+	if _, err := repo.CursorPage(ctx, page.Cursor{Cursor: "bad"}, orders); !errors.Is(err, page.ErrInvalidCursor) {
+		t.Fatal(err)
+	}
+	if _, err := repo.CursorPage(ctx, page.Cursor{Cursor: p1.Next}, orders[:1]); !errors.Is(err, page.ErrInvalidCursor) {
+		t.Fatal(err)
+	}
+}
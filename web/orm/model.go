@@ -13,15 +13,17 @@ import (
 // Model
 // ID 值使用UUID, 避免分布式环境下key冲突
 //
+// query tag 见 query 包
+//
 // LocalTime
 //
 //	1.可以通过配置指定格式 app.timeFormat
 //	1.1.string -> time (指定根式序列化)
 //	1.2.time -> string (指定根式反序列化)
 type Model struct {
-	ID        string         `json:"id" gorm:"primarykey"`
-	CreatedAt LocalTime      `json:"createdAt"`
-	UpdatedAt LocalTime      `json:"updatedAt"`
+	ID        string         `json:"id" gorm:"primarykey" query:"filter=eq,in;sort;select"`
+	CreatedAt LocalTime      `json:"createdAt" query:"filter=gt,gte,lt,lte;sort;select"`
+	UpdatedAt LocalTime      `json:"updatedAt" query:"filter=gt,gte,lt,lte;sort;select"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
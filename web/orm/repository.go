@@ -0,0 +1,199 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/bobacgo/kit/app/db"
+	"github.com/bobacgo/kit/web/r/errs"
+	"github.com/bobacgo/kit/web/r/page"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository 通用的 CRUD
+/*
+	type UserDao struct {
+		*orm.Repository[model.User]
+	}
+
+	dao := UserDao{orm.NewRepository[model.User](dbs.Default())}
+	user, err := dao.FindByID(ctx, id)
+
+	1.ctx 中有事务时 (见 db.Transaction) 在事务中执行
+	2.记录不存在返回 errs.NotFound, 唯一键冲突返回 errs.Conflict (需要 gorm 开启 TranslateError, db.NewDB 默认开启)
+	3.T 有 gorm.DeletedAt 字段时 Delete 为软删除, 查询默认排除软删除的记录
+*/
+type Repository[T any] struct {
+	db *gorm.DB
+}
+
+func NewRepository[T any](gdb *gorm.DB) *Repository[T] {
+	return &Repository[T]{db: gdb}
+}
+
+// DB ctx 中的事务或 T 的查询
+func (r *Repository[T]) DB(ctx context.Context) *gorm.DB {
+	return db.Tx(ctx, r.db).Model(new(T))
+}
+
+func (r *Repository[T]) query(ctx context.Context, specs []Spec) *gorm.DB {
+	return applySpecs(r.DB(ctx), specs)
+}
+
+// FindByID 按主键查询
+func (r *Repository[T]) FindByID(ctx context.Context, id any, specs ...Spec) (*T, error) {
+	var m T
+	err := r.query(ctx, specs).Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).Take(&m).Error
+	if err != nil {
+		return nil, Error(err)
+	}
+	return &m, nil
+}
+
+// FindOne 查询符合条件的第一条记录
+func (r *Repository[T]) FindOne(ctx context.Context, specs ...Spec) (*T, error) {
+	var m T
+	if err := r.query(ctx, specs).Take(&m).Error; err != nil {
+		return nil, Error(err)
+	}
+	return &m, nil
+}
+
+// FindAll 查询符合条件的所有记录
+func (r *Repository[T]) FindAll(ctx context.Context, specs ...Spec) ([]T, error) {
+	list := make([]T, 0)
+	if err := r.query(ctx, specs).Find(&list).Error; err != nil {
+		return nil, Error(err)
+	}
+	return list, nil
+}
+
+// Page 分页查询, 见 PageFind
+func (r *Repository[T]) Page(ctx context.Context, q page.Query, specs ...Spec) (*page.Data[T], error) {
+	data, err := PageFind[T](r.query(ctx, specs).Session(&gorm.Session{}), q)
+	return data, Error(err)
+}
+
+// CursorPage 游标分页查询, 见 CursorFind
+func (r *Repository[T]) CursorPage(ctx context.Context, c page.Cursor, orders []Order, specs ...Spec) (*page.CursorData[T], error) {
+	data, err := CursorFind[T](r.query(ctx, specs), c, orders...)
+	return data, Error(err)
+}
+
+// Count 符合条件的记录数
+func (r *Repository[T]) Count(ctx context.Context, specs ...Spec) (int64, error) {
+	var n int64
+	err := r.query(ctx, specs).Count(&n).Error
+	return n, Error(err)
+}
+
+// Exists 是否有符合条件的记录
+func (r *Repository[T]) Exists(ctx context.Context, specs ...Spec) (bool, error) {
+	var one int
+	res := r.query(ctx, specs).Select("1").Limit(1).Scan(&one)
+	return res.RowsAffected > 0, Error(res.Error)
+}
+
+func (r *Repository[T]) Create(ctx context.Context, m *T) error {
+	return Error(db.Tx(ctx, r.db).Create(m).Error)
+}
+
+// CreateBatch 批量插入, 超过 CreateBatchSize 时分批
+func (r *Repository[T]) CreateBatch(ctx context.Context, list []*T) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return Error(db.Tx(ctx, r.db).Create(list).Error)
+}
+
+// Update 按 m 的主键更新
+// fields 为要更新的字段 (字段名或列名), 可以更新为零值; 为空时只更新非零值字段
+func (r *Repository[T]) Update(ctx context.Context, m *T, fields ...string) error {
+	tx := db.Tx(ctx, r.db).Model(m)
+	if len(fields) > 0 {
+		tx = tx.Select(fields)
+	}
+	res := tx.Updates(m)
+	if res.Error != nil {
+		return Error(res.Error)
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+	// MySQL 值没有变化时影响行数也为 0
+	cond, err := r.primaryKey(m)
+	if err != nil {
+		return err
+	}
+	ok, err := r.Exists(ctx, cond)
+	if err == nil && !ok {
+		err = errs.NotFound
+	}
+	return err
+}
+
+// Upsert 插入, 唯一键冲突时更新
+// conflict 为冲突的唯一键列 (MySQL 忽略, 按表的所有唯一键), fields 为冲突时要更新的列, 为空时更新所有列 (不包括主键)
+// orm.Model 创建时总是生成新的 ID, 冲突目标应为业务唯一键
+func (r *Repository[T]) Upsert(ctx context.Context, m *T, conflict []string, fields ...string) error {
+	columns := make([]clause.Column, 0, len(conflict))
+	for _, name := range conflict {
+		columns = append(columns, clause.Column{Name: name})
+	}
+	onConflict := clause.OnConflict{Columns: columns, UpdateAll: true}
+	if len(fields) > 0 {
+		onConflict = clause.OnConflict{Columns: columns, DoUpdates: clause.AssignmentColumns(fields)}
+	}
+	return Error(db.Tx(ctx, r.db).Clauses(onConflict).Create(m).Error)
+}
+
+// Delete 按主键删除, T 有 gorm.DeletedAt 字段时为软删除
+func (r *Repository[T]) Delete(ctx context.Context, id any) error {
+	return r.delete(db.Tx(ctx, r.db), id)
+}
+
+// HardDelete 按主键物理删除 (包括已软删除的记录)
+func (r *Repository[T]) HardDelete(ctx context.Context, id any) error {
+	return r.delete(db.Tx(ctx, r.db).Unscoped(), id)
+}
+
+func (r *Repository[T]) delete(tx *gorm.DB, id any) error {
+	res := tx.Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).Delete(new(T))
+	if res.Error != nil {
+		return Error(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+// primaryKey m 的主键条件
+func (r *Repository[T]) primaryKey(m *T) (Cond, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(m); err != nil {
+		return Cond{}, err
+	}
+	rv := reflect.ValueOf(m).Elem()
+	conds := make([]Cond, 0, len(stmt.Schema.PrimaryFields))
+	for _, f := range stmt.Schema.PrimaryFields {
+		v, _ := f.ValueOf(context.Background(), rv)
+		conds = append(conds, Cond{clause.Eq{Column: clause.Column{Name: f.DBName}, Value: v}})
+	}
+	return And(conds...), nil
+}
+
+// Error 转换 gorm 的错误: 记录不存在返回 errs.NotFound, 唯一键冲突返回 errs.Conflict
+func Error(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errs.NotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errs.Conflict
+	}
+	return err
+}
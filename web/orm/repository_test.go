@@ -0,0 +1,132 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/bobacgo/kit/app/db"
+	"github.com/bobacgo/kit/web/r/errs"
+	"github.com/bobacgo/kit/web/r/page"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type user struct {
+	Model
+	Name   string `gorm:"size:64;uniqueIndex"`
+	Status int
+}
+
+var (
+	userName   = Field[string]("name")
+	userStatus = Field[int]("status")
+)
+
+func TestRepository(t *testing.T) {
+	gdb := newTestDB(t, &user{})
+	ctx := context.Background()
+	repo := NewRepository[user](gdb)
+
+	err := repo.CreateBatch(ctx, []*user{{Name: "a", Status: 1}, {Name: "b", Status: 2}, {Name: "c", Status: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Create(ctx, &user{Name: "a"}); !errors.Is(err, errs.Conflict) {
+		t.Fatal(err)
+	}
+
+	list, err := repo.FindAll(ctx, Or(userName.Eq("a"), userStatus.Gte(3)), OrderBy("name", true))
+	if err != nil || len(list) != 2 || list[0].Name != "c" {
+		t.Fatal(list, err)
+	}
+	if n, err := repo.Count(ctx, userStatus.In(1, 2)); err != nil || n != 2 {
+		t.Fatal(n, err)
+	}
+	data, err := repo.Page(ctx, page.NewQuery(2, 2), OrderBy("name", false))
+	if err != nil || data.Total != 3 || len(data.List) != 1 || data.List[0].Name != "c" {
+		t.Fatal(data, err)
+	}
+
+	a, err := repo.FindOne(ctx, userName.Eq("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 指定字段时可以更新为零值
+	a.Status = 0
+	if err := repo.Update(ctx, a, "status"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := repo.FindByID(ctx, a.ID); err != nil || got.Status != 0 {
+		t.Fatal(got, err)
+	}
+	if err := repo.Update(ctx, &user{Model: Model{ID: "missing"}, Name: "x"}); !errors.Is(err, errs.NotFound) {
+		t.Fatal(err)
+	}
+
+	// 唯一键冲突时更新, 传入的 ID 不会作为主键 (不会覆盖 a)
+	b, err := repo.FindOne(ctx, userName.Eq("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Upsert(ctx, &user{Name: "b", Status: 9}, []string{"name"}, "status"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Upsert(ctx, &user{Model: Model{ID: a.ID}, Name: "e", Status: 5}, []string{"name"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := repo.FindOne(ctx, userName.Eq("b")); err != nil || got.Status != 9 || got.ID != b.ID {
+		t.Fatal(got, err)
+	}
+	if got, err := repo.FindByID(ctx, a.ID); err != nil || got.Name != "a" {
+		t.Fatal(got, err)
+	}
+
+	// 软删除
+	if err := repo.Delete(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.FindByID(ctx, a.ID); !errors.Is(err, errs.NotFound) {
+		t.Fatal(err)
+	}
+	if ok, err := repo.Exists(ctx, Unscoped(), userName.Eq("a")); err != nil || !ok {
+		t.Fatal(ok, err)
+	}
+	if err := repo.HardDelete(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := repo.Exists(ctx, Unscoped(), userName.Eq("a")); err != nil || ok {
+		t.Fatal(ok, err)
+	}
+	if err := repo.Delete(ctx, a.ID); !errors.Is(err, errs.NotFound) {
+		t.Fatal(err)
+	}
+
+	// 事务中执行
+	errRollback := errors.New("rollback")
+	err = db.Transaction(ctx, gdb, func(ctx context.Context) error {
+		if err := repo.Create(ctx, &user{Name: "d"}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+	if ok, _ := repo.Exists(ctx, userName.Eq("d")); ok {
+		t.Fatal("not rolled back")
+	}
+}
+
+// newTestDB 临时目录下的 sqlite 数据库, 并创建 models 的表
+func newTestDB(t *testing.T, models ...any) *gorm.DB {
+	gdb, err := db.NewDB(sqlite.Open(filepath.Join(t.TempDir(), "orm.db")), db.Config{Driver: "sqlite"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return gdb
+}
@@ -0,0 +1,134 @@
+package orm
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Spec 查询条件, 见 Repository
+//
+//	var (
+//		UserName   = orm.Field[string]("name")
+//		UserStatus = orm.Field[int]("status")
+//	)
+//
+//	users, err := repo.FindAll(ctx,
+//		orm.Or(UserName.Like("bob%"), UserStatus.In(1, 2)),
+//		orm.OrderBy("created_at", true),
+//	)
+type Spec interface {
+	Apply(db *gorm.DB) *gorm.DB
+}
+
+// Scope 任意 gorm scope
+type Scope func(db *gorm.DB) *gorm.DB
+
+func (s Scope) Apply(db *gorm.DB) *gorm.DB {
+	return s(db)
+}
+
+// Cond 条件表达式, 可以通过 And、Or、Not 组合
+type Cond struct {
+	clause.Expression
+}
+
+func (c Cond) Apply(db *gorm.DB) *gorm.DB {
+	return db.Where(c.Expression)
+}
+
+// Field 字段 (列名), V 为字段值的类型
+// 列名会被转义, 但不要使用请求参数作为列名
+type Field[V any] string
+
+func (f Field[V]) column() clause.Column {
+	return clause.Column{Name: string(f)}
+}
+
+func (f Field[V]) Eq(v V) Cond {
+	return Cond{clause.Eq{Column: f.column(), Value: v}}
+}
+
+func (f Field[V]) Ne(v V) Cond {
+	return Cond{clause.Neq{Column: f.column(), Value: v}}
+}
+
+func (f Field[V]) Gt(v V) Cond {
+	return Cond{clause.Gt{Column: f.column(), Value: v}}
+}
+
+func (f Field[V]) Gte(v V) Cond {
+	return Cond{clause.Gte{Column: f.column(), Value: v}}
+}
+
+func (f Field[V]) Lt(v V) Cond {
+	return Cond{clause.Lt{Column: f.column(), Value: v}}
+}
+
+func (f Field[V]) Lte(v V) Cond {
+	return Cond{clause.Lte{Column: f.column(), Value: v}}
+}
+
+// In vs 为空时条件不成立
+func (f Field[V]) In(vs ...V) Cond {
+	values := make([]any, len(vs))
+	for i, v := range vs {
+		values[i] = v
+	}
+	return Cond{clause.IN{Column: f.column(), Values: values}}
+}
+
+// Like pattern 需要自己加 %
+func (f Field[V]) Like(pattern string) Cond {
+	return Cond{clause.Like{Column: f.column(), Value: pattern}}
+}
+
+func (f Field[V]) IsNull() Cond {
+	return Cond{clause.Eq{Column: f.column(), Value: nil}}
+}
+
+func (f Field[V]) NotNull() Cond {
+	return Cond{clause.Neq{Column: f.column(), Value: nil}}
+}
+
+func And(conds ...Cond) Cond {
+	return Cond{clause.And(exprs(conds)...)}
+}
+
+func Or(conds ...Cond) Cond {
+	return Cond{clause.Or(exprs(conds)...)}
+}
+
+func Not(conds ...Cond) Cond {
+	return Cond{clause.Not(exprs(conds)...)}
+}
+
+func exprs(conds []Cond) []clause.Expression {
+	list := make([]clause.Expression, len(conds))
+	for i, c := range conds {
+		list[i] = c.Expression
+	}
+	return list
+}
+
+// OrderBy 排序, 可以传多次
+func OrderBy(field string, desc bool) Spec {
+	return Scope(func(db *gorm.DB) *gorm.DB {
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Name: field}, Desc: desc})
+	})
+}
+
+// Unscoped 包括软删除的记录
+func Unscoped() Spec {
+	return Scope(func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	})
+}
+
+func applySpecs(db *gorm.DB, specs []Spec) *gorm.DB {
+	for _, s := range specs {
+		if s != nil {
+			db = s.Apply(db)
+		}
+	}
+	return db
+}
@@ -16,5 +16,8 @@ const (
 	BadRequest          Code = 400
 	TokenInvalid        Code = 401
 	TokenMission        Code = 402
+	Forbidden           Code = 403
+	NotFound            Code = 404
+	Conflict            Code = 409
 	InternalServerError Code = 500
 )
@@ -8,4 +8,6 @@ import (
 var (
 	BadRequest    = status.New(codes.BadRequest, "请求参数错误")
 	InternalError = status.New(codes.InternalServerError, "服务器繁忙")
+	NotFound      = status.New(codes.NotFound, "记录不存在")
+	Conflict      = status.New(codes.Conflict, "记录已存在")
 )
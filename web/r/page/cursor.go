@@ -0,0 +1,68 @@
+package page
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/bobacgo/kit/web/r/codes"
+	"github.com/bobacgo/kit/web/r/status"
+)
+
+// ErrInvalidCursor 游标格式错误 (被篡改或排序列变化)
+var ErrInvalidCursor = status.New(codes.BadRequest, "无效的分页游标")
+
+// Cursor 游标分页请求的基类
+// 适用于大表, 不使用 OFFSET, 翻页时间与页码无关
+type Cursor struct {
+	// 上一次响应的 next (下一页) 或 prev (上一页), 为空时查询第一页
+	Cursor string `json:"cursor" form:"cursor"`
+	// 每一页多少条数据
+	PageSize int `json:"pageSize" form:"pageSize"`
+	// 是否查询总数 (COUNT 在大表上较慢)
+	WithTotal bool `json:"withTotal" form:"withTotal"`
+}
+
+func NewCursor(cursor string, pageSize int) Cursor {
+	return Cursor{Cursor: cursor, PageSize: pageSize}
+}
+
+func (c Cursor) Limit() int {
+	if c.PageSize <= 0 {
+		return 5
+	}
+	return c.PageSize
+}
+
+// CursorData 游标分页数据响应体
+// T 列表每一项的数据类型
+type CursorData[T any] struct {
+	List  []T    `json:"list"`
+	Next  string `json:"next"`            // 下一页的游标, 为空时没有下一页
+	Prev  string `json:"prev"`            // 上一页的游标, 为空时没有上一页
+	Total *int64 `json:"total,omitempty"` // WithTotal 时返回
+}
+
+// cursorToken 游标的内容, 编码后对客户端不透明
+type cursorToken struct {
+	Backward bool              `json:"b,omitempty"` // 向前翻页 (prev)
+	Values   []json.RawMessage `json:"v"`           // 边界记录排序列的值
+}
+
+// EncodeCursor 编码游标, values 为边界记录排序列的值 (JSON)
+func EncodeCursor(backward bool, values ...json.RawMessage) string {
+	data, _ := json.Marshal(cursorToken{Backward: backward, Values: values})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor 解码游标, 格式错误时返回 ErrInvalidCursor
+func DecodeCursor(cursor string) (backward bool, values []json.RawMessage, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return false, nil, ErrInvalidCursor
+	}
+	var t cursorToken
+	if err := json.Unmarshal(data, &t); err != nil || len(t.Values) == 0 {
+		return false, nil, ErrInvalidCursor
+	}
+	return t.Backward, t.Values, nil
+}
@@ -0,0 +1,151 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// Op 过滤操作符
+type Op string
+
+const (
+	Eq   Op = "eq"   // name=bob
+	Ne   Op = "ne"   // name!=bob
+	Gt   Op = "gt"   // age>18
+	Gte  Op = "gte"  // age>=18
+	Lt   Op = "lt"   // age<18
+	Lte  Op = "lte"  // age<=18
+	Like Op = "like" // name~bob (包含)
+	In   Op = "in"   // status=1|2|3
+)
+
+var allOps = []Op{Eq, Ne, Gt, Gte, Lt, Lte, Like, In}
+
+// field 模型中允许查询的字段
+type field struct {
+	name   string // 查询参数中的名称 (json tag)
+	column string
+	typ    reflect.Type
+	ops    map[Op]bool // 允许的过滤操作符
+	sort   bool
+	sel    bool
+}
+
+type model struct {
+	name   string
+	fields map[string]*field
+}
+
+var (
+	models      sync.Map // reflect.Type -> *model
+	schemaCache sync.Map
+)
+
+// modelOf 读取 T 字段的 query tag
+/*
+	type User struct {
+		orm.Model
+		Name     string `json:"name" query:"filter=eq,like;sort;select"`
+		Age      int    `json:"age" query:"filter;sort"` // filter 不指定操作符时允许所有操作符 (like 只用于字符串)
+		Passcode string `json:"-"`                       // 没有 query tag 的字段不能查询
+	}
+*/
+func modelOf[T any]() (*model, error) {
+	typ := reflect.TypeFor[T]()
+	if m, ok := models.Load(typ); ok {
+		return m.(*model), nil
+	}
+	// 列名与 gorm 一致 (包括 column tag)
+	s, err := schema.Parse(new(T), &schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+	m := &model{name: s.Name, fields: make(map[string]*field)}
+	if err := m.parse(typ, s); err != nil {
+		return nil, err
+	}
+	models.Store(typ, m)
+	return m, nil
+}
+
+func (m *model) parse(typ reflect.Type, s *schema.Schema) error {
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag, ok := sf.Tag.Lookup("query")
+		if sf.Anonymous && !ok { // 嵌入的结构体 (orm.Model)
+			if t := indirect(sf.Type); t.Kind() == reflect.Struct {
+				if err := m.parse(t, s); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !ok || tag == "-" {
+			continue
+		}
+		sch := s.LookUpField(sf.Name)
+		if sch == nil || sch.DBName == "" {
+			return fmt.Errorf("query: %s.%s is not a column", m.name, sf.Name)
+		}
+		f := &field{name: jsonName(sf), column: sch.DBName, typ: indirect(sf.Type)}
+		for _, opt := range strings.Split(tag, ";") {
+			key, val, _ := strings.Cut(strings.TrimSpace(opt), "=")
+			switch key {
+			case "filter":
+				f.ops = make(map[Op]bool)
+				if val == "" {
+					for _, op := range allOps {
+						f.ops[op] = op != Like || f.typ.Kind() == reflect.String
+					}
+					continue
+				}
+				for _, op := range strings.Split(val, ",") {
+					op := Op(strings.TrimSpace(op))
+					if !validOp(op) {
+						return fmt.Errorf("query: %s.%s unknown operator %q", m.name, sf.Name, op)
+					}
+					f.ops[op] = true
+				}
+			case "sort":
+				f.sort = true
+			case "select":
+				f.sel = true
+			case "":
+			default:
+				return fmt.Errorf("query: %s.%s unknown option %q", m.name, sf.Name, key)
+			}
+		}
+		m.fields[f.name] = f
+	}
+	return nil
+}
+
+func validOp(op Op) bool {
+	for _, o := range allOps {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonName(sf reflect.StructField) string {
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return sf.Name
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
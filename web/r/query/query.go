@@ -0,0 +1,292 @@
+// Package query 列表接口的动态过滤、排序、字段选择
+/*
+	GET /users?filter=age>18,name~bob&sort=-createdAt,name&fields=id,name
+
+	type ListUserReq struct {
+		page.Query
+		query.Params
+	}
+
+	func (h *UserHandler) List(c *gin.Context) {
+		var req ListUserReq
+		if err := c.ShouldBindQuery(&req); err != nil { ... }
+		q, err := query.Parse[model.User](req.Params) // grpc-gateway: query.Parse[model.User](query.Params{Filter: req.GetFilter(), ...})
+		if err != nil {
+			r.Reply(c, err) // 400
+			return
+		}
+		data, err := userRepo.Page(c, req.Query, q) // 或 db.Scopes(q.Apply)
+	}
+
+	1.字段和操作符通过模型的 query tag 白名单控制, 见 modelOf; 参数中使用 json 名称
+	2.值按字段类型转换后作为 SQL 参数, 列名来自模型, 不会拼接请求中的字符串
+	3.过滤语法: 多个条件用逗号分隔 (AND), 值中的逗号写作 \,
+		=  !=  >  >=  <  <=  ~ (包含)  =a|b|c (in)
+	4.排序: 逗号分隔, - 前缀为降序
+*/
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobacgo/kit/web/r/codes"
+	"github.com/bobacgo/kit/web/r/status"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxConditions 过滤条件的最大数量
+const maxConditions = 20
+
+// Params 查询参数, 可以和 page.Query 一起嵌入请求结构体
+type Params struct {
+	Filter string `json:"filter" form:"filter"` // age>18,name~bob
+	Sort   string `json:"sort" form:"sort"`     // -createdAt,name
+	Fields string `json:"fields" form:"fields"` // id,name
+}
+
+// Query 校验后的查询, 实现 orm.Spec
+type Query struct {
+	where  []clause.Expression
+	order  []clause.OrderByColumn
+	fields []string // 列名
+}
+
+// Parse 按 T 的 query tag 校验并解析参数, 错误为 status.Status (400)
+func Parse[T any](p Params) (*Query, error) {
+	m, err := modelOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	q := new(Query)
+	if err := q.parseFilter(m, p.Filter); err != nil {
+		return nil, err
+	}
+	if err := q.parseSort(m, p.Sort); err != nil {
+		return nil, err
+	}
+	if err := q.parseFields(m, p.Fields); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// ParseValues 从 URL 参数解析 (filter、sort、fields)
+func ParseValues[T any](v url.Values) (*Query, error) {
+	return Parse[T](Params{Filter: v.Get("filter"), Sort: v.Get("sort"), Fields: v.Get("fields")})
+}
+
+// Bind 从 gin 请求的 URL 参数解析
+func Bind[T any](c *gin.Context) (*Query, error) {
+	return ParseValues[T](c.Request.URL.Query())
+}
+
+// Apply 过滤、排序、字段选择
+// 注意 orm.PageFind 会在 COUNT 中使用选择的字段
+func (q *Query) Apply(db *gorm.DB) *gorm.DB {
+	return q.Select(q.Order(q.Where(db)))
+}
+
+// Where 只使用过滤条件
+func (q *Query) Where(db *gorm.DB) *gorm.DB {
+	for _, expr := range q.where {
+		db = db.Where(expr)
+	}
+	return db
+}
+
+// Order 只使用排序
+func (q *Query) Order(db *gorm.DB) *gorm.DB {
+	for _, o := range q.order {
+		db = db.Order(o)
+	}
+	return db
+}
+
+// Select 只使用字段选择
+func (q *Query) Select(db *gorm.DB) *gorm.DB {
+	if len(q.fields) == 0 {
+		return db
+	}
+	return db.Select(q.fields)
+}
+
+func badRequest(format string, args ...any) error {
+	return status.Newf(codes.BadRequest, format, args...)
+}
+
+func (q *Query) parseFilter(m *model, filter string) error {
+	conds := splitEscaped(filter, ',')
+	if len(conds) > maxConditions {
+		return badRequest("too many filter conditions, max %d", maxConditions)
+	}
+	for _, cond := range conds {
+		name, op, val, ok := cutCondition(cond)
+		if !ok {
+			return badRequest("invalid filter %q", cond)
+		}
+		f := m.fields[name]
+		if f == nil || f.ops == nil {
+			return badRequest("filter field %q is not allowed", name)
+		}
+		if op == Eq && strings.Contains(val, "|") {
+			op = In
+		}
+		if !f.ops[op] {
+			return badRequest("filter operator %q is not allowed on %q", op, name)
+		}
+		col := clause.Column{Name: f.column}
+		if op == Like {
+			q.where = append(q.where, clause.Expr{SQL: "? LIKE ? ESCAPE '!'", Vars: []any{col, "%" + likeEscaper.Replace(val) + "%"}})
+			continue
+		}
+		if op == In {
+			parts := strings.Split(val, "|")
+			values := make([]any, len(parts))
+			for i, s := range parts {
+				v, err := convert(f.typ, s)
+				if err != nil {
+					return badRequest("invalid filter value %q for %q", s, name)
+				}
+				values[i] = v
+			}
+			q.where = append(q.where, clause.IN{Column: col, Values: values})
+			continue
+		}
+		v, err := convert(f.typ, val)
+		if err != nil {
+			return badRequest("invalid filter value %q for %q", val, name)
+		}
+		var expr clause.Expression
+		switch op {
+		case Eq:
+			expr = clause.Eq{Column: col, Value: v}
+		case Ne:
+			expr = clause.Neq{Column: col, Value: v}
+		case Gt:
+			expr = clause.Gt{Column: col, Value: v}
+		case Gte:
+			expr = clause.Gte{Column: col, Value: v}
+		case Lt:
+			expr = clause.Lt{Column: col, Value: v}
+		case Lte:
+			expr = clause.Lte{Column: col, Value: v}
+		}
+		q.where = append(q.where, expr)
+	}
+	return nil
+}
+
+func (q *Query) parseSort(m *model, sort string) error {
+	for _, s := range splitEscaped(sort, ',') {
+		desc := strings.HasPrefix(s, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+		f := m.fields[name]
+		if f == nil || !f.sort {
+			return badRequest("sort field %q is not allowed", name)
+		}
+		q.order = append(q.order, clause.OrderByColumn{Column: clause.Column{Name: f.column}, Desc: desc})
+	}
+	return nil
+}
+
+func (q *Query) parseFields(m *model, fields string) error {
+	for _, name := range splitEscaped(fields, ',') {
+		f := m.fields[name]
+		if f == nil || !f.sel {
+			return badRequest("field %q is not allowed", name)
+		}
+		q.fields = append(q.fields, f.column)
+	}
+	return nil
+}
+
+// symbols 按长度从长到短匹配
+var symbols = []struct {
+	symbol string
+	op     Op
+}{
+	{"!=", Ne}, {">=", Gte}, {"<=", Lte}, {"=", Eq}, {">", Gt}, {"<", Lt}, {"~", Like},
+}
+
+// cutCondition age>=18 -> age, gte, 18
+func cutCondition(cond string) (name string, op Op, val string, ok bool) {
+	i := strings.IndexFunc(cond, func(r rune) bool {
+		return !(r == '_' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if i <= 0 {
+		return "", "", "", false
+	}
+	for _, s := range symbols {
+		if strings.HasPrefix(cond[i:], s.symbol) {
+			return cond[:i], s.op, cond[i+len(s.symbol):], true
+		}
+	}
+	return "", "", "", false
+}
+
+// splitEscaped 按 sep 分隔, 忽略 \ 转义的 sep, 去掉空白和空项
+func splitEscaped(s string, sep byte) []string {
+	var (
+		list []string
+		b    strings.Builder
+	)
+	flush := func() {
+		if item := strings.TrimSpace(b.String()); item != "" {
+			list = append(list, item)
+		}
+		b.Reset()
+	}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == sep:
+			b.WriteByte(sep)
+			i++
+		case s[i] == sep:
+			flush()
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	flush()
+	return list
+}
+
+// likeEscaper LIKE 使用 ! 作为转义字符 (MySQL、SQLite、PostgreSQL 都支持)
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var timeLayouts = []string{time.RFC3339Nano, time.DateTime, time.DateOnly}
+
+// convert 按字段类型转换参数值
+func convert(typ reflect.Type, s string) (any, error) {
+	switch typ.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(s).Convert(typ).Interface(), nil
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(s, 10, typ.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(s, 10, typ.Bits())
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(s, typ.Bits())
+	case reflect.Struct:
+		if typ.ConvertibleTo(timeType) { // time.Time、orm.LocalTime
+			for _, layout := range timeLayouts {
+				if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+					return t, nil
+				}
+			}
+			return nil, fmt.Errorf("invalid time %q", s)
+		}
+	}
+	return nil, fmt.Errorf("unsupported filter type %s", typ)
+}
@@ -0,0 +1,97 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/bobacgo/kit/app/db"
+	"github.com/bobacgo/kit/web/orm"
+	"github.com/bobacgo/kit/web/r/codes"
+	"github.com/bobacgo/kit/web/r/status"
+	"gorm.io/driver/sqlite"
+)
+
+type user struct {
+	orm.Model
+	Name     string `json:"name" query:"filter=eq,ne,like,in;sort;select"`
+	Age      int    `json:"age" query:"filter;sort;select"`
+	Passcode string `json:"-"`
+}
+
+func TestParse(t *testing.T) {
+	gdb, err := db.NewDB(sqlite.Open(filepath.Join(t.TempDir(), "query.db")), db.Config{Driver: "sqlite"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.AutoMigrate(&user{}); err != nil {
+		t.Fatal(err)
+	}
+	repo := orm.NewRepository[user](gdb)
+	ctx := context.Background()
+	for i, name := range []string{"bob", "bobby", "alice", "50%", "a,b"} {
+		if err := repo.Create(ctx, &user{Name: name, Age: 10 * (i + 1), Passcode: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		filter, sort string
+		want         string
+	}{
+		{"age>18,name~bob", "-age", "[bobby]"},
+		{"age>=20,age<=40", "name", "[50% alice bobby]"},
+		{"name=bob|alice", "-name", "[bob alice]"},
+		{"name!=bob,age<30", "", "[bobby]"},
+		{"name~0%", "", "[50%]"},
+		{`name=a\,b`, "", "[a,b]"},
+		{"", "-age", "[a,b 50% alice bobby bob]"},
+		{"name=1;DROP TABLE user", "", "[]"}, // 值作为 SQL 参数
+	}
+	for _, c := range cases {
+		q, err := Parse[user](Params{Filter: c.filter, Sort: c.sort})
+		if err != nil {
+			t.Fatal(c.filter, err)
+		}
+		list, err := repo.FindAll(ctx, q)
+		if err != nil {
+			t.Fatal(c.filter, err)
+		}
+		var names []string
+		for _, u := range list {
+			names = append(names, u.Name)
+		}
+		if got := fmt.Sprint(names); got != c.want {
+			t.Errorf("filter %q sort %q: got %s, want %s", c.filter, c.sort, got, c.want)
+		}
+	}
+
+	q, err := ParseValues[user](url.Values{"fields": {"id,name"}, "filter": {"createdAt>2000-01-01"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := repo.FindOne(ctx, q)
+	if err != nil || u.Name == "" || u.Age != 0 || u.Passcode != "" {
+		t.Fatal(u, err)
+	}
+
+	for _, p := range []Params{
+		{Filter: "passcode=x"}, // 没有 query tag
+		{Filter: "age~1"},      // like 只用于字符串
+		{Filter: "age>abc"},    // 类型错误
+		{Filter: "name>a"},     // 操作符不允许
+		{Filter: "1=1 or name"},
+		{Sort: "passcode"},
+		{Fields: "passcode"},
+		{Filter: "createdAt=2000-01-01"},
+	} {
+		_, err := Parse[user](p)
+		var st *status.Status
+		if !errors.As(err, &st) || st.Code != codes.BadRequest {
+			t.Errorf("%+v: %v", p, err)
+		}
+	}
+}
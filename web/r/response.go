@@ -13,6 +13,9 @@ import (
 	pkgvalidator "github.com/go-playground/validator/v10"
 )
 
+// CodeKey 业务码在 gin.Context 中的 key (访问日志读取)
+const CodeKey = "r.code"
+
 type Response[T any] struct {
 	Code codes.Code `json:"code"`
 	Data T          `json:"data"`
@@ -38,5 +41,6 @@ func Reply(c *gin.Context, data any) {
 	default:
 		resp.Data = data
 	}
+	c.Set(CodeKey, resp.Code)
 	c.JSON(httpCode, resp)
 }